@@ -0,0 +1,88 @@
+package i2c
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Func is a set of adapter functionality flags, I2C_FUNC_* of
+// linux/i2c.h.
+type Func uint32
+
+// Adapter functionality flags.
+const (
+	FuncI2C                 Func = 0x00000001
+	Func10BitAddr           Func = 0x00000002
+	FuncProtocolMangling    Func = 0x00000004
+	FuncSMBusPEC            Func = 0x00000008
+	FuncNoStart             Func = 0x00000010
+	FuncSlave               Func = 0x00000020
+	FuncSMBusBlockProcCall  Func = 0x00008000
+	FuncSMBusQuick          Func = 0x00010000
+	FuncSMBusReadByte       Func = 0x00020000
+	FuncSMBusWriteByte      Func = 0x00040000
+	FuncSMBusReadByteData   Func = 0x00080000
+	FuncSMBusWriteByteData  Func = 0x00100000
+	FuncSMBusReadWordData   Func = 0x00200000
+	FuncSMBusWriteWordData  Func = 0x00400000
+	FuncSMBusProcCall       Func = 0x00800000
+	FuncSMBusReadBlockData  Func = 0x01000000
+	FuncSMBusWriteBlockData Func = 0x02000000
+	FuncSMBusReadI2CBlock   Func = 0x04000000
+	FuncSMBusWriteI2CBlock  Func = 0x08000000
+	FuncSMBusHostNotify     Func = 0x10000000
+)
+
+// Has reports whether all the flags of x are set.
+func (f Func) Has(x Func) bool {
+	return f&x == x
+}
+
+// Adapter describes the adapter driving an i2c bus.
+type Adapter struct {
+	Bus  int
+	Name string
+	// Parent is the sysfs path of the device the adapter belongs to, e.g.
+	// /sys/devices/platform/soc/fe804000.i2c, or the mux chip for a mux
+	// channel.
+	Parent string
+	// Funcs are the functionality flags of the adapter, zero when its
+	// /dev/i2c-N node does not exist.
+	Funcs Func
+	// Mux reports whether the bus is a channel of an i2c mux, MuxParent
+	// is then the bus the mux sits on.
+	Mux       bool
+	MuxParent int
+}
+
+// AdapterInfo returns the description of the adapter of bus, read from
+// sysfs and the I2C_FUNCS ioctl. It lets deployment code select buses by
+// name, parent device or mux topology on boards where bus numbers change
+// between kernel versions.
+func AdapterInfo(bus int) (Adapter, error) {
+	a := Adapter{Bus: bus, MuxParent: -1}
+	dir := filepath.Join(sysAdapters, "i2c-"+strconv.Itoa(bus))
+	path, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return a, fmt.Errorf("i2c: bus %d: %w", bus, err)
+	}
+	a.Name = adapterName(bus)
+	a.Parent = filepath.Dir(path)
+	if _, err := os.Lstat(filepath.Join(dir, "mux_device")); err == nil {
+		a.Mux = true
+		for p := a.Parent; p != "/" && p != "."; p = filepath.Dir(p) {
+			if n, ok := busNumber(filepath.Base(p)); ok {
+				a.MuxParent = n
+				break
+			}
+		}
+	}
+	a.Funcs, err = adapterFuncs(bus)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return a, err
+	}
+	return a, nil
+}
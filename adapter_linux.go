@@ -0,0 +1,28 @@
+//go:build !baremetal
+
+package i2c
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+)
+
+// adapterFuncs queries the functionality flags of the adapter of bus.
+func adapterFuncs(bus int) (Func, error) {
+	f, err := os.Open(fmt.Sprintf("/dev/i2c-%d", bus))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return funcsFd(f.Fd())
+}
+
+// funcsFd queries the functionality flags of the adapter of descriptor fd.
+func funcsFd(fd uintptr) (Func, error) {
+	var funcs uint // unsigned long
+	if err := ioctlPtr(fd, i2cFuncs, unsafe.Pointer(&funcs)); err != nil {
+		return 0, err
+	}
+	return Func(funcs), nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux || baremetal
+
+package i2c
+
+// adapterFuncs is only supported on Linux.
+func adapterFuncs(bus int) (Func, error) {
+	return 0, ErrUnsupported
+}
@@ -0,0 +1,44 @@
+package i2c
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrAddress is returned for device addresses which cannot be used.
+var ErrAddress = errors.New("i2c: invalid device address")
+
+// Addr7 returns the 7 bit address a. The package always works with 7 bit
+// addresses, Addr7 only makes the convention explicit at call sites.
+func Addr7(a uint8) uint8 {
+	return a
+}
+
+// Addr8 converts the 8 bit address a, as quoted by datasheets including
+// the read/write bit (e.g. 0x78/0x79), to the 7 bit address (0x3C).
+func Addr8(a uint8) uint8 {
+	return a >> 1
+}
+
+// To8 returns the 8 bit write address of the 7 bit address a. The read
+// address is To8(a)|1.
+func To8(a uint8) uint8 {
+	return a << 1
+}
+
+// ValidateAddr checks that a is a usable 7 bit address. Values above 0x77
+// are rejected as they are most likely 8 bit addresses, and the error
+// suggests the corresponding 7 bit one.
+func ValidateAddr(a uint8) error {
+	switch {
+	case a > 0x7F:
+		return fmt.Errorf("%w 0x%02X: looks like an 8 bit address, use Addr8(0x%02X) = 0x%02X",
+			ErrAddress, a, a, Addr8(a))
+	case a > 0x77:
+		return fmt.Errorf("%w 0x%02X: reserved for 10 bit addressing, if this is an 8 bit address use Addr8(0x%02X) = 0x%02X",
+			ErrAddress, a, a, Addr8(a))
+	case a < 0x03:
+		return fmt.Errorf("%w 0x%02X: reserved for general call and bus control", ErrAddress, a)
+	}
+	return nil
+}
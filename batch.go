@@ -0,0 +1,67 @@
+package i2c
+
+import (
+	"sync"
+	"time"
+)
+
+// Target identifies a device taking part in a batch operation.
+type Target struct {
+	Bus  int
+	Addr uint8
+	// Select, if not nil, is called before the operation to route the bus
+	// to the device, e.g. by switching the channel of a mux.
+	Select func() error
+}
+
+// Result reports the outcome of a batch operation on a target.
+type Result struct {
+	Target   Target
+	Err      error
+	Duration time.Duration
+}
+
+// Batch performs op on every target, such as writing the same EEPROM
+// image or firmware to all devices of a programming station. Targets on
+// different buses are handled in parallel, while targets sharing a bus
+// are handled one at a time in the given order, since mux channel
+// selection is bus wide state. Results are returned in target order.
+func Batch(targets []Target, op func(*I2C) error) []Result {
+	res := make([]Result, len(targets))
+	byBus := make(map[int][]int)
+	for i, t := range targets {
+		byBus[t.Bus] = append(byBus[t.Bus], i)
+	}
+	var wg sync.WaitGroup
+	for _, idx := range byBus {
+		wg.Add(1)
+		go func(idx []int) {
+			defer wg.Done()
+			for _, i := range idx {
+				res[i] = runTarget(targets[i], op)
+			}
+		}(idx)
+	}
+	wg.Wait()
+	return res
+}
+
+func runTarget(t Target, op func(*I2C) error) Result {
+	start := time.Now()
+	err := runOp(t, op)
+	return Result{Target: t, Err: err, Duration: time.Since(start)}
+}
+
+func runOp(t Target, op func(*I2C) error) error {
+	if t.Select != nil {
+		if err := t.Select(); err != nil {
+			return err
+		}
+	}
+	v, err := NewI2C(t.Addr, t.Bus)
+	if err != nil {
+		return err
+	}
+	defer v.Close()
+	return op(v)
+}
@@ -0,0 +1,43 @@
+package i2c
+
+import "errors"
+
+// ErrBCD is returned for bytes which are not valid packed BCD, or values
+// which cannot be encoded in a single BCD byte.
+var ErrBCD = errors.New("i2c: invalid BCD value")
+
+// FromBCD decodes the packed BCD byte b (e.g. 0x59) to binary (59).
+func FromBCD(b byte) (byte, error) {
+	if b&0x0F > 9 || b>>4 > 9 {
+		return 0, ErrBCD
+	}
+	return b>>4*10 + b&0x0F, nil
+}
+
+// ToBCD encodes n, which must be below 100, to packed BCD.
+func ToBCD(n byte) (byte, error) {
+	if n > 99 {
+		return 0, ErrBCD
+	}
+	return n/10<<4 | n%10, nil
+}
+
+// ReadRegBCD read packed BCD byte from register reg, as used by RTC
+// chips. Bits outside of mask (e.g. flags sharing the register) are
+// cleared before decoding.
+func (v *I2C) ReadRegBCD(reg byte, mask byte) (byte, error) {
+	b, err := v.ReadRegU8(reg)
+	if err != nil {
+		return 0, err
+	}
+	return FromBCD(b & mask)
+}
+
+// WriteRegBCD write value as packed BCD byte to register reg.
+func (v *I2C) WriteRegBCD(reg byte, value byte) error {
+	b, err := ToBCD(value)
+	if err != nil {
+		return err
+	}
+	return v.WriteRegU8(reg, b)
+}
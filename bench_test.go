@@ -0,0 +1,55 @@
+package i2c
+
+import "testing"
+
+// nopConn is a Conn whose transfers do nothing.
+type nopConn struct{}
+
+func (nopConn) Write(p []byte) (int, error) { return len(p), nil }
+func (nopConn) Read(p []byte) (int, error)  { return len(p), nil }
+func (nopConn) Close() error                { return nil }
+
+func BenchmarkReadRegU8(b *testing.B) {
+	v := NewI2CConn(nopConn{}, 0x40)
+	defer v.Close()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := v.ReadRegU8(0x10); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadRegU16(b *testing.B) {
+	v := NewI2CConn(nopConn{}, 0x40)
+	defer v.Close()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := v.ReadRegU16BE(0x10); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWriteReg(b *testing.B) {
+	v := NewI2CConn(nopConn{}, 0x40)
+	defer v.Close()
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := v.WriteRegU16BE(0x10, 0x1234); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadRegBytesInto(b *testing.B) {
+	v := NewI2CConn(nopConn{}, 0x40)
+	defer v.Close()
+	buf := make([]byte, 6)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := v.ReadRegBytesInto(0x10, buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
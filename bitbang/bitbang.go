@@ -0,0 +1,327 @@
+// Package bitbang implements an i2c bus master driving two GPIO lines in
+// software, for boards where no hardware controller reaches the pins.
+//
+//	pins, err := bitbang.OpenGPIO("/dev/gpiochip0", 5, 6) // SCL, SDA
+//	if err != nil {
+//		return err
+//	}
+//	bus := bitbang.NewBus(pins, bitbang.Config{Freq: 50000})
+//	defer bus.Close()
+//	v := bus.Open(0x76)
+//
+// Lines are driven open drain and need pull-up resistors. The clock is
+// timed by busy waiting, so a transfer keeps a CPU busy, and actual rates
+// depend on the GPIO access latency.
+package bitbang
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+var (
+	// ErrNack is returned when the device does not acknowledge its
+	// address or a written byte. On Linux i2c.IsNack reports true for it.
+	ErrNack error = syscall.ENXIO
+	// ErrStretch is returned when a device holds the clock low longer than
+	// the stretch timeout.
+	ErrStretch = errors.New("bitbang: clock stretch timeout")
+)
+
+// Pins drives the SCL and SDA lines. Setting a line high releases it,
+// letting the pull-up raise it unless a device holds it low; reading it
+// returns its actual level.
+type Pins interface {
+	SetSCL(high bool) error
+	SetSDA(high bool) error
+	SCL() (bool, error)
+	SDA() (bool, error)
+}
+
+// Config configures a Bus.
+type Config struct {
+	// Freq is the clock frequency in Hz, 100kHz when zero.
+	Freq int
+	// Stretch is how long devices may hold the clock low, 10ms when zero.
+	Stretch time.Duration
+}
+
+// Bus is an i2c bus driven by bit-banging Pins.
+type Bus struct {
+	mu      sync.Mutex
+	pins    Pins
+	half    time.Duration
+	stretch time.Duration
+}
+
+// NewBus returns a Bus driving pins.
+func NewBus(pins Pins, cfg Config) *Bus {
+	if cfg.Freq <= 0 {
+		cfg.Freq = 100000
+	}
+	if cfg.Stretch <= 0 {
+		cfg.Stretch = 10 * time.Millisecond
+	}
+	return &Bus{pins: pins, half: time.Second / time.Duration(2*cfg.Freq), stretch: cfg.Stretch}
+}
+
+// Close releases the pins, if they implement io.Closer.
+func (b *Bus) Close() error {
+	if c, ok := b.pins.(interface{ Close() error }); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Conn returns a transport to addr on the bus, for i2c.NewI2CConn.
+func (b *Bus) Conn(addr uint8) *Conn {
+	return &Conn{bus: b, addr: addr}
+}
+
+// Open returns a connection to the device at addr on the bus.
+func (b *Bus) Open(addr uint8) *i2c.I2C {
+	return i2c.NewI2CConn(b.Conn(addr), addr)
+}
+
+// delay busy waits half a clock period, sleeping is far too coarse.
+func (b *Bus) delay() {
+	for t := time.Now(); time.Since(t) < b.half; {
+	}
+}
+
+// sclHigh releases the clock and waits for devices stretching it.
+func (b *Bus) sclHigh() error {
+	if err := b.pins.SetSCL(true); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(b.stretch)
+	for {
+		high, err := b.pins.SCL()
+		if err != nil || high {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return ErrStretch
+		}
+	}
+}
+
+func (b *Bus) start() error {
+	if err := b.pins.SetSDA(true); err != nil {
+		return err
+	}
+	if err := b.sclHigh(); err != nil {
+		return err
+	}
+	b.delay()
+	if err := b.pins.SetSDA(false); err != nil {
+		return err
+	}
+	b.delay()
+	return b.pins.SetSCL(false)
+}
+
+func (b *Bus) stop() error {
+	if err := b.pins.SetSDA(false); err != nil {
+		return err
+	}
+	b.delay()
+	if err := b.sclHigh(); err != nil {
+		return err
+	}
+	b.delay()
+	if err := b.pins.SetSDA(true); err != nil {
+		return err
+	}
+	b.delay()
+	return nil
+}
+
+// clock sets SDA to bit, pulses the clock and returns the SDA level
+// sampled while the clock is high.
+func (b *Bus) clock(bit bool) (bool, error) {
+	if err := b.pins.SetSDA(bit); err != nil {
+		return false, err
+	}
+	b.delay()
+	if err := b.sclHigh(); err != nil {
+		return false, err
+	}
+	b.delay()
+	v, err := b.pins.SDA()
+	if err != nil {
+		return false, err
+	}
+	return v, b.pins.SetSCL(false)
+}
+
+// writeByte sends c and reports whether the device acknowledged it.
+func (b *Bus) writeByte(c byte) (bool, error) {
+	for i := 7; i >= 0; i-- {
+		if _, err := b.clock(c>>i&1 != 0); err != nil {
+			return false, err
+		}
+	}
+	nack, err := b.clock(true)
+	return !nack, err
+}
+
+// readByte receives a byte, acknowledging it when ack is set.
+func (b *Bus) readByte(ack bool) (byte, error) {
+	var c byte
+	for i := 0; i < 8; i++ {
+		v, err := b.clock(true)
+		if err != nil {
+			return 0, err
+		}
+		c <<= 1
+		if v {
+			c |= 1
+		}
+	}
+	_, err := b.clock(!ack)
+	return c, err
+}
+
+// begin sends a start, or a repeated start within a transfer, and the
+// address byte a, and fails with ErrNack when the device does not
+// acknowledge it.
+func (b *Bus) begin(a byte) error {
+	if err := b.start(); err != nil {
+		return err
+	}
+	ack, err := b.writeByte(a)
+	if err == nil && !ack {
+		err = ErrNack
+	}
+	return err
+}
+
+// write sends p, stopping at the first byte not acknowledged.
+func (b *Bus) write(p []byte) (int, error) {
+	for i, x := range p {
+		ack, err := b.writeByte(x)
+		if err != nil {
+			return i, err
+		}
+		if !ack {
+			return i, ErrNack
+		}
+	}
+	return len(p), nil
+}
+
+// read receives p, acknowledging every byte but the last.
+func (b *Bus) read(p []byte) (int, error) {
+	for i := range p {
+		x, err := b.readByte(i < len(p)-1)
+		if err != nil {
+			return i, err
+		}
+		p[i] = x
+	}
+	return len(p), nil
+}
+
+// transfer runs fn between a start and a stop condition, after
+// addressing the device.
+func (b *Bus) transfer(addr byte, fn func() (int, error)) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n, err := 0, b.begin(addr)
+	if err == nil {
+		n, err = fn()
+	}
+	if serr := b.stop(); err == nil {
+		err = serr
+	}
+	return n, err
+}
+
+// Tx writes w to the device at addr then reads len(r) bytes into r, with
+// a repeated start in between and a single stop at the end, implementing
+// i2c.Bus. Either may be empty.
+func (b *Bus) Tx(addr uint16, w, r []byte) error {
+	if addr > 0x7F {
+		return fmt.Errorf("%w: 10 bit address 0x%03X", i2c.ErrUnsupported, addr)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := b.tx(byte(addr), w, r)
+	if serr := b.stop(); err == nil {
+		err = serr
+	}
+	return err
+}
+
+func (b *Bus) tx(addr byte, w, r []byte) error {
+	if len(w) > 0 || len(r) == 0 {
+		if err := b.begin(addr << 1); err != nil {
+			return err
+		}
+		if _, err := b.write(w); err != nil {
+			return err
+		}
+	}
+	if len(r) == 0 {
+		return nil
+	}
+	if err := b.begin(addr<<1 | 1); err != nil {
+		return err
+	}
+	_, err := b.read(r)
+	return err
+}
+
+// Conn is an i2c.Conn to an address of a Bus. It implements
+// i2c.Addresser, so connections can be retargeted with SetAddr.
+type Conn struct {
+	bus  *Bus
+	mu   sync.Mutex
+	addr uint8
+}
+
+func (c *Conn) target() uint8 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.addr
+}
+
+// Write sends p to the device in one transfer.
+func (c *Conn) Write(p []byte) (int, error) {
+	return c.bus.transfer(c.target()<<1, func() (int, error) {
+		return c.bus.write(p)
+	})
+}
+
+// Read receives p from the device in one transfer.
+func (c *Conn) Read(p []byte) (int, error) {
+	return c.bus.transfer(c.target()<<1|1, func() (int, error) {
+		return c.bus.read(p)
+	})
+}
+
+// Tx writes w to the device then reads len(r) bytes into r in one
+// transfer with a repeated start, so that register reads and Tx calls of
+// the connection are combined transfers.
+func (c *Conn) Tx(w, r []byte) error {
+	return c.bus.Tx(uint16(c.target()), w, r)
+}
+
+// SetAddr retargets the connection to addr.
+func (c *Conn) SetAddr(addr uint8) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addr = addr
+	return nil
+}
+
+// Close does nothing, the pins are released by closing the Bus.
+func (c *Conn) Close() error {
+	return nil
+}
@@ -0,0 +1,153 @@
+package bitbang
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// GPIO character device uAPI v2, from linux/gpio.h.
+const (
+	gpioLinesMax    = 64
+	gpioNameSize    = 32
+	gpioAttrsMax    = 10
+	gpioFlagInput   = 1 << 2
+	gpioFlagOutput  = 1 << 3
+	gpioFlagOpenDrn = 1 << 6
+	gpioFlagPullUp  = 1 << 8
+)
+
+type gpioLineValues struct {
+	bits uint64
+	mask uint64
+}
+
+type gpioLineAttribute struct {
+	id      uint32
+	padding uint32
+	value   uint64
+}
+
+type gpioLineConfigAttribute struct {
+	attr gpioLineAttribute
+	mask uint64
+}
+
+type gpioLineConfig struct {
+	flags    uint64
+	numAttrs uint32
+	padding  [5]uint32
+	attrs    [gpioAttrsMax]gpioLineConfigAttribute
+}
+
+type gpioLineRequest struct {
+	offsets         [gpioLinesMax]uint32
+	consumer        [gpioNameSize]byte
+	config          gpioLineConfig
+	numLines        uint32
+	eventBufferSize uint32
+	padding         [5]uint32
+	fd              int32
+}
+
+// The layouts above must match the kernel ones, which use 8 byte aligned
+// 64 bit fields, on every GOARCH.
+var _ = [1]struct{}{}[unsafe.Sizeof(gpioLineConfig{})-272]
+var _ = [1]struct{}{}[unsafe.Sizeof(gpioLineRequest{})-592]
+var _ = [1]struct{}{}[unsafe.Offsetof(gpioLineRequest{}.config)-288]
+
+// iowr returns the _IOWR request number nr of the GPIO ioctls for an
+// argument of size bytes. The direction bits differ on some GOARCHes.
+func iowr(nr, size uintptr) uintptr {
+	shift, rw := uintptr(30), uintptr(3)
+	switch runtime.GOARCH {
+	case "mips", "mipsle", "mips64", "mips64le", "ppc64", "ppc64le", "sparc64":
+		shift, rw = 29, 6
+	}
+	return rw<<shift | size<<16 | 0xB4<<8 | nr
+}
+
+var (
+	gpioGetLine   = iowr(0x07, unsafe.Sizeof(gpioLineRequest{}))
+	gpioGetValues = iowr(0x0E, unsafe.Sizeof(gpioLineValues{}))
+	gpioSetValues = iowr(0x0F, unsafe.Sizeof(gpioLineValues{}))
+)
+
+// Lines of the GPIO request.
+const (
+	lineSCL = 1 << 0
+	lineSDA = 1 << 1
+)
+
+// GPIO drives SCL and SDA through the GPIO character device.
+type GPIO struct {
+	f *os.File
+}
+
+// OpenGPIO requests lines scl and sda of the GPIO chip (e.g.
+// "/dev/gpiochip0") as open drain outputs with pull-ups enabled, when the
+// chip supports them.
+func OpenGPIO(chip string, scl, sda uint32) (*GPIO, error) {
+	c, err := os.Open(chip)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	req := gpioLineRequest{numLines: 2}
+	req.offsets[0], req.offsets[1] = scl, sda
+	copy(req.consumer[:], "i2c-go bitbang")
+	req.config.flags = gpioFlagOutput | gpioFlagOpenDrn | gpioFlagPullUp
+	// start with both lines released, the bus idle state
+	req.config.numAttrs = 1
+	req.config.attrs[0] = gpioLineConfigAttribute{
+		attr: gpioLineAttribute{id: 2, value: lineSCL | lineSDA}, // GPIO_V2_LINE_ATTR_ID_OUTPUT_VALUES
+		mask: lineSCL | lineSDA,
+	}
+	if err := ioctl(c.Fd(), gpioGetLine, unsafe.Pointer(&req)); err != nil {
+		return nil, fmt.Errorf("bitbang: request lines %d, %d of %s: %w", scl, sda, chip, err)
+	}
+	return &GPIO{f: os.NewFile(uintptr(req.fd), chip+" lines")}, nil
+}
+
+func ioctl(fd, req uintptr, arg unsafe.Pointer) error {
+	_, _, e := unix.Syscall(unix.SYS_IOCTL, fd, req, uintptr(arg))
+	runtime.KeepAlive(arg)
+	if e != 0 {
+		return e
+	}
+	return nil
+}
+
+func (g *GPIO) set(line uint64, high bool) error {
+	v := gpioLineValues{mask: line}
+	if high {
+		v.bits = line
+	}
+	return ioctl(g.f.Fd(), gpioSetValues, unsafe.Pointer(&v))
+}
+
+func (g *GPIO) get(line uint64) (bool, error) {
+	v := gpioLineValues{mask: line}
+	err := ioctl(g.f.Fd(), gpioGetValues, unsafe.Pointer(&v))
+	return v.bits&line != 0, err
+}
+
+// SetSCL drives SCL low, or releases it.
+func (g *GPIO) SetSCL(high bool) error { return g.set(lineSCL, high) }
+
+// SetSDA drives SDA low, or releases it.
+func (g *GPIO) SetSDA(high bool) error { return g.set(lineSDA, high) }
+
+// SCL reads the level of SCL.
+func (g *GPIO) SCL() (bool, error) { return g.get(lineSCL) }
+
+// SDA reads the level of SDA.
+func (g *GPIO) SDA() (bool, error) { return g.get(lineSDA) }
+
+// Close releases the lines.
+func (g *GPIO) Close() error {
+	return g.f.Close()
+}
@@ -0,0 +1,28 @@
+//go:build !linux
+
+package bitbang
+
+import i2c "github.com/fedeonline/i2c-go"
+
+// GPIO drives SCL and SDA through the GPIO character device.
+type GPIO struct{}
+
+// OpenGPIO is only supported on Linux.
+func OpenGPIO(chip string, scl, sda uint32) (*GPIO, error) {
+	return nil, i2c.ErrUnsupported
+}
+
+// SetSCL drives SCL low, or releases it.
+func (g *GPIO) SetSCL(high bool) error { return i2c.ErrUnsupported }
+
+// SetSDA drives SDA low, or releases it.
+func (g *GPIO) SetSDA(high bool) error { return i2c.ErrUnsupported }
+
+// SCL reads the level of SCL.
+func (g *GPIO) SCL() (bool, error) { return false, i2c.ErrUnsupported }
+
+// SDA reads the level of SDA.
+func (g *GPIO) SDA() (bool, error) { return false, i2c.ErrUnsupported }
+
+// Close releases the lines.
+func (g *GPIO) Close() error { return nil }
@@ -0,0 +1,30 @@
+package i2c
+
+import (
+	"errors"
+)
+
+// ErrFieldRange is returned when a value does not fit in a bit field.
+var ErrFieldRange = errors.New("i2c: value out of bit field range")
+
+// ReadRegBits read the bit field selected by mask from register reg,
+// shifted right by shift. mask is in register position, e.g. 0x38 and a
+// shift of 3 select bits 3 to 5.
+func (v *I2C) ReadRegBits(reg byte, mask byte, shift uint) (byte, error) {
+	r, err := v.ReadRegU8(reg)
+	if err != nil {
+		return 0, err
+	}
+	return r & mask >> shift, nil
+}
+
+// WriteRegBits write value to the bit field selected by mask of register
+// reg, leaving the other bits untouched, as a single read-modify-write
+// operation. value is shifted left by shift and must fit in mask.
+func (v *I2C) WriteRegBits(reg byte, mask byte, shift uint, value byte) error {
+	f := value << shift
+	if f&^mask != 0 || f>>shift != value {
+		return ErrFieldRange
+	}
+	return v.UpdateRegU8(reg, mask, f)
+}
@@ -0,0 +1,73 @@
+package i2c
+
+import "sync"
+
+// Bus is a bus master performing combined transfers: Tx writes w to the
+// device at addr, then reads len(r) bytes into r after a repeated start.
+// It is the interface of TinyGo's machine.I2C and of periph.io buses, so
+// drivers written against I2C run on microcontrollers too:
+//
+//	machine.I2C0.Configure(machine.I2CConfig{})
+//	dev := i2c.OpenBus(machine.I2C0, 0x76)
+type Bus interface {
+	Tx(addr uint16, w, r []byte) error
+}
+
+// BusConn is a Conn talking through a Bus. It implements Addresser, so
+// connections can be retargeted with SetAddr.
+type BusConn struct {
+	bus  Bus
+	mu   sync.Mutex
+	addr uint8
+}
+
+// NewBusConn returns a transport to addr on b, for NewI2CConn.
+func NewBusConn(b Bus, addr uint8) *BusConn {
+	return &BusConn{bus: b, addr: addr}
+}
+
+// OpenBus returns a connection to the device at addr on b. Tx calls and
+// register reads of the connection are combined transfers of b.
+func OpenBus(b Bus, addr uint8) *I2C {
+	return NewI2CConn(NewBusConn(b, addr), addr)
+}
+
+func (c *BusConn) target() uint16 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return uint16(c.addr)
+}
+
+// Write sends p to the device.
+func (c *BusConn) Write(p []byte) (int, error) {
+	if err := c.bus.Tx(c.target(), p, nil); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Read receives p from the device.
+func (c *BusConn) Read(p []byte) (int, error) {
+	if err := c.bus.Tx(c.target(), nil, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Tx writes w then reads r in one combined transfer.
+func (c *BusConn) Tx(w, r []byte) error {
+	return c.bus.Tx(c.target(), w, r)
+}
+
+// SetAddr retargets the connection to addr.
+func (c *BusConn) SetAddr(addr uint8) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addr = addr
+	return nil
+}
+
+// Close does nothing, the bus is owned by the caller.
+func (c *BusConn) Close() error {
+	return nil
+}
@@ -0,0 +1,84 @@
+package i2c
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sysAdapters is the sysfs directory listing the i2c adapters.
+const sysAdapters = "/sys/class/i2c-adapter"
+
+// BusInfo describes an i2c bus of the system.
+type BusInfo struct {
+	Bus int
+	// Name is the adapter name, e.g. "bcm2835 (i2c@7e804000)", empty when
+	// sysfs is not available.
+	Name string
+	// Dev reports whether the /dev/i2c-N node exists, i.e. whether the
+	// bus can be opened with NewI2C.
+	Dev bool
+}
+
+// ListBuses returns the i2c buses of the system sorted by number, found
+// in /dev and /sys/class/i2c-adapter, so that applications can pick a
+// bus by adapter name rather than hard-coding its number. It returns an
+// empty list when there are none, e.g. on systems other than Linux.
+func ListBuses() ([]BusInfo, error) {
+	found := map[int]*BusInfo{}
+	get := func(name string) *BusInfo {
+		n, ok := busNumber(name)
+		if !ok {
+			return nil
+		}
+		if found[n] == nil {
+			found[n] = &BusInfo{Bus: n}
+		}
+		return found[n]
+	}
+	devs, err := filepath.Glob("/dev/i2c-*")
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range devs {
+		if b := get(filepath.Base(d)); b != nil {
+			b.Dev = true
+		}
+	}
+	ents, err := os.ReadDir(sysAdapters)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, e := range ents {
+		if b := get(e.Name()); b != nil {
+			b.Name = adapterName(b.Bus)
+		}
+	}
+	buses := make([]BusInfo, 0, len(found))
+	for _, b := range found {
+		buses = append(buses, *b)
+	}
+	sort.Slice(buses, func(i, j int) bool { return buses[i].Bus < buses[j].Bus })
+	return buses, nil
+}
+
+// adapterName returns the sysfs name of the adapter of bus, or "".
+func adapterName(bus int) string {
+	b, err := os.ReadFile(filepath.Join(sysAdapters, "i2c-"+strconv.Itoa(bus), "name"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// busNumber parses the bus number of an "i2c-N" device name.
+func busNumber(name string) (int, bool) {
+	s, ok := strings.CutPrefix(name, "i2c-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n >= 0
+}
@@ -0,0 +1,97 @@
+package i2c
+
+import "sync"
+
+// regCache is a shadow copy of the registers read from or written to the
+// device. Only registers declared static are served from it.
+type regCache struct {
+	mu     sync.Mutex
+	on     bool
+	val    [regSpace]byte
+	valid  [regSpace]bool
+	static [regSpace]bool
+}
+
+// CacheRegs declares regs as static, i.e. their value only changes when
+// written through this connection (configuration, calibration or
+// identification registers). Reads covering only static registers are
+// then served from a shadow copy once the registers were read or written.
+func (v *I2C) CacheRegs(regs ...byte) {
+	c := &v.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, r := range regs {
+		c.static[r] = true
+	}
+	c.on = true
+}
+
+// Invalidate drops the shadow copy of regs, so that the next read goes
+// to the device.
+func (v *I2C) Invalidate(regs ...byte) {
+	c := &v.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, r := range regs {
+		c.valid[r] = false
+	}
+}
+
+// InvalidateAll drops the whole shadow copy, e.g. after a device reset.
+func (v *I2C) InvalidateAll() {
+	c := &v.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.valid = [regSpace]bool{}
+}
+
+// load fills buf from the shadow copy starting at reg, reporting whether
+// all the registers were static and known.
+func (c *regCache) load(reg byte, buf []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.on {
+		return false
+	}
+	for i := range buf {
+		r := reg + byte(i)
+		if !c.static[r] || !c.valid[r] {
+			return false
+		}
+	}
+	for i := range buf {
+		buf[i] = c.val[reg+byte(i)]
+	}
+	return true
+}
+
+// store records buf as the content of the registers starting at reg.
+func (c *regCache) store(reg byte, buf []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.on {
+		return
+	}
+	for i, b := range buf {
+		c.val[reg+byte(i)] = b
+		c.valid[reg+byte(i)] = true
+	}
+}
+
+// drop invalidates the n registers starting at reg.
+func (c *regCache) drop(reg byte, n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := 0; i < n; i++ {
+		c.valid[reg+byte(i)] = false
+	}
+}
+
+// reset drops the whole cache, static declarations included.
+func (c *regCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.on = false
+	c.valid = [regSpace]bool{}
+	c.static = [regSpace]bool{}
+}
@@ -0,0 +1,28 @@
+package i2c
+
+import "testing"
+
+func TestCacheFailedWrite(t *testing.T) {
+	c := &flakyConn{DryRun: NewDryRun(map[byte]byte{0x10: 0xAB})}
+	v := NewI2CConn(c, 0x40)
+	defer v.Close()
+	v.CacheRegs(0x10)
+	if err := v.WriteRegU8(0x10, 0xCD); err != nil {
+		t.Fatal(err)
+	}
+	c.fails = 1
+	if err := v.WriteRegU8(0x10, 0xEF); err == nil {
+		t.Fatal("failing write succeeded")
+	}
+	writes := len(c.Writes())
+	b, err := v.ReadRegU8(0x10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Writes()) == writes {
+		t.Error("read served from the cache after a failed write")
+	}
+	if b != 0xCD {
+		t.Errorf("got 0x%02X, want 0xCD", b)
+	}
+}
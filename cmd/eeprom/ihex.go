@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Intel HEX record types.
+const (
+	recData        = 0x00
+	recEOF         = 0x01
+	recExtSegment  = 0x02
+	recStartSeg    = 0x03
+	recExtLinear   = 0x04
+	recStartLinear = 0x05
+)
+
+// segment is a contiguous run of image bytes starting at addr.
+type segment struct {
+	addr int
+	data []byte
+}
+
+// parseHex parses an Intel HEX image into its contiguous segments,
+// sorted as they appear in the file.
+func parseHex(r io.Reader) ([]segment, error) {
+	var segs []segment
+	base := 0
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		s := strings.TrimSpace(sc.Text())
+		if s == "" {
+			continue
+		}
+		if s[0] != ':' {
+			return nil, fmt.Errorf("line %d: missing start code", line)
+		}
+		rec, err := hex.DecodeString(s[1:])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(rec) < 5 || len(rec) != 5+int(rec[0]) {
+			return nil, fmt.Errorf("line %d: bad record length", line)
+		}
+		var sum byte
+		for _, c := range rec {
+			sum += c
+		}
+		if sum != 0 {
+			return nil, fmt.Errorf("line %d: bad checksum", line)
+		}
+		data := rec[4 : len(rec)-1]
+		switch rec[3] {
+		case recData:
+			addr := base + (int(rec[1])<<8 | int(rec[2]))
+			if n := len(segs); n > 0 && segs[n-1].addr+len(segs[n-1].data) == addr {
+				segs[n-1].data = append(segs[n-1].data, data...)
+			} else {
+				segs = append(segs, segment{addr: addr, data: append([]byte(nil), data...)})
+			}
+		case recEOF:
+			return segs, nil
+		case recExtSegment, recExtLinear:
+			if len(data) != 2 {
+				return nil, fmt.Errorf("line %d: bad extended address", line)
+			}
+			base = int(data[0])<<8 | int(data[1])
+			if rec[3] == recExtSegment {
+				base <<= 4
+			} else {
+				base <<= 16
+			}
+		case recStartSeg, recStartLinear:
+			// entry points are meaningless for a memory image
+		default:
+			return nil, fmt.Errorf("line %d: unknown record type %02X", line, rec[3])
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("missing end of file record")
+}
+
+// writeHex writes data, starting at addr, as an Intel HEX image of 16
+// byte data records.
+func writeHex(w io.Writer, addr int, data []byte) error {
+	bw := bufio.NewWriter(w)
+	upper := -1
+	for off, n := 0, 0; off < len(data); off += n {
+		a := addr + off
+		if a>>16 != upper {
+			upper = a >> 16
+			writeRecord(bw, 0, recExtLinear, []byte{byte(upper >> 8), byte(upper)})
+		}
+		// records do not cross 64KiB boundaries
+		n = min(16, len(data)-off, 0x10000-a&0xFFFF)
+		writeRecord(bw, a&0xFFFF, recData, data[off:off+n])
+	}
+	writeRecord(bw, 0, recEOF, nil)
+	return bw.Flush()
+}
+
+func writeRecord(w *bufio.Writer, addr int, typ byte, data []byte) {
+	rec := append([]byte{byte(len(data)), byte(addr >> 8), byte(addr), typ}, data...)
+	var sum byte
+	for _, c := range rec {
+		sum += c
+	}
+	rec = append(rec, -sum)
+	fmt.Fprintf(w, ":%s\n", strings.ToUpper(hex.EncodeToString(rec)))
+}
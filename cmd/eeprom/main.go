@@ -0,0 +1,249 @@
+// Command eeprom reads, writes and verifies 24Cxx i2c EEPROMs, from and
+// to raw binary or Intel HEX images.
+//
+//	eeprom -type 24c256 read 1 0x50 dump.bin
+//	eeprom -type 24c02 write 1 0x50 config.hex
+//	eeprom -size 4096 -page 32 -addrw 2 verify 1 0x50 image.bin
+//
+// The bus is a number, an i2c-N name, a device tree alias or a node path.
+// The memory geometry comes from -type, or from -size, -page and -addrw
+// which also override the ones of the type. Parts addressing more memory
+// than their address bytes cover, such as the 24c04 to 24c16, respond on
+// consecutive device addresses starting from addr.
+//
+// The image format is Intel HEX for files ending in .hex, .ihex or .ihx
+// and raw binary otherwise, unless set with -format. Binary images are
+// placed at -offset; HEX images at their own addresses, shifted by
+// -offset.
+//
+// Writes are page aligned and wait for the end of each write cycle by
+// polling the memory until it acknowledges again. Pages already holding
+// their data are not rewritten. Every page is read back: a memory which
+// keeps its previous contents is reported as write protected.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fedeonline/i2c-go/devices/at24"
+	"github.com/fedeonline/i2c-go/internal/cli"
+)
+
+type options struct {
+	at24.Geometry
+	format  string
+	offset  int
+	timeout time.Duration
+	quiet   bool
+}
+
+func main() {
+	var o options
+	typ := flag.String("type", "", "memory type, e.g. 24c02, 24c256")
+	flag.IntVar(&o.Size, "size", 0, "memory size in bytes")
+	flag.IntVar(&o.Page, "page", 0, "page size in bytes")
+	flag.IntVar(&o.AddrW, "addrw", 0, "memory address width in bytes, 1 or 2")
+	flag.StringVar(&o.format, "format", "auto", "image format: auto, bin or hex")
+	flag.IntVar(&o.offset, "offset", 0, "memory offset of the image")
+	flag.DurationVar(&o.timeout, "timeout", 50*time.Millisecond, "write cycle timeout")
+	flag.BoolVar(&o.quiet, "q", false, "do not report progress")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: eeprom [flags] read|write|verify bus addr file\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 4 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Args(), *typ, o); err != nil {
+		fmt.Fprintf(os.Stderr, "eeprom: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, typ string, o options) error {
+	g, err := resolveGeometry(typ, o.Geometry)
+	if err != nil {
+		return err
+	}
+	o.Geometry = g
+	if o.offset < 0 || o.offset >= o.Size {
+		return fmt.Errorf("offset 0x%X: outside of the memory", o.offset)
+	}
+	if o.format == "auto" {
+		switch strings.ToLower(filepath.Ext(args[3])) {
+		case ".hex", ".ihex", ".ihx":
+			o.format = "hex"
+		default:
+			o.format = "bin"
+		}
+	}
+	if o.format != "bin" && o.format != "hex" {
+		return fmt.Errorf("format %q: must be auto, bin or hex", o.format)
+	}
+	v, err := cli.Open(args[1], args[2])
+	if err != nil {
+		return err
+	}
+	defer v.Close()
+	e, err := at24.New(v, o.Geometry)
+	if err != nil {
+		return err
+	}
+	e.SetWriteTimeout(o.timeout)
+	switch args[0] {
+	case "read":
+		return readImage(e, args[3], o)
+	case "write":
+		return writeImage(e, args[3], o)
+	case "verify":
+		return verifyImage(e, args[3], o)
+	}
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+// resolveGeometry returns the geometry of typ overridden by the non zero
+// fields of g.
+func resolveGeometry(typ string, g at24.Geometry) (at24.Geometry, error) {
+	var r at24.Geometry
+	if typ != "" {
+		var ok bool
+		if r, ok = at24.Chips[strings.ToLower(typ)]; !ok {
+			names := make([]string, 0, len(at24.Chips))
+			for n := range at24.Chips {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return r, fmt.Errorf("unknown type %q, known types: %s", typ, strings.Join(names, ", "))
+		}
+	}
+	if g.Size != 0 {
+		r.Size = g.Size
+	}
+	if g.Page != 0 {
+		r.Page = g.Page
+	}
+	if g.AddrW != 0 {
+		r.AddrW = g.AddrW
+	}
+	switch {
+	case r.Size <= 0:
+		return r, errors.New("missing memory size, use -type or -size")
+	case r.Page <= 0:
+		return r, errors.New("missing page size, use -type or -page")
+	case r.AddrW == 0:
+		r.AddrW = 1
+		if r.Size > 2048 {
+			r.AddrW = 2
+		}
+	case r.AddrW != 1 && r.AddrW != 2:
+		return r, fmt.Errorf("address width %d: must be 1 or 2", r.AddrW)
+	}
+	return r, nil
+}
+
+// loadImage returns the segments of the image file.
+func loadImage(path string, o options) ([]segment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	segs := []segment{{addr: 0, data: data}}
+	if o.format == "hex" {
+		if segs, err = parseHex(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	for i := range segs {
+		segs[i].addr += o.offset
+		if s := segs[i]; s.addr+len(s.data) > o.Size {
+			return nil, fmt.Errorf("%s: image data at 0x%X-0x%X beyond the memory size 0x%X",
+				path, s.addr, s.addr+len(s.data)-1, o.Size)
+		}
+	}
+	return segs, nil
+}
+
+func readImage(e *at24.Device, path string, o options) error {
+	buf := make([]byte, o.Size-o.offset)
+	if _, err := e.ReadAt(buf, int64(o.offset)); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if o.format == "hex" {
+		err = writeHex(f, o.offset, buf)
+	} else {
+		_, err = f.Write(buf)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func writeImage(e *at24.Device, path string, o options) error {
+	segs, err := loadImage(path, o)
+	if err != nil {
+		return err
+	}
+	total, pages := 0, 0
+	for _, s := range segs {
+		var progress func(done, total int)
+		if !o.quiet {
+			progress = func(done, total int) {
+				fmt.Fprintf(os.Stderr, "\r0x%06X: %d/%d bytes", s.addr, done, total)
+			}
+		}
+		n, err := e.Update(s.addr, s.data, progress)
+		pages += n
+		if !o.quiet {
+			fmt.Fprintln(os.Stderr)
+		}
+		if err != nil {
+			return err
+		}
+		total += len(s.data)
+	}
+	if !o.quiet {
+		fmt.Fprintf(os.Stderr, "%d bytes programmed, %d page writes\n", total, pages)
+	}
+	return nil
+}
+
+func verifyImage(e *at24.Device, path string, o options) error {
+	segs, err := loadImage(path, o)
+	if err != nil {
+		return err
+	}
+	mismatches := 0
+	for _, s := range segs {
+		got := make([]byte, len(s.data))
+		if _, err := e.ReadAt(got, int64(s.addr)); err != nil {
+			return err
+		}
+		for i := range got {
+			if got[i] != s.data[i] {
+				if mismatches < 16 {
+					fmt.Fprintf(os.Stderr, "0x%06X: read 0x%02X, want 0x%02X\n", s.addr+i, got[i], s.data[i])
+				}
+				mismatches++
+			}
+		}
+	}
+	if mismatches > 0 {
+		return fmt.Errorf("verify failed: %d bytes differ", mismatches)
+	}
+	return nil
+}
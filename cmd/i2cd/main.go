@@ -0,0 +1,96 @@
+// Command i2cd shares the i2c buses of the host over HTTP, for clients
+// of package remote, turning a Linux board into a shared lab i2c server.
+//
+//	i2cd -config /etc/i2cd.json
+//
+// The configuration lists the listen address and the clients with their
+// token and the buses and addresses they may access:
+//
+//	{
+//	  "listen": ":8420",
+//	  "clients": [
+//	    {"name": "ci", "token": "s3cr3t", "grants": [
+//	      {"bus": 1, "addrs": [118, 72]},
+//	      {"bus": 2, "read_only": true}
+//	    ]}
+//	  ]
+//	}
+//
+// Use TLS termination in front of the daemon when the network is not
+// trusted, tokens are sent in clear otherwise.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fedeonline/i2c-go/remote"
+)
+
+type config struct {
+	Listen  string              `json:"listen"`
+	Clients []remote.Credential `json:"clients"`
+}
+
+func main() {
+	path := flag.String("config", "/etc/i2cd.json", "configuration file")
+	listen := flag.String("listen", "", "listen address, overrides the configuration")
+	flag.Parse()
+	if err := run(*path, *listen); err != nil {
+		fmt.Fprintf(os.Stderr, "i2cd: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(path, listen string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if listen != "" {
+		cfg.Listen = listen
+	}
+	if cfg.Listen == "" {
+		cfg.Listen = ":8420"
+	}
+	for _, c := range cfg.Clients {
+		if c.Token == "" {
+			return fmt.Errorf("%s: client %q without token", path, c.Name)
+		}
+	}
+	log := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	srv := &remote.Server{Credentials: cfg.Clients, Log: log}
+	defer srv.Close()
+	hs := &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           srv,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shut, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		hs.Shutdown(shut)
+	}()
+	log.Info("listening", "addr", cfg.Listen, "clients", len(cfg.Clients))
+	if err := hs.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
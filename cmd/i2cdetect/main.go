@@ -0,0 +1,136 @@
+// Command i2cdetect scans an i2c bus for devices, like i2cdetect of
+// i2c-tools, for minimal images where i2c-tools is not installed.
+//
+//	i2cdetect 1
+//	i2cdetect -json i2c-1
+//	i2cdetect -l
+//
+// The bus is a number, an i2c-N name, a device tree alias or a node
+// path. The grid shows the address of each responding device, UU for
+// addresses claimed by a kernel driver and -- otherwise. The JSON output
+// lists the responding addresses.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+type device struct {
+	Addr uint8 `json:"addr"`
+	Busy bool  `json:"busy,omitempty"`
+}
+
+type result struct {
+	Bus     int      `json:"bus"`
+	Devices []device `json:"devices"`
+}
+
+type bus struct {
+	Bus  int    `json:"bus"`
+	Name string `json:"name"`
+	Dev  bool   `json:"dev"`
+}
+
+func main() {
+	asJSON := flag.Bool("json", false, "print JSON")
+	list := flag.Bool("l", false, "list the buses")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: i2cdetect [-json] bus\n       i2cdetect [-json] -l\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	var err error
+	switch {
+	case *list && flag.NArg() == 0:
+		err = listBuses(os.Stdout, *asJSON)
+	case !*list && flag.NArg() == 1:
+		err = detect(os.Stdout, flag.Arg(0), *asJSON)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "i2cdetect: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func listBuses(w io.Writer, asJSON bool) error {
+	l, err := i2c.ListBuses()
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		out := make([]bus, 0, len(l))
+		for _, b := range l {
+			out = append(out, bus(b))
+		}
+		return writeJSON(w, out)
+	}
+	for _, b := range l {
+		fmt.Fprintf(w, "i2c-%d\t%s\n", b.Bus, b.Name)
+	}
+	return nil
+}
+
+func detect(w io.Writer, name string, asJSON bool) error {
+	n, err := i2c.ResolveBus(name)
+	if err != nil {
+		return err
+	}
+	found, err := i2c.Scan(n)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		r := result{Bus: n, Devices: make([]device, 0, len(found))}
+		for _, f := range found {
+			r.Devices = append(r.Devices, device(f))
+		}
+		return writeJSON(w, r)
+	}
+	grid(w, found)
+	return nil
+}
+
+// grid prints the scan results in the layout of i2cdetect.
+func grid(w io.Writer, found []i2c.ScanResult) {
+	var cells [128]string
+	for a := 0x03; a <= 0x77; a++ {
+		cells[a] = "--"
+	}
+	for _, f := range found {
+		if f.Busy {
+			cells[f.Addr] = "UU"
+		} else {
+			cells[f.Addr] = fmt.Sprintf("%02x", f.Addr)
+		}
+	}
+	fmt.Fprint(w, "   ")
+	for c := 0; c < 16; c++ {
+		fmt.Fprintf(w, "  %x", c)
+	}
+	fmt.Fprintln(w)
+	for row := 0; row < 128; row += 16 {
+		fmt.Fprintf(w, "%02x:", row)
+		for _, c := range cells[row : row+16] {
+			if c == "" {
+				c = "  "
+			}
+			fmt.Fprint(w, " "+c)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,116 @@
+// Command i2cdiff compares two register dumps written by i2cdump -f json,
+// or a dump and the live device, e.g. to find what a firmware update
+// changed.
+//
+//	i2cdiff before.json after.json
+//	i2cdiff -map bme280.json golden.json 1 0x76
+//
+// When comparing with a device, the registers of the dump are read with
+// its width and byte order. With -map, a register map in the format of
+// cmd/i2cgen, registers are named and the differing bit fields decoded.
+// The exit status is 0 when the dumps match, 1 when they differ and 2 on
+// errors.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fedeonline/i2c-go/internal/cli"
+	"github.com/fedeonline/i2c-go/regmap"
+)
+
+func main() {
+	mapPath := flag.String("map", "", "register map for field decoding")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: i2cdiff [-map file] old.json new.json\n       i2cdiff [-map file] old.json bus addr\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 && flag.NArg() != 3 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	changed, err := run(os.Stdout, flag.Args(), *mapPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "i2cdiff: %v\n", err)
+		os.Exit(2)
+	}
+	if changed {
+		os.Exit(1)
+	}
+}
+
+func run(w io.Writer, args []string, mapPath string) (bool, error) {
+	var m *regmap.Map
+	if mapPath != "" {
+		f, err := os.Open(mapPath)
+		if err != nil {
+			return false, err
+		}
+		m, err = regmap.Load(f)
+		f.Close()
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", mapPath, err)
+		}
+	}
+	old, err := loadDump(args[0])
+	if err != nil {
+		return false, err
+	}
+	var cur *regmap.Dump
+	if len(args) == 3 {
+		v, err := cli.Open(args[1], args[2])
+		if err != nil {
+			return false, err
+		}
+		cur = regmap.Read(v, old.Regs(), old.Width, old.Order)
+		v.Close()
+	} else if cur, err = loadDump(args[1]); err != nil {
+		return false, err
+	}
+	changes, err := regmap.Diff(old, cur, m)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range changes {
+		width := old.Width
+		name := ""
+		if c.Register != nil {
+			name = " " + c.Register.Name
+			if c.Register.Width > width {
+				width = c.Register.Width
+			}
+		}
+		fmt.Fprintf(w, "0x%02X%s: %s -> %s\n", c.Reg, name,
+			value(c.Old, c.InOld, width), value(c.New, c.InNew, width))
+		for _, f := range c.Fields {
+			fmt.Fprintf(w, "\t%s: %s -> %s\n", f.Field.Name, f.Field.Format(f.Old), f.Field.Format(f.New))
+		}
+	}
+	return len(changes) > 0, nil
+}
+
+func loadDump(path string) (*regmap.Dump, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	d, err := regmap.LoadDump(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return d, nil
+}
+
+// value formats the width bytes register value x, ok reporting whether
+// it was read.
+func value(x uint64, ok bool, width int) string {
+	if !ok {
+		return "(not read)"
+	}
+	return fmt.Sprintf("0x%0*X", width*2, x)
+}
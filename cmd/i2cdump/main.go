@@ -0,0 +1,216 @@
+// Command i2cdump dumps the registers of an i2c device, like i2cdump of
+// i2c-tools, as a hex grid, JSON or CSV.
+//
+//	i2cdump 1 0x76
+//	i2cdump -r 0x80-0xff -mode block -f json 1 0x76 > before.json
+//	i2cdump -w 2 -f csv 1 0x40
+//
+// The bus is a number, an i2c-N name, a device tree alias or a node path.
+// -r selects the inclusive register range. The read mode is one of:
+//
+//	byte   every register is read with its own transfer of -w bytes
+//	word   every register is read as a little endian SMBus word
+//	block  the range is read as one byte block, split in -w bytes values
+//	       at the addresses first, first+w, first+2w...
+//
+// -le applies to the byte and block modes. Registers which cannot be read
+// are shown as XX in the grid and carry the error in JSON and CSV. The
+// JSON output is the dump format of package regmap, compared by
+// cmd/i2cdiff.
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"strings"
+
+	"github.com/fedeonline/i2c-go/internal/cli"
+	"github.com/fedeonline/i2c-go/regmap"
+)
+
+func main() {
+	rng := flag.String("r", "0x00-0xff", "register range, first-last")
+	width := flag.Int("w", 1, "value width in bytes, 1 to 8")
+	le := flag.Bool("le", false, "little endian values")
+	mode := flag.String("mode", "byte", "read mode: byte, word or block")
+	format := flag.String("f", "grid", "output format: grid, json or csv")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: i2cdump [-r range] [-w width] [-le] [-mode mode] [-f format] bus addr\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), flag.Arg(1), *rng, *width, *le, *mode, *format); err != nil {
+		fmt.Fprintf(os.Stderr, "i2cdump: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(bus, addr, rng string, width int, le bool, mode, format string) error {
+	first, last, err := parseRange(rng)
+	if err != nil {
+		return err
+	}
+	if err := cli.CheckWidth(width); err != nil {
+		return err
+	}
+	if mode == "word" {
+		width, le = 2, true
+	}
+	var out func(io.Writer, *regmap.Dump) error
+	switch format {
+	case "grid":
+		out = grid
+	case "json":
+		out = writeJSON
+	case "csv":
+		out = writeCSV
+	default:
+		return fmt.Errorf("format %q: must be grid, json or csv", format)
+	}
+	v, err := cli.Open(bus, addr)
+	if err != nil {
+		return err
+	}
+	defer v.Close()
+	order := ""
+	if le {
+		order = "le"
+	}
+	d := &regmap.Dump{Bus: v.Bus(), Addr: v.Addr(), Width: width, Order: order}
+	switch mode {
+	case "byte":
+		regs := make([]int, 0, last-first+1)
+		for r := first; r <= last; r++ {
+			regs = append(regs, r)
+		}
+		d = regmap.Read(v, regs, width, order)
+	case "word":
+		for r := first; r <= last; r++ {
+			w, err := v.ReadRegU16LE(byte(r))
+			d.Registers = append(d.Registers, newEntry(r, uint64(w), err))
+		}
+	case "block":
+		n := (last - first + 1) / width * width
+		if n == 0 {
+			return fmt.Errorf("range %s: shorter than the width", rng)
+		}
+		buf := make([]byte, n)
+		c, err := v.ReadRegBytesInto(byte(first), buf)
+		if err == nil && c < n {
+			err = io.ErrUnexpectedEOF
+		}
+		for off := 0; off < n; off += width {
+			d.Registers = append(d.Registers, newEntry(first+off, cli.Decode(buf[off:off+width], cli.Order(le)), err))
+		}
+	default:
+		return fmt.Errorf("mode %q: must be byte, word or block", mode)
+	}
+	if err := allFailed(d.Registers); err != nil {
+		return err
+	}
+	return out(os.Stdout, d)
+}
+
+func newEntry(reg int, value uint64, err error) regmap.Entry {
+	if err != nil {
+		return regmap.Entry{Reg: reg, Err: err.Error()}
+	}
+	return regmap.Entry{Reg: reg, Value: value}
+}
+
+// allFailed returns an error when no register could be read, most likely
+// because the device is absent.
+func allFailed(l []regmap.Entry) error {
+	for _, e := range l {
+		if e.Err == "" {
+			return nil
+		}
+	}
+	return errors.New(l[0].Err)
+}
+
+// parseRange parses the inclusive register range s, "first-last".
+func parseRange(s string) (first, last int, err error) {
+	a, b, ok := strings.Cut(s, "-")
+	if !ok {
+		b = a
+	}
+	f, err := cli.ParseUint(a, 8)
+	if err != nil {
+		return 0, 0, fmt.Errorf("range %q: %w", s, err)
+	}
+	l, err := cli.ParseUint(b, 8)
+	if err != nil {
+		return 0, 0, fmt.Errorf("range %q: %w", s, err)
+	}
+	if l < f {
+		return 0, 0, fmt.Errorf("range %q: last register before the first", s)
+	}
+	return int(f), int(l), nil
+}
+
+// grid prints the registers in the layout of i2cdump, up to 16 bytes per row,
+// with the ASCII rendering of single byte registers.
+func grid(w io.Writer, d *regmap.Dump) error {
+	cols := 16 >> bits.Len(uint(d.Width-1))
+	step := 1
+	if len(d.Registers) > 1 {
+		step = d.Registers[1].Reg - d.Registers[0].Reg
+	}
+	cell := d.Width * 2
+	fmt.Fprint(w, "   ")
+	for c := 0; c < cols; c++ {
+		fmt.Fprintf(w, " %*x", cell, c*step)
+	}
+	fmt.Fprintln(w)
+	for i := 0; i < len(d.Registers); i += cols {
+		row := d.Registers[i:min(i+cols, len(d.Registers))]
+		fmt.Fprintf(w, "%02x:", row[0].Reg)
+		var ascii strings.Builder
+		for _, e := range row {
+			if e.Err != "" {
+				fmt.Fprint(w, " "+strings.Repeat("X", cell))
+				ascii.WriteByte('X')
+				continue
+			}
+			fmt.Fprintf(w, " %0*x", cell, e.Value)
+			if c := byte(e.Value); c >= 0x20 && c < 0x7f {
+				ascii.WriteByte(c)
+			} else {
+				ascii.WriteByte('.')
+			}
+		}
+		if d.Width == 1 {
+			fmt.Fprintf(w, "%*s    %s", (cols-len(row))*(cell+1), "", ascii.String())
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, d *regmap.Dump) error {
+	return d.WriteJSON(w)
+}
+
+func writeCSV(w io.Writer, d *regmap.Dump) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"reg", "value", "error"})
+	for _, e := range d.Registers {
+		value := ""
+		if e.Err == "" {
+			value = fmt.Sprintf("0x%0*x", d.Width*2, e.Value)
+		}
+		cw.Write([]string{fmt.Sprintf("0x%02x", e.Reg), value, e.Err})
+	}
+	cw.Flush()
+	return cw.Error()
+}
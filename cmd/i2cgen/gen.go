@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fedeonline/i2c-go/regmap"
+)
+
+// generator accumulates the generated source.
+type generator struct {
+	bytes.Buffer
+	recv    string
+	binary  bool
+	strconv bool
+}
+
+func (g *generator) p(format string, args ...any) {
+	fmt.Fprintf(&g.Buffer, format, args...)
+	g.WriteByte('\n')
+}
+
+func generate(c *regmap.Map, in string) ([]byte, error) {
+	if c.Package == "" {
+		return nil, errors.New("missing package name")
+	}
+	if c.Type == "" {
+		c.Type = "Device"
+	}
+	if err := checkOrder(c.Order); err != nil {
+		return nil, err
+	}
+	var body generator
+	body.recv = "d *" + c.Type
+	names := map[string]bool{}
+	for i := range c.Registers {
+		r := &c.Registers[i]
+		if err := body.register(c, r, names); err != nil {
+			return nil, fmt.Errorf("register %s: %w", r.Name, err)
+		}
+	}
+
+	var g generator
+	g.p("// Code generated by i2cgen from %s; DO NOT EDIT.", filepath.Base(in))
+	g.p("")
+	g.p("package %s", c.Package)
+	g.p("")
+	g.p("import (")
+	if body.binary {
+		g.p(`"encoding/binary"`)
+	}
+	if body.strconv {
+		g.p(`"strconv"`)
+	}
+	g.p("")
+	g.p(`i2c "github.com/fedeonline/i2c-go"`)
+	g.p(")")
+	g.p("")
+	g.p("// %s is a connection to the device.", c.Type)
+	g.p("type %s struct {", c.Type)
+	g.p("*i2c.I2C")
+	g.p("}")
+	g.p("")
+	g.p("// Register addresses.")
+	g.p("const (")
+	for _, r := range c.Registers {
+		g.p("Reg%s = 0x%02X", r.Name, uint64(r.Addr))
+	}
+	g.p(")")
+	g.Write(body.Bytes())
+	src, err := format.Source(g.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+func checkOrder(o string) error {
+	if o != "" && o != "be" && o != "le" {
+		return fmt.Errorf("bad order %q", o)
+	}
+	return nil
+}
+
+func checkName(names map[string]bool, name string) error {
+	if !token.IsIdentifier(name) || !token.IsExported(name) {
+		return fmt.Errorf("bad name %q, must be an exported identifier", name)
+	}
+	if names[name] {
+		return fmt.Errorf("duplicate name %q", name)
+	}
+	names[name] = true
+	return nil
+}
+
+func access(a string) (read, write bool, err error) {
+	switch a {
+	case "", "rw":
+		return true, true, nil
+	case "ro":
+		return true, false, nil
+	case "wo":
+		return false, true, nil
+	}
+	return false, false, fmt.Errorf("bad access %q", a)
+}
+
+// uintType returns the smallest unsigned type holding bits bits.
+func uintType(bits int) string {
+	switch {
+	case bits <= 8:
+		return "uint8"
+	case bits <= 16:
+		return "uint16"
+	case bits <= 32:
+		return "uint32"
+	}
+	return "uint64"
+}
+
+func (g *generator) register(c *regmap.Map, r *regmap.Register, names map[string]bool) error {
+	if err := checkName(names, r.Name); err != nil {
+		return err
+	}
+	if r.Addr > 0xFF {
+		return fmt.Errorf("address %#x out of range", uint64(r.Addr))
+	}
+	if r.Width == 0 {
+		r.Width = 1
+	}
+	switch r.Width {
+	case 1, 2, 3, 4, 8:
+	default:
+		return fmt.Errorf("unsupported width %d", r.Width)
+	}
+	if r.Order == "" {
+		r.Order = c.Order
+	}
+	if err := checkOrder(r.Order); err != nil {
+		return err
+	}
+	order := "binary.BigEndian"
+	if r.Order == "le" {
+		order = "binary.LittleEndian"
+	}
+	if r.Width > 1 {
+		g.binary = true
+	}
+	rd, wr, err := access(r.Access)
+	if err != nil {
+		return err
+	}
+	utyp := uintType(8 * r.Width)
+	typ := utyp
+	if r.Signed {
+		typ = "int" + strings.TrimPrefix(utyp, "uint")
+	}
+	// read and write expressions of the whole register, raw unsigned
+	// ones for the fields
+	read := func(t string) string {
+		switch r.Width {
+		case 1:
+			return fmt.Sprintf("d.ReadRegU8(Reg%s)", r.Name)
+		case 3:
+			if t[0] == 'i' {
+				return fmt.Sprintf("d.ReadRegS24(Reg%s, %s)", r.Name, order)
+			}
+			return fmt.Sprintf("d.ReadRegU24(Reg%s, %s)", r.Name, order)
+		}
+		return fmt.Sprintf("i2c.ReadReg[%s](d.I2C, Reg%s, %s)", t, r.Name, order)
+	}
+	doc := func(name, def string) {
+		if r.Doc != "" {
+			def = r.Doc
+		}
+		g.p("")
+		g.p("// %s %s", name, def)
+	}
+
+	if rd {
+		doc(r.Name, fmt.Sprintf("reads the %s register.", r.Name))
+		g.p("func (%s) %s() (%s, error) {", g.recv, r.Name, typ)
+		if r.Width == 1 && r.Signed {
+			g.p("v, err := %s", read(utyp))
+			g.p("return int8(v), err")
+		} else {
+			g.p("return %s", read(typ))
+		}
+		g.p("}")
+	}
+	if wr {
+		doc("Set"+r.Name, fmt.Sprintf("writes the %s register.", r.Name))
+		g.p("func (%s) Set%s(v %s) error {", g.recv, r.Name, typ)
+		switch r.Width {
+		case 1:
+			g.p("return d.WriteRegU8(Reg%s, byte(v))", r.Name)
+		case 3:
+			g.p("return d.WriteRegU24(Reg%s, uint32(v), %s)", r.Name, order)
+		default:
+			g.p("return i2c.WriteReg(d.I2C, Reg%s, v, %s)", r.Name, order)
+		}
+		g.p("}")
+	}
+
+	for i := range r.Fields {
+		f := &r.Fields[i]
+		if err := g.field(r, f, names, read(utyp), order); err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func (g *generator) field(r *regmap.Register, f *regmap.Field, names map[string]bool, read, order string) error {
+	name := r.Name + f.Name
+	if err := checkName(names, name); err != nil {
+		return err
+	}
+	hi, lo, err := regmap.ParseBits(f.Bits)
+	if err != nil {
+		return err
+	}
+	if hi >= 8*r.Width {
+		return fmt.Errorf("bits %s out of %d byte register", f.Bits, r.Width)
+	}
+	if f.Access == "" {
+		f.Access = r.Access
+	}
+	rd, wr, err := access(f.Access)
+	if err != nil {
+		return err
+	}
+	if wr && r.Width > 2 {
+		return fmt.Errorf("writable fields need a 1 or 2 byte register")
+	}
+	width := hi - lo + 1
+	mask := (uint64(1)<<width - 1) << lo
+	typ := uintType(width)
+	isBool := width == 1 && len(f.Enum) == 0 && f.Type == ""
+	if len(f.Enum) > 0 || f.Type != "" {
+		if f.Type == "" {
+			f.Type = name
+		} else if err := checkName(names, f.Type); err != nil {
+			return err
+		}
+		if err := g.enum(f, typ, names); err != nil {
+			return err
+		}
+		typ = f.Type
+	}
+	if isBool {
+		typ = "bool"
+	}
+	doc := func(name, def string) {
+		if f.Doc != "" {
+			def = f.Doc
+		}
+		g.p("")
+		g.p("// %s %s", name, def)
+	}
+
+	if rd {
+		doc(name, fmt.Sprintf("reads the %s field of the %s register.", f.Name, r.Name))
+		g.p("func (%s) %s() (%s, error) {", g.recv, name, typ)
+		if r.Width == 1 {
+			g.p("v, err := d.ReadRegBits(Reg%s, 0x%02X, %d)", r.Name, mask, lo)
+			if isBool {
+				g.p("return v != 0, err")
+			} else {
+				g.p("return %s(v), err", typ)
+			}
+		} else {
+			g.p("v, err := %s", read)
+			if isBool {
+				g.p("return v&0x%X != 0, err", mask)
+			} else {
+				g.p("return %s(v & 0x%X >> %d), err", typ, mask, lo)
+			}
+		}
+		g.p("}")
+	}
+	if wr {
+		doc("Set"+name, fmt.Sprintf("writes the %s field of the %s register, leaving the other bits untouched.", f.Name, r.Name))
+		g.p("func (%s) Set%s(v %s) error {", g.recv, name, typ)
+		val := "v"
+		if isBool {
+			g.p("var b uint8")
+			g.p("if v {")
+			g.p("b = 1")
+			g.p("}")
+			val = "b"
+		}
+		if r.Width == 1 {
+			g.p("return d.WriteRegBits(Reg%s, 0x%02X, %d, byte(%s))", r.Name, mask, lo, val)
+		} else {
+			g.p("if uint64(%s)<<%d&^0x%X != 0 {", val, lo, mask)
+			g.p("return i2c.ErrFieldRange")
+			g.p("}")
+			g.p("return d.UpdateRegU16(Reg%s, 0x%X, uint16(%s)<<%d, %s)", r.Name, mask, val, lo, order)
+		}
+		g.p("}")
+	}
+	return nil
+}
+
+// enum declares the type of an enumerated field with its values.
+func (g *generator) enum(f *regmap.Field, under string, names map[string]bool) error {
+	keys := make([]string, 0, len(f.Enum))
+	for k := range f.Enum {
+		keys = append(keys, k)
+	}
+	// declare the values in numeric order, as in datasheet tables
+	sort.Slice(keys, func(i, j int) bool {
+		if f.Enum[keys[i]] != f.Enum[keys[j]] {
+			return f.Enum[keys[i]] < f.Enum[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	g.p("")
+	g.p("// %s is the %s field value.", f.Type, f.Name)
+	g.p("type %s %s", f.Type, under)
+	if len(keys) == 0 {
+		return nil
+	}
+	g.p("")
+	g.p("const (")
+	for _, k := range keys {
+		if err := checkName(names, f.Type+k); err != nil {
+			return err
+		}
+		g.p("%s%s %s = %d", f.Type, k, f.Type, uint64(f.Enum[k]))
+	}
+	g.p(")")
+	g.strconv = true
+	g.p("")
+	g.p("func (v %s) String() string {", f.Type)
+	g.p("switch v {")
+	seen := map[regmap.Num]bool{}
+	for _, k := range keys {
+		if seen[f.Enum[k]] {
+			continue
+		}
+		seen[f.Enum[k]] = true
+		g.p("case %s%s:", f.Type, k)
+		g.p("return %q", k)
+	}
+	g.p("}")
+	g.p(`return "%s(" + strconv.Itoa(int(v)) + ")"`, f.Type)
+	g.p("}")
+	return nil
+}
@@ -0,0 +1,83 @@
+// Command i2cgen generates typed register accessors from a JSON chip
+// description, to be run by go generate:
+//
+//	//go:generate go run github.com/fedeonline/i2c-go/cmd/i2cgen bme280.json
+//
+// The description lists the registers of the chip, and optionally their
+// bit fields and enumerated values:
+//
+//	{
+//	  "type": "BME280",
+//	  "order": "be",
+//	  "registers": [
+//	    {"name": "ChipID", "addr": "0xD0", "access": "ro"},
+//	    {"name": "Press", "addr": "0xF7", "width": 3, "access": "ro"},
+//	    {"name": "CtrlMeas", "addr": "0xF4", "fields": [
+//	      {"name": "Mode", "bits": "1:0", "enum": {"Sleep": 0, "Forced": 1, "Normal": 3}},
+//	      {"name": "OsrsP", "bits": "4:2"}
+//	    ]}
+//	  ]
+//	}
+//
+// Register width is in bytes (1, 2, 3, 4 or 8, default 1) and order is
+// "be" or "le" (default "be"), per chip or per register. access is "rw"
+// (default), "ro" or "wo", per register or per field. Single bit fields
+// without enum are booleans. Numbers are decimal or strings with a 0x
+// prefix.
+//
+// The output declares the device type embedding *i2c.I2C, the register
+// address constants, a getter and setter for every register and field,
+// and a type with constants for every enumeration. Field setters are
+// atomic read-modify-writes, and are supported on 1 and 2 byte registers.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fedeonline/i2c-go/regmap"
+)
+
+func main() {
+	out := flag.String("o", "", "output file (default: input with _regs.go suffix)")
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "package name")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: i2cgen [-o file] [-pkg name] chip.json\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	in := flag.Arg(0)
+	if *out == "" {
+		*out = strings.TrimSuffix(in, ".json") + "_regs.go"
+	}
+	if err := run(in, *out, *pkg); err != nil {
+		fmt.Fprintf(os.Stderr, "i2cgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, pkg string) error {
+	f, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	c, err := regmap.Load(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", in, err)
+	}
+	if pkg != "" {
+		c.Package = pkg
+	}
+	src, err := generate(c, in)
+	if err != nil {
+		return fmt.Errorf("%s: %w", in, err)
+	}
+	return os.WriteFile(out, src, 0644)
+}
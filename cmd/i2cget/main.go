@@ -0,0 +1,74 @@
+// Command i2cget reads a register of an i2c device, like i2cget of
+// i2c-tools, with typed output.
+//
+//	i2cget 1 0x76 0xd0
+//	i2cget -w 2 -le -f signed 1 0x48 0x00
+//
+// The bus is a number, an i2c-N name, a device tree alias or a node path.
+// -w sets the register width in bytes, -le reads little endian values.
+// Without a register the value is read straight from the device, as
+// devices without registers expect.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fedeonline/i2c-go/internal/cli"
+)
+
+func main() {
+	width := flag.Int("w", 1, "register width in bytes, 1 to 8")
+	le := flag.Bool("le", false, "little endian value")
+	format := flag.String("f", "hex", "output format: hex, dec or signed")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: i2cget [-w width] [-le] [-f format] bus addr [reg]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 2 || flag.NArg() > 3 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Args(), *width, *le, *format); err != nil {
+		fmt.Fprintf(os.Stderr, "i2cget: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, width int, le bool, format string) error {
+	if err := cli.CheckWidth(width); err != nil {
+		return err
+	}
+	if _, err := cli.Format(0, width, format); err != nil {
+		return err
+	}
+	v, err := cli.Open(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	defer v.Close()
+	buf := make([]byte, width)
+	if len(args) == 3 {
+		reg, err := cli.ParseUint(args[2], 8)
+		if err != nil {
+			return fmt.Errorf("register %q: %w", args[2], err)
+		}
+		var n int
+		n, err = v.ReadRegBytesInto(byte(reg), buf)
+		if err == nil && n < width {
+			// the value would be decoded from zeros
+			err = fmt.Errorf("read %d of %d bytes: %w", n, width, io.ErrUnexpectedEOF)
+		}
+	} else {
+		err = v.Tx(nil, buf)
+	}
+	if err != nil {
+		return err
+	}
+	s, _ := cli.Format(cli.Decode(buf, cli.Order(le)), width, format)
+	fmt.Println(s)
+	return nil
+}
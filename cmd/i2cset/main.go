@@ -0,0 +1,62 @@
+// Command i2cset writes a register of an i2c device, like i2cset of
+// i2c-tools.
+//
+//	i2cset 1 0x76 0xf4 0x27
+//	i2cset -w 2 -le -verify 1 0x40 0x05 -1200
+//
+// The bus is a number, an i2c-N name, a device tree alias or a node path.
+// -w sets the register width in bytes, -le writes little endian values.
+// Negative values are stored in two's complement. -verify reads the
+// register back and fails when the device did not take the value.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fedeonline/i2c-go/internal/cli"
+)
+
+func main() {
+	width := flag.Int("w", 1, "register width in bytes, 1 to 8")
+	le := flag.Bool("le", false, "little endian value")
+	verify := flag.Bool("verify", false, "read the register back after writing")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: i2cset [-w width] [-le] [-verify] bus addr reg value\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 4 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Args(), *width, *le, *verify); err != nil {
+		fmt.Fprintf(os.Stderr, "i2cset: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, width int, le, verify bool) error {
+	if err := cli.CheckWidth(width); err != nil {
+		return err
+	}
+	reg, err := cli.ParseUint(args[2], 8)
+	if err != nil {
+		return fmt.Errorf("register %q: %w", args[2], err)
+	}
+	x, err := cli.ParseValue(args[3], width)
+	if err != nil {
+		return fmt.Errorf("value %q: %w", args[3], err)
+	}
+	v, err := cli.Open(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	defer v.Close()
+	v.SetVerifyWrites(verify)
+	buf := make([]byte, width)
+	cli.Encode(buf, x, cli.Order(le))
+	_, err = v.WriteRegBytes(byte(reg), buf)
+	return err
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// historyMax is the count of lines kept in the history.
+const historyMax = 1000
+
+// history is the command history, an x/term History persisted to a file.
+type history struct {
+	path  string
+	lines []string // oldest first
+}
+
+// loadHistory reads the history file at path. A missing file is an empty
+// history, an empty path disables persistence.
+func loadHistory(path string) *history {
+	h := &history{path: path}
+	if path == "" {
+		return h
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return h
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if l := strings.TrimSpace(sc.Text()); l != "" {
+			h.lines = append(h.lines, l)
+		}
+	}
+	if len(h.lines) > historyMax {
+		h.lines = h.lines[len(h.lines)-historyMax:]
+	}
+	return h
+}
+
+// Add records entry, skipping repeats of the last line.
+func (h *history) Add(entry string) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" || len(h.lines) > 0 && h.lines[len(h.lines)-1] == entry {
+		return
+	}
+	h.lines = append(h.lines, entry)
+	if len(h.lines) > historyMax {
+		h.lines = h.lines[1:]
+	}
+}
+
+// Len returns the count of lines.
+func (h *history) Len() int {
+	return len(h.lines)
+}
+
+// At returns the idx-th most recent line.
+func (h *history) At(idx int) string {
+	return h.lines[len(h.lines)-1-idx]
+}
+
+// save writes the history file.
+func (h *history) save() error {
+	if h.path == "" {
+		return nil
+	}
+	return os.WriteFile(h.path, []byte(strings.Join(h.lines, "\n")+"\n"), 0600)
+}
@@ -0,0 +1,130 @@
+// Command i2csh is an interactive shell for bringing up i2c devices:
+// scanning buses, reading and writing registers, watching them and
+// running combined transfers, without re-running one-shot commands.
+//
+//	$ i2csh 1 0x76
+//	i2c-1@0x76> r8 0xd0
+//	r8 0xD0 = 0x60 (96)
+//	i2c-1@0x76> w8 0xf4 0x27; sleep 10ms; rb 0xf7 8
+//	i2c-1@0x76> transfer w1@0x76 0xd0 r1
+//
+// The optional arguments select the bus and open the device. Numbers are
+// decimal or hex with a 0x prefix. Type help for the list of commands.
+// On a terminal the shell supports line editing, and the history is kept
+// in ~/.i2csh_history; otherwise it reads commands from its input, one per
+// line, and stops at the first error.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"golang.org/x/term"
+)
+
+func main() {
+	histPath := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		histPath = filepath.Join(home, ".i2csh_history")
+	}
+	flag.StringVar(&histPath, "history", histPath, "history file, empty to disable")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: i2csh [-history file] [bus [addr]]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Args(), histPath); err != nil {
+		fmt.Fprintf(os.Stderr, "i2csh: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, histPath string) error {
+	sh := &shell{out: os.Stdout, bus: -1}
+	defer sh.closeDev()
+	if len(args) > 0 {
+		if err := sh.selectBus(args[:1]); err != nil {
+			return err
+		}
+	}
+	if len(args) > 1 {
+		if err := sh.open(args[1:]); err != nil {
+			return err
+		}
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		sh.stop = func() (context.Context, context.CancelFunc) {
+			return signal.NotifyContext(context.Background(), os.Interrupt)
+		}
+		return batch(sh, os.Stdin)
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+	sh.hist = loadHistory(histPath)
+	defer sh.hist.save()
+	return interactive(sh)
+}
+
+// batch runs the commands read from r, stopping at the first error.
+func batch(sh *shell, r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		if err := sh.exec(sc.Text()); err == errQuit {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// interactive runs the commands typed on the terminal, reporting errors
+// without leaving.
+func interactive(sh *shell) error {
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, sh.prompt())
+	t.History = sh.hist
+	sh.out = t
+	// in raw mode ^C is not a signal: a watch ends on any key. The key
+	// is consumed, as is the next one typed if the watch fails instead.
+	sh.stop = func() (context.Context, context.CancelFunc) {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			var b [1]byte
+			os.Stdin.Read(b[:])
+			cancel()
+		}()
+		return ctx, cancel
+	}
+	for {
+		line, err := t.ReadLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := sh.exec(line); err == errQuit {
+			return nil
+		} else if err != nil {
+			fmt.Fprintf(t, "error: %v\n", err)
+		}
+		t.SetPrompt(sh.prompt())
+	}
+}
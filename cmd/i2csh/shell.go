@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+	"github.com/fedeonline/i2c-go/internal/cli"
+	"github.com/fedeonline/i2c-go/script"
+)
+
+// errQuit ends the shell.
+var errQuit = errors.New("quit")
+
+const help = `buses                     list the i2c buses
+bus [BUS]                 show or select the bus
+scan                      scan the bus
+open ADDR                 open the device at ADDR on the bus
+close                     close the device
+ping                      check that the device acknowledges
+r8 REG, r16be REG, ...    register statements of package script,
+w8 REG VAL, wb REG B...   separated by semicolons
+transfer DESC [DATA]...   combined transfer, i2ctransfer syntax
+watch REG [N] [INTERVAL]  print N bytes from REG when they change,
+                          until a key is pressed (^C in batch mode)
+history                   list the history
+help                      show this help
+quit                      leave the shell
+`
+
+// shell is the state of an i2csh session.
+type shell struct {
+	out  io.Writer
+	bus  int // -1 when none is selected
+	dev  *i2c.I2C
+	hist *history
+	// stop returns a context canceled when the user interrupts a long
+	// running command.
+	stop func() (context.Context, context.CancelFunc)
+}
+
+// prompt returns the prompt showing the selected bus and device.
+func (sh *shell) prompt() string {
+	switch {
+	case sh.dev != nil:
+		return fmt.Sprintf("i2c-%d@0x%02x> ", sh.bus, sh.dev.Addr())
+	case sh.bus >= 0:
+		return fmt.Sprintf("i2c-%d> ", sh.bus)
+	}
+	return "i2c> "
+}
+
+// exec runs the command line.
+func (sh *shell) exec(line string) error {
+	f := strings.Fields(line)
+	if len(f) == 0 || strings.HasPrefix(f[0], "#") {
+		return nil
+	}
+	switch strings.ToLower(f[0]) {
+	case "quit", "exit":
+		return errQuit
+	case "help", "?":
+		_, err := io.WriteString(sh.out, help)
+		return err
+	case "history":
+		if sh.hist != nil {
+			for i, l := range sh.hist.lines {
+				fmt.Fprintf(sh.out, "%4d  %s\n", i+1, l)
+			}
+		}
+		return nil
+	case "buses":
+		return sh.buses()
+	case "bus":
+		return sh.selectBus(f[1:])
+	case "scan":
+		return sh.scan()
+	case "open":
+		return sh.open(f[1:])
+	case "close":
+		sh.closeDev()
+		return nil
+	case "ping":
+		if err := sh.needDev(); err != nil {
+			return err
+		}
+		if err := sh.dev.Ping(); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(sh.out, "ok")
+		return err
+	case "transfer":
+		return sh.transfer(f[1:])
+	case "watch":
+		return sh.watch(f[1:])
+	}
+	if err := sh.needDev(); err != nil {
+		return err
+	}
+	return script.Run(sh.dev, line, sh.out)
+}
+
+func (sh *shell) needBus() error {
+	if sh.bus < 0 {
+		return errors.New("no bus selected, use bus BUS")
+	}
+	return nil
+}
+
+func (sh *shell) needDev() error {
+	if sh.dev == nil {
+		return errors.New("no device open, use open ADDR")
+	}
+	return nil
+}
+
+func (sh *shell) buses() error {
+	l, err := i2c.ListBuses()
+	if err != nil {
+		return err
+	}
+	for _, b := range l {
+		fmt.Fprintf(sh.out, "i2c-%d\t%s\n", b.Bus, b.Name)
+	}
+	return nil
+}
+
+func (sh *shell) selectBus(args []string) error {
+	switch len(args) {
+	case 0:
+		if err := sh.needBus(); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(sh.out, "i2c-%d\n", sh.bus)
+		return err
+	case 1:
+		n, err := i2c.ResolveBus(args[0])
+		if err != nil {
+			return err
+		}
+		sh.closeDev()
+		sh.bus = n
+		return nil
+	}
+	return errors.New("usage: bus [BUS]")
+}
+
+func (sh *shell) scan() error {
+	if err := sh.needBus(); err != nil {
+		return err
+	}
+	found, err := i2c.Scan(sh.bus)
+	if err != nil {
+		return err
+	}
+	for _, r := range found {
+		busy := ""
+		if r.Busy {
+			busy = " (in use by a driver)"
+		}
+		fmt.Fprintf(sh.out, "0x%02x%s\n", r.Addr, busy)
+	}
+	return nil
+}
+
+func (sh *shell) open(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: open ADDR")
+	}
+	if err := sh.needBus(); err != nil {
+		return err
+	}
+	a, err := cli.ParseUint(args[0], 8)
+	if err != nil {
+		return fmt.Errorf("address %q: %w", args[0], err)
+	}
+	v, err := i2c.NewI2C(uint8(a), sh.bus)
+	if err != nil {
+		return err
+	}
+	sh.closeDev()
+	sh.dev = v
+	return nil
+}
+
+func (sh *shell) closeDev() {
+	if sh.dev != nil {
+		sh.dev.Close()
+		sh.dev = nil
+	}
+}
+
+func (sh *shell) transfer(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: transfer DESC [DATA]...")
+	}
+	msgs, err := cli.ParseMsgs(args)
+	if err != nil {
+		return err
+	}
+	v := sh.dev
+	if v == nil {
+		if err := sh.needBus(); err != nil {
+			return err
+		}
+		if v, err = i2c.NewI2C(msgs[0].Addr, sh.bus); err != nil {
+			return err
+		}
+		defer v.Close()
+	}
+	if err := v.Transfer(msgs...); err != nil {
+		return err
+	}
+	for _, m := range msgs {
+		if m.Read {
+			fmt.Fprintln(sh.out, cli.FormatBytes(m.Buf))
+		}
+	}
+	return nil
+}
+
+func (sh *shell) watch(args []string) error {
+	if len(args) < 1 || len(args) > 3 {
+		return errors.New("usage: watch REG [N] [INTERVAL]")
+	}
+	if err := sh.needDev(); err != nil {
+		return err
+	}
+	reg, err := cli.ParseUint(args[0], 8)
+	if err != nil {
+		return fmt.Errorf("register %q: %w", args[0], err)
+	}
+	n := uint64(1)
+	if len(args) > 1 {
+		if n, err = cli.ParseUint(args[1], 8); err != nil || n == 0 {
+			return fmt.Errorf("count %q: must be 1 to 255", args[1])
+		}
+	}
+	interval := 100 * time.Millisecond
+	if len(args) > 2 {
+		if interval, err = time.ParseDuration(args[2]); err != nil || interval <= 0 {
+			return fmt.Errorf("bad interval %q", args[2])
+		}
+	}
+	ctx, cancel := sh.stop()
+	defer cancel()
+	regs := []i2c.RegRead{{Reg: byte(reg), Buf: make([]byte, n)}}
+	err = sh.dev.Watch(ctx, regs, interval, func(c i2c.RegChange) {
+		fmt.Fprintf(sh.out, "%s  0x%02x: % x\n", c.Time.Format("15:04:05.000"), c.Reg, c.New)
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+// Command i2ctransfer sends a sequence of messages as one combined
+// transfer, like i2ctransfer of i2c-tools.
+//
+//	i2ctransfer 1 w2@0x50 0x00 0x10 r16
+//	i2ctransfer 1 w17@0x50 0x00 0xff=
+//
+// The bus is a number, an i2c-N name, a device tree alias or a node path.
+// Each message is described by {r|w}LENGTH[@ADDR]; the first message
+// must carry the address, the next ones default to the address of the
+// previous message. Write messages are followed by their LENGTH bytes. A
+// byte ending with = repeats it up to the end of the message, ending with
+// + or - increments or decrements it for every following byte.
+//
+// The bytes received by each read message are printed on a line.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	i2c "github.com/fedeonline/i2c-go"
+	"github.com/fedeonline/i2c-go/internal/cli"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: i2ctransfer bus desc [data...] [desc [data...]]...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "i2ctransfer: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(bus string, args []string) error {
+	msgs, err := cli.ParseMsgs(args)
+	if err != nil {
+		return err
+	}
+	n, err := i2c.ResolveBus(bus)
+	if err != nil {
+		return err
+	}
+	v, err := i2c.NewI2C(msgs[0].Addr, n)
+	if err != nil {
+		return err
+	}
+	defer v.Close()
+	if err := v.Transfer(msgs...); err != nil {
+		return err
+	}
+	for _, m := range msgs {
+		if !m.Read {
+			continue
+		}
+		fmt.Println(cli.FormatBytes(m.Buf))
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+// Command i2cwatch polls registers of an i2c device and prints their
+// values when they change, e.g. to observe interrupt and status flags
+// while exercising the hardware.
+//
+//	i2cwatch 1 0x76 0xf3
+//	i2cwatch -i 10ms -t -csv 1 0x68 0x3a 0x3b:6 > log.csv
+//
+// The bus is a number, an i2c-N name, a device tree alias or a node path.
+// Each register argument is REG, or REG:N to watch N bytes starting from
+// REG. All the registers are read at once every -i, and their first
+// values are printed too. -t prefixes each line with the time. The
+// command runs until interrupted.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+	"github.com/fedeonline/i2c-go/internal/cli"
+)
+
+func main() {
+	interval := flag.Duration("i", 100*time.Millisecond, "poll interval")
+	stamp := flag.Bool("t", false, "print timestamps")
+	asCSV := flag.Bool("csv", false, "print CSV")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: i2cwatch [-i interval] [-t] [-csv] bus addr reg[:n]...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 3 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Args(), *interval, *stamp, *asCSV); err != nil {
+		fmt.Fprintf(os.Stderr, "i2cwatch: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, interval time.Duration, stamp, asCSV bool) error {
+	regs := make([]i2c.RegRead, 0, len(args)-2)
+	for _, a := range args[2:] {
+		r, err := parseReg(a)
+		if err != nil {
+			return err
+		}
+		regs = append(regs, r)
+	}
+	v, err := cli.Open(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	defer v.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var report func(c i2c.RegChange)
+	if asCSV {
+		w := csv.NewWriter(os.Stdout)
+		head := []string{"reg", "old", "new"}
+		if stamp {
+			head = append([]string{"time"}, head...)
+		}
+		w.Write(head)
+		w.Flush()
+		report = func(c i2c.RegChange) {
+			rec := []string{fmt.Sprintf("0x%02x", c.Reg), fmt.Sprintf("%x", c.Old), fmt.Sprintf("%x", c.New)}
+			if stamp {
+				rec = append([]string{c.Time.Format(time.RFC3339Nano)}, rec...)
+			}
+			w.Write(rec)
+			w.Flush()
+		}
+	} else {
+		report = func(c i2c.RegChange) {
+			if stamp {
+				fmt.Print(c.Time.Format("15:04:05.000000"), "  ")
+			}
+			if c.Old == nil {
+				fmt.Printf("0x%02x: % x\n", c.Reg, c.New)
+			} else {
+				fmt.Printf("0x%02x: % x -> % x\n", c.Reg, c.Old, c.New)
+			}
+		}
+	}
+	err = v.Watch(ctx, regs, interval, report)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// parseReg parses a REG or REG:N register argument.
+func parseReg(s string) (i2c.RegRead, error) {
+	reg, count, ok := strings.Cut(s, ":")
+	r, err := cli.ParseUint(reg, 8)
+	if err != nil {
+		return i2c.RegRead{}, fmt.Errorf("register %q: %w", s, err)
+	}
+	n := uint64(1)
+	if ok {
+		if n, err = cli.ParseUint(count, 8); err != nil || n == 0 {
+			return i2c.RegRead{}, fmt.Errorf("register %q: count must be 1 to 255", s)
+		}
+	}
+	return i2c.RegRead{Reg: byte(r), Buf: make([]byte, n)}, nil
+}
@@ -0,0 +1,79 @@
+// Package cmdword implements the command and CRC protected word protocol
+// used by Sensirion style devices (SHT3x, SGP30, SCD30, ...).
+//
+// Instead of registers these devices take 16 bit big endian commands,
+// optionally followed by argument words, and reply with 16 bit words each
+// followed by a CRC-8 byte.
+package cmdword
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// ErrCRC is returned when a received word does not match its checksum.
+var ErrCRC = errors.New("cmdword: crc mismatch")
+
+// CRC8 computes the checksum of data with polynomial 0x31 and
+// initialization 0xFF, as specified by Sensirion.
+func CRC8(data []byte) byte {
+	crc := byte(0xFF)
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x31
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// Send sends cmd followed by the argument words, each one protected by
+// its checksum.
+func Send(v *i2c.I2C, cmd uint16, args ...uint16) error {
+	buf := make([]byte, 2, 2+3*len(args))
+	buf[0], buf[1] = byte(cmd>>8), byte(cmd)
+	for _, a := range args {
+		w := []byte{byte(a >> 8), byte(a)}
+		buf = append(buf, w[0], w[1], CRC8(w))
+	}
+	_, err := v.WriteBytes(buf)
+	return err
+}
+
+// ReadWords reads n words from the device, verifying their checksums.
+func ReadWords(v *i2c.I2C, n int) ([]uint16, error) {
+	buf := make([]byte, 3*n)
+	c, err := v.ReadBytes(buf)
+	if err != nil {
+		return nil, err
+	}
+	if c < len(buf) {
+		return nil, fmt.Errorf("cmdword: short read, got %d of %d bytes", c, len(buf))
+	}
+	words := make([]uint16, n)
+	for i := range words {
+		b := buf[3*i : 3*i+3]
+		if CRC8(b[:2]) != b[2] {
+			return nil, fmt.Errorf("%w in word %d", ErrCRC, i)
+		}
+		words[i] = uint16(b[0])<<8 | uint16(b[1])
+	}
+	return words, nil
+}
+
+// Read sends cmd, waits delay for the device to execute it, then reads n
+// words from the device.
+func Read(v *i2c.I2C, cmd uint16, delay time.Duration, n int) ([]uint16, error) {
+	if err := Send(v, cmd); err != nil {
+		return nil, err
+	}
+	time.Sleep(delay)
+	return ReadWords(v, n)
+}
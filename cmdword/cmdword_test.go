@@ -0,0 +1,78 @@
+package cmdword
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+func TestCRC8(t *testing.T) {
+	// the example of the Sensirion datasheets
+	if c := CRC8([]byte{0xBE, 0xEF}); c != 0x92 {
+		t.Fatalf("CRC8(BE EF) = 0x%02X, want 0x92", c)
+	}
+}
+
+func TestSend(t *testing.T) {
+	d := i2c.NewDryRun(nil)
+	v := i2c.NewI2CConn(d, 0x44)
+	defer v.Close()
+	if err := Send(v, 0x2400, 0xBEEF, 0x0000); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0x24, 0x00, 0xBE, 0xEF, 0x92, 0x00, 0x00, 0x81}
+	if w := d.Writes(); len(w) != 1 || !slices.Equal(w[0], want) {
+		t.Fatalf("wrote % X, want % X", w, want)
+	}
+}
+
+// words returns a connection whose reads return b.
+func words(b ...byte) *i2c.I2C {
+	seed := map[byte]byte{}
+	for i, x := range b {
+		seed[byte(i)] = x
+	}
+	return i2c.NewI2CConn(i2c.NewDryRun(seed), 0x44)
+}
+
+func TestReadWords(t *testing.T) {
+	v := words(0xBE, 0xEF, 0x92, 0x00, 0x00, 0x81)
+	defer v.Close()
+	w, err := ReadWords(v, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(w, []uint16{0xBEEF, 0}) {
+		t.Fatalf("got %04X, want [BEEF 0000]", w)
+	}
+}
+
+func TestReadWordsCRC(t *testing.T) {
+	v := words(0xBE, 0xEF, 0x92, 0x00, 0x00, 0x80)
+	defer v.Close()
+	if _, err := ReadWords(v, 2); !errors.Is(err, ErrCRC) {
+		t.Fatalf("got %v, want ErrCRC", err)
+	}
+}
+
+func TestRead(t *testing.T) {
+	for _, c := range []struct {
+		crc byte
+		err error
+	}{{0x92, nil}, {0x93, ErrCRC}} {
+		// the DryRun takes the command as the pointer 0xE0 followed by
+		// register data 0x00: the reply is read from 0xE1 on
+		d := i2c.NewDryRun(map[byte]byte{0xE1: 0xBE, 0xE2: 0xEF, 0xE3: c.crc})
+		v := i2c.NewI2CConn(d, 0x44)
+		w, err := Read(v, 0xE000, 0, 1)
+		v.Close()
+		if !errors.Is(err, c.err) {
+			t.Fatalf("crc 0x%02X: got %v, want %v", c.crc, err, c.err)
+		}
+		if err == nil && w[0] != 0xBEEF {
+			t.Fatalf("got %04X, want BEEF", w[0])
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package i2c
+
+import "context"
+
+// Coalescer queues register writes and merges runs of consecutive
+// registers into single burst writes, relying on the device to
+// auto-increment its register pointer. Writes are sent in order: queuing
+// a write which does not extend the pending run flushes the run first.
+// A Coalescer is not safe for concurrent use.
+type Coalescer struct {
+	v   *I2C
+	reg byte
+	buf []byte
+}
+
+// Coalesce returns a write coalescing queue on the connection.
+func (v *I2C) Coalesce() *Coalescer {
+	return &Coalescer{v: v}
+}
+
+// Write queues buf to be written starting from register reg.
+func (c *Coalescer) Write(reg byte, buf []byte) error {
+	if len(c.buf) > 0 && (reg != c.reg+byte(len(c.buf)) || len(c.buf)+len(buf) > regSpace) {
+		if err := c.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(c.buf) == 0 {
+		c.reg = reg
+	}
+	c.buf = append(c.buf, buf...)
+	return nil
+}
+
+// WriteRegU8 queues value to be written to register reg.
+func (c *Coalescer) WriteRegU8(reg byte, value byte) error {
+	return c.Write(reg, []byte{value})
+}
+
+// Flush writes the pending run as a burst. Bursts honor the traffic
+// shaping settings of the connection.
+func (c *Coalescer) Flush() error {
+	if len(c.buf) == 0 {
+		return nil
+	}
+	_, err := c.v.writeBlock(context.Background(), c.reg, c.buf)
+	c.buf = c.buf[:0]
+	return err
+}
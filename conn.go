@@ -0,0 +1,23 @@
+package i2c
+
+import (
+	"io"
+	"sync"
+)
+
+// Conn is the transport an I2C connection talks through: each Write and
+// Read is a single transfer with the device. NewI2C uses the /dev/i2c-N
+// character device, alternative transports are plugged with NewI2CConn.
+type Conn interface {
+	io.ReadWriteCloser
+}
+
+// NewI2CConn returns a connection to the device at addr talking through
+// c. Transactions of the connection report a bus of -1, and operations
+// are serialized per connection rather than per bus.
+func NewI2CConn(c Conn, addr uint8) *I2C {
+	v := &I2C{rc: c, bus: -1, addr: addr, mu: new(sync.Mutex)}
+	v.dev.Store(newDevice())
+	track(v)
+	return v
+}
@@ -0,0 +1,256 @@
+// Package cp2112 implements an i2c bus master on the Silicon Labs CP2112
+// USB HID to SMBus bridge, which needs no kernel driver and so works on
+// hosts other than Linux. On Linux the hid-cp2112 driver exposes the
+// bridge as a regular /dev/i2c-N bus, usable with i2c.NewI2C.
+//
+// The package speaks the HID reports of Silicon Labs AN495 over a Device
+// opened by a HID library (e.g. hidapi bindings).
+//
+//	bus, err := cp2112.NewBus(dev, cp2112.Config{Freq: 400000})
+//	if err != nil {
+//		return err
+//	}
+//	v := bus.Open(0x76)
+package cp2112
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+var (
+	// ErrNack is returned when the device does not acknowledge its
+	// address. On Linux i2c.IsNack reports true for it.
+	ErrNack error = syscall.ENXIO
+	// ErrTooLong is returned for transfers exceeding the bridge limits.
+	ErrTooLong = errors.New("cp2112: transfer too long")
+	// ErrTimeout is returned when a transfer does not complete in time.
+	ErrTimeout = errors.New("cp2112: transfer timeout")
+)
+
+// Device is a HID connection to a CP2112. Reports start with their
+// report id.
+type Device interface {
+	// Write sends an output report.
+	Write(report []byte) (int, error)
+	// Read receives an input report.
+	Read(report []byte) (int, error)
+	// SendFeatureReport sets a feature report.
+	SendFeatureReport(report []byte) (int, error)
+}
+
+// HID reports, from AN495.
+const (
+	repSMBusConfig    = 0x06
+	repReadRequest    = 0x10
+	repReadForceSend  = 0x12
+	repReadResponse   = 0x13
+	repWrite          = 0x14
+	repStatusRequest  = 0x15
+	repStatusResponse = 0x16
+	reportSize        = 64
+	maxWrite          = 61
+	maxRead           = 512
+	statusBusy        = 1
+	statusComplete    = 2
+	statusError       = 3
+	errAddrNack       = 0
+)
+
+// defaultTimeout bounds transfers when no timeout is configured.
+const defaultTimeout = time.Second
+
+// Config configures a Bus.
+type Config struct {
+	// Freq is the clock frequency in Hz, 100kHz when zero.
+	Freq int
+	// Timeout bounds each transfer, 1s when zero.
+	Timeout time.Duration
+}
+
+// Bus is an i2c bus mastered by a CP2112.
+type Bus struct {
+	mu      sync.Mutex
+	dev     Device
+	timeout time.Duration
+	buf     [reportSize]byte
+}
+
+// NewBus configures dev as an i2c master.
+func NewBus(dev Device, cfg Config) (*Bus, error) {
+	if cfg.Freq <= 0 {
+		cfg.Freq = 100000
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	ms := uint16(min(cfg.Timeout.Milliseconds(), 1000))
+	f := uint32(cfg.Freq)
+	// clock, own address, no auto send read, write and read timeouts,
+	// SCL low timeout enabled, one retry
+	rep := []byte{repSMBusConfig, byte(f >> 24), byte(f >> 16), byte(f >> 8), byte(f),
+		0x02, 0, byte(ms >> 8), byte(ms), byte(ms >> 8), byte(ms), 1, 0, 1}
+	if _, err := dev.SendFeatureReport(rep); err != nil {
+		return nil, fmt.Errorf("cp2112: configure: %w", err)
+	}
+	return &Bus{dev: dev, timeout: cfg.Timeout}, nil
+}
+
+// Conn returns a transport to addr on the bus, for i2c.NewI2CConn.
+func (b *Bus) Conn(addr uint8) *Conn {
+	return &Conn{bus: b, addr: addr}
+}
+
+// Open returns a connection to the device at addr on the bus. Register
+// block transfers are split to fit the 61 bytes writes of the bridge.
+func (b *Bus) Open(addr uint8) *i2c.I2C {
+	v := i2c.NewI2CConn(b.Conn(addr), addr)
+	v.SetTransferLimit(maxWrite - 1)
+	return v
+}
+
+// report reads input reports until one with id arrives.
+func (b *Bus) report(id byte, deadline time.Time) ([]byte, error) {
+	for {
+		n, err := b.dev.Read(b.buf[:])
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 && b.buf[0] == id {
+			return b.buf[:n], nil
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrTimeout
+		}
+	}
+}
+
+// wait polls the transfer status until the transfer completes.
+func (b *Bus) wait(deadline time.Time) error {
+	for {
+		if _, err := b.dev.Write([]byte{repStatusRequest, 0x01}); err != nil {
+			return err
+		}
+		r, err := b.report(repStatusResponse, deadline)
+		if err != nil {
+			return err
+		}
+		if len(r) < 3 {
+			return fmt.Errorf("cp2112: short status report")
+		}
+		switch r[1] {
+		case statusComplete:
+			return nil
+		case statusError:
+			if r[2] == errAddrNack {
+				return ErrNack
+			}
+			return fmt.Errorf("cp2112: transfer error %d", r[2])
+		}
+		if time.Now().After(deadline) {
+			return ErrTimeout
+		}
+	}
+}
+
+func (b *Bus) write(addr uint8, p []byte) (int, error) {
+	if len(p) > maxWrite {
+		return 0, ErrTooLong
+	}
+	if len(p) == 0 {
+		// the bridge cannot send zero length writes
+		return 0, i2c.ErrUnsupported
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	rep := append([]byte{repWrite, addr << 1, byte(len(p))}, p...)
+	if _, err := b.dev.Write(rep); err != nil {
+		return 0, err
+	}
+	if err := b.wait(time.Now().Add(b.timeout)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (b *Bus) read(addr uint8, p []byte) (int, error) {
+	if len(p) > maxRead {
+		return 0, ErrTooLong
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	deadline := time.Now().Add(b.timeout)
+	if _, err := b.dev.Write([]byte{repReadRequest, addr << 1, byte(len(p) >> 8), byte(len(p))}); err != nil {
+		return 0, err
+	}
+	if err := b.wait(deadline); err != nil {
+		return 0, err
+	}
+	// the data is held by the bridge until requested, in responses of up
+	// to 61 bytes
+	n := 0
+	for n < len(p) {
+		rem := len(p) - n
+		if _, err := b.dev.Write([]byte{repReadForceSend, byte(rem >> 8), byte(rem)}); err != nil {
+			return n, err
+		}
+		r, err := b.report(repReadResponse, deadline)
+		if err != nil {
+			return n, err
+		}
+		if len(r) < 3 || r[1] == statusError {
+			return n, fmt.Errorf("cp2112: read error")
+		}
+		c := copy(p[n:], r[3:3+min(int(r[2]), len(r)-3)])
+		if c == 0 && time.Now().After(deadline) {
+			return n, ErrTimeout
+		}
+		n += c
+	}
+	return n, nil
+}
+
+// Conn is an i2c.Conn to an address of a Bus. It implements
+// i2c.Addresser, so connections can be retargeted with SetAddr.
+type Conn struct {
+	bus  *Bus
+	mu   sync.Mutex
+	addr uint8
+}
+
+func (c *Conn) target() uint8 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.addr
+}
+
+// Write sends p, up to 61 bytes, to the device in one transfer.
+func (c *Conn) Write(p []byte) (int, error) {
+	return c.bus.write(c.target(), p)
+}
+
+// Read receives p, up to 512 bytes, from the device in one transfer.
+func (c *Conn) Read(p []byte) (int, error) {
+	return c.bus.read(c.target(), p)
+}
+
+// SetAddr retargets the connection to addr.
+func (c *Conn) SetAddr(addr uint8) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addr = addr
+	return nil
+}
+
+// Close does nothing, the Device is owned by the caller.
+func (c *Conn) Close() error {
+	return nil
+}
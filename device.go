@@ -0,0 +1,57 @@
+package i2c
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// devKey identifies a device across all the connections of the program.
+type devKey struct {
+	bus  int
+	addr uint8
+}
+
+// device holds the state shared by all the connections to a device.
+type device struct {
+	limit   limiter
+	flight  flight
+	readyAt atomic.Int64 // end of the warm-up period, in Unix ns
+}
+
+var (
+	devicesMu sync.Mutex
+	devices   = make(map[devKey]*device)
+	buses     = make(map[int]*sync.Mutex)
+)
+
+// sharedDevice returns the state shared by all the connections to the
+// device at addr on bus.
+func sharedDevice(bus int, addr uint8) *device {
+	devicesMu.Lock()
+	defer devicesMu.Unlock()
+	k := devKey{bus, addr}
+	d, ok := devices[k]
+	if !ok {
+		d = newDevice()
+		devices[k] = d
+	}
+	return d
+}
+
+func newDevice() *device {
+	d := &device{}
+	d.flight.cond.L = &d.flight.mu
+	return d
+}
+
+// busLock returns the lock serializing the operations on bus.
+func busLock(bus int) *sync.Mutex {
+	devicesMu.Lock()
+	defer devicesMu.Unlock()
+	mu, ok := buses[bus]
+	if !ok {
+		mu = new(sync.Mutex)
+		buses[bus] = mu
+	}
+	return mu
+}
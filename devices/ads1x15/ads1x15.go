@@ -0,0 +1,284 @@
+// Package ads1x15 drives the Texas Instruments ADS1015 and ADS1115
+// analog to digital converters, 12 and 16 bit respectively.
+//
+//	v, err := i2c.NewI2C(0x48, 1)
+//	...
+//	d := ads1x15.New(v, ads1x15.ADS1115)
+//	d.SetGain(ads1x15.Gain4V096)
+//	volts, err := d.Read(ads1x15.AIN0)
+//
+// Conversions are awaited by polling the config register, or by waiting
+// for the ALERT/RDY pin when one is set with SetReadyPin.
+package ads1x15
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// Registers.
+const (
+	regConversion = 0x00
+	regConfig     = 0x01
+	regLoThresh   = 0x02
+	regHiThresh   = 0x03
+)
+
+// Config register bits.
+const (
+	cfgOS       = 1 << 15 // write: start a single shot, read: idle
+	cfgSingle   = 1 << 8
+	cfgCompQue  = 3 // comparator disabled
+	cfgMuxShift = 12
+	cfgPGAShift = 9
+	cfgDRShift  = 5
+)
+
+// ErrTimeout is returned when a conversion does not complete in time.
+var ErrTimeout = errors.New("ads1x15: conversion timeout")
+
+// Model is the converter model.
+type Model uint8
+
+// Models.
+const (
+	ADS1115 Model = iota
+	ADS1015
+)
+
+// rates holds the data rates in samples per second by model.
+var rates = [2][8]int{
+	ADS1115: {8, 16, 32, 64, 128, 250, 475, 860},
+	ADS1015: {128, 250, 490, 920, 1600, 2400, 3300, 3300},
+}
+
+// Mux is the input of a conversion.
+type Mux uint8
+
+// Inputs: differential pairs, then single ended inputs against ground.
+const (
+	Diff01 Mux = iota // AIN0 - AIN1
+	Diff03            // AIN0 - AIN3
+	Diff13            // AIN1 - AIN3
+	Diff23            // AIN2 - AIN3
+	AIN0
+	AIN1
+	AIN2
+	AIN3
+)
+
+// Gain is the programmable gain amplifier setting, named by its full
+// scale range. The inputs must stay within the supply regardless.
+type Gain uint8
+
+// Gains.
+const (
+	Gain6V144 Gain = iota
+	Gain4V096
+	Gain2V048
+	Gain1V024
+	Gain0V512
+	Gain0V256
+)
+
+// fullScale holds the full scale ranges by gain.
+var fullScale = [...]float64{6.144, 4.096, 2.048, 1.024, 0.512, 0.256}
+
+// FullScale returns the full scale range of g in volts.
+func (g Gain) FullScale() float64 {
+	return fullScale[g]
+}
+
+// ReadyPin is the input connected to the ALERT/RDY pin. It is satisfied
+// by periph.io gpio.PinIn pins configured for falling edges.
+type ReadyPin interface {
+	WaitForEdge(timeout time.Duration) bool
+}
+
+// Device is an ADS1015 or ADS1115.
+type Device struct {
+	v     *i2c.I2C
+	model Model
+	gain  Gain
+	rate  uint8 // index in rates
+	ready ReadyPin
+	// cont is set in continuous mode.
+	cont bool
+}
+
+// New returns the converter of the given model talking through v, with
+// the power on settings: ±2.048V range and 128 samples per second on the
+// ADS1115, 1600 on the ADS1015.
+func New(v *i2c.I2C, m Model) *Device {
+	return &Device{v: v, model: m, gain: Gain2V048, rate: 4}
+}
+
+// SetGain sets the gain of the following conversions.
+func (d *Device) SetGain(g Gain) error {
+	if g > Gain0V256 {
+		return errors.New("ads1x15: invalid gain")
+	}
+	d.gain = g
+	return nil
+}
+
+// SetDataRate sets the data rate of the following conversions, in
+// samples per second. It must be one supported by the model.
+func (d *Device) SetDataRate(sps int) error {
+	for i, r := range rates[d.model] {
+		if r == sps {
+			d.rate = uint8(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("ads1x15: unsupported data rate %d", sps)
+}
+
+// SetReadyPin sets the pin connected to ALERT/RDY, nil to poll the
+// config register instead. The comparator is then configured to pulse
+// the pin at the end of every conversion, so it is no longer available
+// for threshold alerts.
+func (d *Device) SetReadyPin(p ReadyPin) error {
+	if p != nil {
+		// a high threshold MSB of 1 and a low one of 0 turn ALERT into
+		// the conversion ready output
+		if err := d.v.WriteRegU16BE(regHiThresh, 0x8000); err != nil {
+			return err
+		}
+		if err := d.v.WriteRegU16BE(regLoThresh, 0x0000); err != nil {
+			return err
+		}
+	}
+	d.ready = p
+	return nil
+}
+
+// period returns the duration of a conversion.
+func (d *Device) period() time.Duration {
+	return time.Second / time.Duration(rates[d.model][d.rate])
+}
+
+// config returns the config register starting a conversion of m.
+func (d *Device) config(m Mux, single bool) uint16 {
+	c := uint16(m)<<cfgMuxShift | uint16(d.gain)<<cfgPGAShift | uint16(d.rate)<<cfgDRShift
+	if single {
+		c |= cfgOS | cfgSingle
+	}
+	if d.ready == nil {
+		c |= cfgCompQue
+	}
+	return c
+}
+
+// ReadRaw performs a single shot conversion of m and returns its code,
+// 16 bit on the ADS1115 and 12 bit on the ADS1015. It stops continuous
+// mode.
+func (d *Device) ReadRaw(m Mux) (int16, error) {
+	if m > AIN3 {
+		return 0, errors.New("ads1x15: invalid input")
+	}
+	if err := d.v.WriteRegU16BE(regConfig, d.config(m, true)); err != nil {
+		return 0, err
+	}
+	d.cont = false
+	if err := d.wait(); err != nil {
+		return 0, err
+	}
+	return d.conversion()
+}
+
+// Read performs a single shot conversion of m and returns it in volts.
+func (d *Device) Read(m Mux) (float64, error) {
+	raw, err := d.ReadRaw(m)
+	if err != nil {
+		return 0, err
+	}
+	return d.Volts(raw), nil
+}
+
+// Volts converts a code returned by ReadRaw or ValueRaw to volts with
+// the current gain.
+func (d *Device) Volts(raw int16) float64 {
+	full := 1 << 15
+	if d.model == ADS1015 {
+		full = 1 << 11
+	}
+	return float64(raw) * d.gain.FullScale() / float64(full)
+}
+
+// wait waits for the end of the single shot conversion.
+func (d *Device) wait() error {
+	timeout := 2*d.period() + 2*time.Millisecond
+	if d.ready != nil {
+		if !d.ready.WaitForEdge(timeout) {
+			return ErrTimeout
+		}
+		return nil
+	}
+	// the internal oscillator is accurate to 10%
+	time.Sleep(d.period() * 9 / 10)
+	err := d.v.WaitForRegBit(context.Background(), regConfig, cfgOS>>8, true, 100*time.Microsecond, timeout)
+	if err == i2c.ErrPollTimeout {
+		return ErrTimeout
+	}
+	return err
+}
+
+// conversion reads the conversion register.
+func (d *Device) conversion() (int16, error) {
+	raw, err := d.v.ReadRegU16BE(regConversion)
+	if err != nil {
+		return 0, err
+	}
+	if d.model == ADS1015 {
+		return int16(raw) >> 4, nil
+	}
+	return int16(raw), nil
+}
+
+// StartContinuous starts converting m continuously. The conversions are
+// read with ValueRaw or Value.
+func (d *Device) StartContinuous(m Mux) error {
+	if m > AIN3 {
+		return errors.New("ads1x15: invalid input")
+	}
+	if err := d.v.WriteRegU16BE(regConfig, d.config(m, false)); err != nil {
+		return err
+	}
+	d.cont = true
+	return nil
+}
+
+// ValueRaw returns the code of a continuous conversion. With a ready pin
+// it waits for the next conversion, otherwise it returns the latest one.
+func (d *Device) ValueRaw() (int16, error) {
+	if !d.cont {
+		return 0, errors.New("ads1x15: not in continuous mode")
+	}
+	if d.ready != nil && !d.ready.WaitForEdge(2*d.period()+2*time.Millisecond) {
+		return 0, ErrTimeout
+	}
+	return d.conversion()
+}
+
+// Value returns a continuous conversion in volts, see ValueRaw.
+func (d *Device) Value() (float64, error) {
+	raw, err := d.ValueRaw()
+	if err != nil {
+		return 0, err
+	}
+	return d.Volts(raw), nil
+}
+
+// Stop stops continuous mode, powering the converter down.
+func (d *Device) Stop() error {
+	if err := d.v.WriteRegU16BE(regConfig, d.config(AIN0, false)|cfgSingle); err != nil {
+		return err
+	}
+	d.cont = false
+	return nil
+}
@@ -0,0 +1,241 @@
+// Package at24 drives 24Cxx serial EEPROMs, such as the Microchip AT24C
+// and 24LC series, of any size, page size and address width.
+//
+//	v, err := i2c.NewI2C(0x50, 1)
+//	...
+//	d, err := at24.New(v, at24.Chips["24c256"])
+//	...
+//	_, err = d.WriteAt([]byte("hello"), 0x100)
+//	_, err = d.ReadAt(buf, 0)
+//
+// Writes are split at page boundaries, and wait for the end of each write
+// cycle by polling the memory until it acknowledges its address again.
+// Parts addressing more memory than their address bytes cover, such as
+// the 24c04 to 24c16, respond on consecutive device addresses: the
+// Device retargets its connection as needed, so it must not be shared.
+package at24
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// ErrWriteProtected is returned by Update when the memory acknowledges a
+// page write but keeps its previous contents.
+var ErrWriteProtected = errors.New("at24: write ignored, is the memory write protected (WP pin high)?")
+
+// ErrVerify is returned by Update when a page reads back different from
+// what was written.
+var ErrVerify = errors.New("at24: verify failed")
+
+// readChunk is the maximum count of bytes read per transfer.
+const readChunk = 256
+
+// DefaultWriteTimeout is the default maximum duration of a write cycle,
+// well above the 5 to 10ms of most parts.
+const DefaultWriteTimeout = 50 * time.Millisecond
+
+// Geometry describes the memory organization of a 24Cxx EEPROM.
+type Geometry struct {
+	Size  int // bytes
+	Page  int // bytes per page write
+	AddrW int // memory address bytes, 1 or 2
+}
+
+// Chips are the geometries of the common 24Cxx parts.
+var Chips = map[string]Geometry{
+	"24c01":   {128, 8, 1},
+	"24c02":   {256, 8, 1},
+	"24c04":   {512, 16, 1},
+	"24c08":   {1024, 16, 1},
+	"24c16":   {2048, 16, 1},
+	"24c32":   {4096, 32, 2},
+	"24c64":   {8192, 32, 2},
+	"24c128":  {16384, 64, 2},
+	"24c256":  {32768, 64, 2},
+	"24c512":  {65536, 128, 2},
+	"24c1024": {131072, 256, 2},
+}
+
+// Device is a 24Cxx EEPROM.
+type Device struct {
+	Geometry
+	v       *i2c.I2C
+	base    uint8
+	timeout time.Duration
+}
+
+// New returns the memory with geometry g talking through v, whose address
+// is the one of the first block of the memory.
+func New(v *i2c.I2C, g Geometry) (*Device, error) {
+	switch {
+	case g.Size <= 0 || g.Page <= 0:
+		return nil, errors.New("at24: invalid geometry")
+	case g.AddrW != 1 && g.AddrW != 2:
+		return nil, fmt.Errorf("at24: address width %d: must be 1 or 2", g.AddrW)
+	}
+	return &Device{Geometry: g, v: v, base: v.Addr(), timeout: DefaultWriteTimeout}, nil
+}
+
+// SetWriteTimeout sets the maximum duration of a write cycle.
+func (d *Device) SetWriteTimeout(t time.Duration) {
+	d.timeout = t
+}
+
+// target retargets the connection to the device holding off and returns
+// the memory address bytes of off.
+func (d *Device) target(off int) ([]byte, error) {
+	bits := 8 * d.AddrW
+	if dev := d.base + uint8(off>>bits); d.v.Addr() != dev {
+		if err := d.v.SetAddr(dev); err != nil {
+			return nil, err
+		}
+	}
+	if d.AddrW == 1 {
+		return []byte{byte(off)}, nil
+	}
+	return []byte{byte(off >> 8), byte(off)}, nil
+}
+
+// span returns the count of bytes from off up to limit which do not cross
+// a multiple of align.
+func span(off, limit, align int) int {
+	return min(limit, align-off%align)
+}
+
+// clip returns the count of the n bytes from off within the memory, and
+// an error when off is outside of it.
+func (d *Device) clip(off int64, n int) (int, error) {
+	if off < 0 || off > int64(d.Size) {
+		return 0, fmt.Errorf("at24: offset 0x%X outside of the memory", off)
+	}
+	return int(min(int64(n), int64(d.Size)-off)), nil
+}
+
+// ReadAt reads len(p) bytes starting at off, implementing io.ReaderAt.
+// It returns io.EOF when reading past the end of the memory.
+func (d *Device) ReadAt(p []byte, off int64) (int, error) {
+	n, err := d.clip(off, len(p))
+	if err != nil {
+		return 0, err
+	}
+	done := 0
+	for done < n {
+		o := int(off) + done
+		c := span(o, min(n-done, readChunk), 1<<(8*d.AddrW))
+		pre, err := d.target(o)
+		if err != nil {
+			return done, err
+		}
+		if err := d.v.Tx(pre, p[done:done+c]); err != nil {
+			return done, fmt.Errorf("at24: read at 0x%X: %w", o, err)
+		}
+		done += c
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// WriteAt writes p starting at off, implementing io.WriterAt, one page
+// write at a time. Writing past the end of the memory writes what fits
+// and returns io.ErrShortWrite.
+func (d *Device) WriteAt(p []byte, off int64) (int, error) {
+	n, err := d.clip(off, len(p))
+	if err != nil {
+		return 0, err
+	}
+	done := 0
+	for done < n {
+		c := span(int(off)+done, n-done, d.Page)
+		if err := d.writePage(int(off)+done, p[done:done+c]); err != nil {
+			return done, err
+		}
+		done += c
+	}
+	if n < len(p) {
+		return n, io.ErrShortWrite
+	}
+	return n, nil
+}
+
+// Update writes data starting at off like WriteAt, but skips the pages
+// already holding their data, sparing write cycles, and reads back every
+// page written. A memory keeping its previous contents is reported with
+// ErrWriteProtected. progress, if not nil, is called after each page.
+// Update returns the count of page writes.
+func (d *Device) Update(off int, data []byte, progress func(done, total int)) (int, error) {
+	if off < 0 || off+len(data) > d.Size {
+		return 0, fmt.Errorf("at24: data at 0x%X-0x%X beyond the memory size 0x%X", off, off+len(data)-1, d.Size)
+	}
+	pages := 0
+	old := make([]byte, d.Page)
+	got := make([]byte, d.Page)
+	for done := 0; done < len(data); {
+		o := off + done
+		n := span(o, len(data)-done, d.Page)
+		p := data[done : done+n]
+		if _, err := d.ReadAt(old[:n], int64(o)); err != nil {
+			return pages, err
+		}
+		if !bytes.Equal(old[:n], p) {
+			if err := d.writePage(o, p); err != nil {
+				return pages, err
+			}
+			pages++
+			if _, err := d.ReadAt(got[:n], int64(o)); err != nil {
+				return pages, err
+			}
+			switch {
+			case bytes.Equal(got[:n], old[:n]):
+				return pages, fmt.Errorf("%w at 0x%X", ErrWriteProtected, o)
+			case !bytes.Equal(got[:n], p):
+				return pages, fmt.Errorf("%w at 0x%X", ErrVerify, o)
+			}
+		}
+		done += n
+		if progress != nil {
+			progress(done, len(data))
+		}
+	}
+	return pages, nil
+}
+
+// writePage writes p, which must not cross a page boundary, at off and
+// waits for the end of the write cycle.
+func (d *Device) writePage(off int, p []byte) error {
+	pre, err := d.target(off)
+	if err != nil {
+		return err
+	}
+	if _, err := d.v.WriteBytes(append(pre, p...)); err != nil {
+		return fmt.Errorf("at24: write at 0x%X: %w", off, err)
+	}
+	return d.ackPoll()
+}
+
+// pollInterval is the delay between the acknowledge polls of ackPoll, a
+// fraction of the typical 5ms write cycle.
+const pollInterval = 500 * time.Microsecond
+
+// ackPoll waits for the end of the write cycle: the memory does not
+// acknowledge its address while programming.
+func (d *Device) ackPoll() error {
+	deadline := time.Now().Add(d.timeout)
+	for {
+		err := d.v.Ping()
+		if err == nil {
+			return nil
+		}
+		if !i2c.IsNack(err) || time.Now().After(deadline) {
+			return fmt.Errorf("at24: write cycle: %w", err)
+		}
+		time.Sleep(pollInterval)
+	}
+}
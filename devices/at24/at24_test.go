@@ -0,0 +1,95 @@
+package at24
+
+import (
+	"bytes"
+	"testing"
+
+	i2c "github.com/fedeonline/i2c-go"
+	"github.com/fedeonline/i2c-go/i2ctest"
+)
+
+// blocks is an i2c.Bus of 256 byte memory blocks at consecutive
+// addresses from 0x50, as the 24c04 to 24c16 respond.
+type blocks []*i2ctest.Fake
+
+func (b blocks) Tx(addr uint16, w, r []byte) error {
+	i := int(addr) - 0x50
+	if i < 0 || i >= len(b) {
+		return i2ctest.ErrNack
+	}
+	if _, err := b[i].Write(w); err != nil {
+		return err
+	}
+	_, err := b[i].Read(r)
+	return err
+}
+
+// writes returns the memory writes to f, without the pointer writes and
+// acknowledge polls.
+func writes(f *i2ctest.Fake) [][]byte {
+	var l [][]byte
+	for _, w := range f.Writes() {
+		if len(w) > 1 {
+			l = append(l, w)
+		}
+	}
+	return l
+}
+
+func newBlocks(t *testing.T, n int) (*Device, blocks) {
+	b := make(blocks, n)
+	for i := range b {
+		b[i] = i2ctest.NewFake(nil)
+	}
+	v := i2c.OpenBus(b, 0x50)
+	t.Cleanup(func() { v.Close() })
+	d, err := New(v, Chips["24c04"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, b
+}
+
+func TestWritePages(t *testing.T) {
+	d, b := newBlocks(t, 2)
+	p := make([]byte, 40)
+	for i := range p {
+		p[i] = byte(i)
+	}
+	if n, err := d.WriteAt(p, 0xF8); n != len(p) || err != nil {
+		t.Fatalf("got %d, %v", n, err)
+	}
+	// 8 bytes up to the end of the first block, then two 16 byte pages
+	want := [][][]byte{
+		{append([]byte{0xF8}, p[:8]...)},
+		{append([]byte{0x00}, p[8:24]...), append([]byte{0x10}, p[24:]...)},
+	}
+	for i, f := range b {
+		got := writes(f)
+		if len(got) != len(want[i]) {
+			t.Fatalf("block %d: got writes % X, want % X", i, got, want[i])
+		}
+		for j := range got {
+			if !bytes.Equal(got[j], want[i][j]) {
+				t.Errorf("block %d write %d: got % X, want % X", i, j, got[j], want[i][j])
+			}
+		}
+	}
+}
+
+func TestReadBlocks(t *testing.T) {
+	d, b := newBlocks(t, 2)
+	b[0].SetReg(0xF0, bytes.Repeat([]byte{0xAA}, 16)...)
+	b[1].SetReg(0x00, bytes.Repeat([]byte{0xBB}, 16)...)
+	p := make([]byte, 32)
+	if n, err := d.ReadAt(p, 0xF0); n != len(p) || err != nil {
+		t.Fatalf("got %d, %v", n, err)
+	}
+	want := append(bytes.Repeat([]byte{0xAA}, 16), bytes.Repeat([]byte{0xBB}, 16)...)
+	if !bytes.Equal(p, want) {
+		t.Errorf("read % X, want % X", p, want)
+	}
+	if a := d.v.Addr(); a != 0x51 {
+		t.Errorf("connection left at 0x%02X, want the second block 0x51", a)
+	}
+}
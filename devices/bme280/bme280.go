@@ -0,0 +1,368 @@
+// Package bme280 drives the Bosch BME280 humidity, pressure and
+// temperature sensor and its pressure only sibling, the BMP280.
+//
+//	v, err := i2c.NewI2C(0x76, 1)
+//	...
+//	d, err := bme280.New(v, nil)
+//	...
+//	r, err := d.Read()
+//	fmt.Printf("%.2f°C %.0fPa %.1f%%\n", r.Temperature, r.Pressure, r.Humidity)
+//
+// Readings are compensated with the factory calibration of the chip,
+// using the integer formulas of the datasheet.
+package bme280
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// Chip IDs.
+const (
+	IDBMP280 = 0x58
+	IDBME280 = 0x60
+)
+
+// Registers.
+const (
+	regCalib00  = 0x88 // T1 to H1, 26 bytes
+	regChipID   = 0xD0
+	regReset    = 0xE0
+	regCalib26  = 0xE1 // H2 to H6, 7 bytes
+	regCtrlHum  = 0xF2
+	regStatus   = 0xF3
+	regCtrlMeas = 0xF4
+	regConfig   = 0xF5
+	regData     = 0xF7 // press, temp, hum
+)
+
+const (
+	resetValue     = 0xB6
+	statusMeasure  = 0x08
+	skippedSample  = 0x80000
+	skippedHumSamp = 0x8000
+)
+
+// ErrChip is returned when the device does not identify as a BME280 or a
+// BMP280.
+var ErrChip = errors.New("bme280: unknown chip id")
+
+// Oversampling is the oversampling of a measurement. Off skips it.
+type Oversampling uint8
+
+// Oversampling settings.
+const (
+	Off Oversampling = iota
+	X1
+	X2
+	X4
+	X8
+	X16
+)
+
+// Filter is the coefficient of the IIR filter smoothing pressure and
+// temperature.
+type Filter uint8
+
+// Filter settings.
+const (
+	FilterOff Filter = iota
+	Filter2
+	Filter4
+	Filter8
+	Filter16
+)
+
+// Mode is the power mode of the sensor.
+type Mode uint8
+
+// Modes. In forced mode Read triggers a single measurement, in normal
+// mode the sensor measures continuously, pausing Standby in between.
+const (
+	Sleep  Mode = 0
+	Forced Mode = 1
+	Normal Mode = 3
+)
+
+// Standby is the pause between measurements in normal mode.
+type Standby uint8
+
+// Standby settings.
+const (
+	Standby500us Standby = iota
+	Standby62ms
+	Standby125ms
+	Standby250ms
+	Standby500ms
+	Standby1s
+	Standby10ms
+	Standby20ms
+)
+
+// Config is the measurement configuration.
+type Config struct {
+	Temperature Oversampling
+	Pressure    Oversampling
+	// Humidity is ignored by the BMP280.
+	Humidity Oversampling
+	Filter   Filter
+	Mode     Mode
+	Standby  Standby
+}
+
+// DefaultConfig is the weather monitoring setting of the datasheet:
+// single samples in forced mode, without filter.
+var DefaultConfig = Config{
+	Temperature: X1,
+	Pressure:    X1,
+	Humidity:    X1,
+	Mode:        Forced,
+}
+
+// Reading is a compensated measurement. Skipped quantities are NaN.
+type Reading struct {
+	Temperature float64 // °C
+	Pressure    float64 // Pa
+	Humidity    float64 // %RH
+}
+
+// calib holds the calibration coefficients.
+type calib struct {
+	t1                             uint16
+	t2, t3                         int16
+	p1                             uint16
+	p2, p3, p4, p5, p6, p7, p8, p9 int16
+	h1, h3                         uint8
+	h2, h4, h5                     int16
+	h6                             int8
+}
+
+// Device is a BME280 or BMP280.
+type Device struct {
+	v   *i2c.I2C
+	id  byte
+	cal calib
+	cfg Config
+}
+
+// New returns the sensor talking through v, after checking its chip id
+// and reading its calibration, configured with cfg, DefaultConfig when
+// nil.
+func New(v *i2c.I2C, cfg *Config) (*Device, error) {
+	id, err := v.ReadRegU8(regChipID)
+	if err != nil {
+		return nil, err
+	}
+	if id != IDBME280 && id != IDBMP280 {
+		return nil, fmt.Errorf("%w 0x%02X", ErrChip, id)
+	}
+	d := &Device{v: v, id: id}
+	if err := d.readCalib(); err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		cfg = &DefaultConfig
+	}
+	if err := d.Configure(*cfg); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ID returns the chip id, IDBME280 or IDBMP280.
+func (d *Device) ID() byte {
+	return d.id
+}
+
+// HasHumidity reports whether the sensor measures humidity.
+func (d *Device) HasHumidity() bool {
+	return d.id == IDBME280
+}
+
+func (d *Device) readCalib() error {
+	var b [26]byte
+	if _, err := d.v.ReadRegBytesInto(regCalib00, b[:]); err != nil {
+		return err
+	}
+	le := binary.LittleEndian
+	c := &d.cal
+	c.t1 = le.Uint16(b[0:])
+	c.t2 = int16(le.Uint16(b[2:]))
+	c.t3 = int16(le.Uint16(b[4:]))
+	c.p1 = le.Uint16(b[6:])
+	for i, p := range []*int16{&c.p2, &c.p3, &c.p4, &c.p5, &c.p6, &c.p7, &c.p8, &c.p9} {
+		*p = int16(le.Uint16(b[8+2*i:]))
+	}
+	if d.id != IDBME280 {
+		return nil
+	}
+	c.h1 = b[25]
+	var h [7]byte
+	if _, err := d.v.ReadRegBytesInto(regCalib26, h[:]); err != nil {
+		return err
+	}
+	c.h2 = int16(le.Uint16(h[0:]))
+	c.h3 = h[2]
+	c.h4 = int16(int8(h[3]))<<4 | int16(h[4]&0x0F)
+	c.h5 = int16(int8(h[5]))<<4 | int16(h[4]>>4)
+	c.h6 = int8(h[6])
+	return nil
+}
+
+// Configure applies cfg. The sensor is put to sleep first, as writes to
+// the config register may be ignored in normal mode.
+func (d *Device) Configure(cfg Config) error {
+	if cfg.Temperature == Off {
+		return errors.New("bme280: temperature is needed to compensate the other measurements")
+	}
+	if cfg.Temperature > X16 || cfg.Pressure > X16 || cfg.Humidity > X16 ||
+		cfg.Filter > Filter16 || cfg.Standby > Standby20ms ||
+		cfg.Mode != Sleep && cfg.Mode != Forced && cfg.Mode != Normal {
+		return errors.New("bme280: invalid configuration")
+	}
+	if err := d.v.WriteRegU8(regCtrlMeas, 0); err != nil {
+		return err
+	}
+	if err := d.v.WriteRegU8(regConfig, byte(cfg.Standby)<<5|byte(cfg.Filter)<<2); err != nil {
+		return err
+	}
+	if d.HasHumidity() {
+		// takes effect with the following ctrl_meas write
+		if err := d.v.WriteRegU8(regCtrlHum, byte(cfg.Humidity)); err != nil {
+			return err
+		}
+	}
+	mode := cfg.Mode
+	if mode == Forced {
+		// measurements are triggered by Read
+		mode = Sleep
+	}
+	if err := d.v.WriteRegU8(regCtrlMeas, d.ctrlMeas(cfg, mode)); err != nil {
+		return err
+	}
+	d.cfg = cfg
+	return nil
+}
+
+func (d *Device) ctrlMeas(cfg Config, mode Mode) byte {
+	return byte(cfg.Temperature)<<5 | byte(cfg.Pressure)<<2 | byte(mode)
+}
+
+// Reset performs a soft reset, restoring the power on configuration:
+// sleep mode with every measurement skipped.
+func (d *Device) Reset() error {
+	if err := d.v.WriteRegU8(regReset, resetValue); err != nil {
+		return err
+	}
+	// the calibration is copied to the registers in 2ms
+	time.Sleep(2 * time.Millisecond)
+	d.cfg = Config{}
+	return nil
+}
+
+// measureTime returns the maximum duration of a measurement, from
+// appendix B of the datasheet.
+func (d *Device) measureTime() time.Duration {
+	us := 1250 + 2300*osrs(d.cfg.Temperature)
+	if n := osrs(d.cfg.Pressure); n > 0 {
+		us += 2300*n + 575
+	}
+	if n := osrs(d.cfg.Humidity); n > 0 && d.HasHumidity() {
+		us += 2300*n + 575
+	}
+	return time.Duration(us) * time.Microsecond
+}
+
+// osrs returns the count of samples of o.
+func osrs(o Oversampling) int {
+	if o == Off {
+		return 0
+	}
+	return 1 << (o - 1)
+}
+
+// Read returns a measurement. In forced mode it triggers the measurement
+// and waits for its completion, in normal mode it returns the latest one.
+func (d *Device) Read() (Reading, error) {
+	if d.cfg.Mode == Sleep || d.cfg.Temperature == Off {
+		return Reading{}, errors.New("bme280: sensor not configured to measure")
+	}
+	if d.cfg.Mode == Forced {
+		if err := d.v.WriteRegU8(regCtrlMeas, d.ctrlMeas(d.cfg, Forced)); err != nil {
+			return Reading{}, err
+		}
+		time.Sleep(d.measureTime())
+		err := d.v.WaitForRegBit(context.Background(), regStatus, statusMeasure, false, time.Millisecond, 100*time.Millisecond)
+		if err != nil {
+			return Reading{}, err
+		}
+	}
+	n := 6
+	if d.HasHumidity() {
+		n = 8
+	}
+	var b [8]byte
+	if _, err := d.v.ReadRegBytesInto(regData, b[:n]); err != nil {
+		return Reading{}, err
+	}
+	adcP := int32(b[0])<<12 | int32(b[1])<<4 | int32(b[2])>>4
+	adcT := int32(b[3])<<12 | int32(b[4])<<4 | int32(b[5])>>4
+	adcH := int32(b[6])<<8 | int32(b[7])
+	if adcT == skippedSample {
+		return Reading{}, errors.New("bme280: temperature measurement skipped")
+	}
+	tFine, t := d.cal.temperature(adcT)
+	r := Reading{Temperature: float64(t) / 100, Pressure: math.NaN(), Humidity: math.NaN()}
+	if adcP != skippedSample {
+		r.Pressure = float64(d.cal.pressure(adcP, tFine)) / 256
+	}
+	if d.HasHumidity() && adcH != skippedHumSamp {
+		r.Humidity = float64(d.cal.humidity(adcH, tFine)) / 1024
+	}
+	return r, nil
+}
+
+// temperature returns the fine temperature and the temperature in
+// hundredths of °C.
+func (c *calib) temperature(adc int32) (tFine, t int32) {
+	var1 := ((adc>>3 - int32(c.t1)<<1) * int32(c.t2)) >> 11
+	x := adc>>4 - int32(c.t1)
+	var2 := (((x * x) >> 12) * int32(c.t3)) >> 14
+	tFine = var1 + var2
+	return tFine, (tFine*5 + 128) >> 8
+}
+
+// pressure returns the pressure in Pa, Q24.8.
+func (c *calib) pressure(adc, tFine int32) uint32 {
+	var1 := int64(tFine) - 128000
+	var2 := var1 * var1 * int64(c.p6)
+	var2 += (var1 * int64(c.p5)) << 17
+	var2 += int64(c.p4) << 35
+	var1 = (var1*var1*int64(c.p3))>>8 + (var1*int64(c.p2))<<12
+	var1 = ((int64(1)<<47 + var1) * int64(c.p1)) >> 33
+	if var1 == 0 {
+		return 0
+	}
+	p := int64(1048576 - adc)
+	p = ((p<<31 - var2) * 3125) / var1
+	var1 = (int64(c.p9) * (p >> 13) * (p >> 13)) >> 25
+	var2 = (int64(c.p8) * p) >> 19
+	return uint32((p+var1+var2)>>8 + int64(c.p7)<<4)
+}
+
+// humidity returns the relative humidity in %, Q22.10.
+func (c *calib) humidity(adc, tFine int32) uint32 {
+	x := tFine - 76800
+	x = ((adc<<14 - int32(c.h4)<<20 - int32(c.h5)*x + 16384) >> 15) *
+		((((((x*int32(c.h6))>>10)*(((x*int32(c.h3))>>11)+32768))>>10+2097152)*int32(c.h2) + 8192) >> 14)
+	x -= ((((x >> 15) * (x >> 15)) >> 7) * int32(c.h1)) >> 4
+	x = max(x, 0)
+	x = min(x, 419430400)
+	return uint32(x >> 12)
+}
@@ -0,0 +1,106 @@
+package bme280
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/fedeonline/i2c-go/i2ctest"
+)
+
+// Compensation example of the BMP280 datasheet, section 3.12, and typical
+// humidity coefficients of a BME280.
+var (
+	exampleT = [3]int{27504, 26435, -1000}
+	exampleP = [9]int{36477, -10685, 3024, 2855, 140, -7, 15500, -14600, 6000}
+	exampleH = calib{h1: 75, h2: 362, h3: 0, h4: 313, h5: 50, h6: 30}
+)
+
+const (
+	exampleAdcT = 519888
+	exampleAdcP = 415148
+	exampleAdcH = 30000
+)
+
+// seed returns the registers of a BME280 with the example calibration
+// and measurement.
+func seed() map[byte]byte {
+	regs := map[byte]byte{regChipID: IDBME280}
+	var b [26]byte
+	le := binary.LittleEndian
+	for i, c := range append(exampleT[:], exampleP[:]...) {
+		le.PutUint16(b[2*i:], uint16(int16(c)))
+	}
+	b[25] = exampleH.h1
+	for i, v := range b {
+		regs[regCalib00+byte(i)] = v
+	}
+	h := exampleH
+	for i, v := range []byte{
+		byte(h.h2), byte(h.h2 >> 8), h.h3,
+		byte(h.h4 >> 4), byte(h.h5&0x0F)<<4 | byte(h.h4&0x0F), byte(h.h5 >> 4),
+		byte(h.h6),
+	} {
+		regs[regCalib26+byte(i)] = v
+	}
+	for i, v := range []byte{
+		exampleAdcP >> 12, exampleAdcP >> 4 & 0xFF, exampleAdcP << 4 & 0xFF,
+		exampleAdcT >> 12, exampleAdcT >> 4 & 0xFF, exampleAdcT << 4 & 0xFF,
+		exampleAdcH >> 8, exampleAdcH & 0xFF,
+	} {
+		regs[regData+byte(i)] = v
+	}
+	return regs
+}
+
+func TestCalibration(t *testing.T) {
+	v, _ := i2ctest.New(t, 0x76, seed())
+	cfg := DefaultConfig
+	cfg.Mode = Normal
+	d, err := New(v, &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := d.cal
+	got := []int{int(c.t1), int(c.t2), int(c.t3),
+		int(c.p1), int(c.p2), int(c.p3), int(c.p4), int(c.p5), int(c.p6), int(c.p7), int(c.p8), int(c.p9)}
+	want := append(exampleT[:], exampleP[:]...)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("coefficient %d: got %d, want %d", i, got[i], want[i])
+		}
+	}
+	h := exampleH
+	if c.h1 != h.h1 || c.h2 != h.h2 || c.h3 != h.h3 || c.h4 != h.h4 || c.h5 != h.h5 || c.h6 != h.h6 {
+		t.Errorf("got humidity coefficients %+v, want %+v", c, h)
+	}
+}
+
+func TestCompensation(t *testing.T) {
+	v, _ := i2ctest.New(t, 0x76, seed())
+	cfg := DefaultConfig
+	cfg.Mode = Normal
+	d, err := New(v, &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tFine, temp := d.cal.temperature(exampleAdcT)
+	if tFine != 128422 || temp != 2508 {
+		t.Errorf("got t_fine %d and %d, want 128422 and 2508", tFine, temp)
+	}
+	r, err := d.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the integer formulas stay within a few hundredths of the floating
+	// point ones: 100653.27Pa for the example, and the humidity x of
+	// section 4.2.3 of the BME280 datasheet
+	h := exampleH
+	x := float64(tFine) - 76800
+	x = (exampleAdcH - (float64(h.h4)*64 + float64(h.h5)/16384*x)) *
+		(float64(h.h2) / 65536 * (1 + float64(h.h6)/67108864*x*(1+float64(h.h3)/67108864*x)))
+	x *= 1 - float64(h.h1)*x/524288
+	if r.Temperature != 25.08 || math.Abs(r.Pressure-100653.27) > 0.05 || math.Abs(r.Humidity-x) > 0.05 {
+		t.Errorf("got %+v, want 25.08°C, 100653.27Pa and %.2f%%", r, x)
+	}
+}
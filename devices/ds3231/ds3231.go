@@ -0,0 +1,336 @@
+// Package ds3231 drives the Maxim DS3231 temperature compensated real
+// time clock.
+//
+//	v, err := i2c.NewI2C(0x68, 1)
+//	...
+//	d := ds3231.New(v)
+//	t, err := d.Now()
+//	...
+//	err = d.Set(time.Now())
+//
+// The clock keeps a calendar date and time of day without time zone. The
+// Device interprets it in a Location, UTC unless changed with
+// SetLocation: keeping UTC in the clock avoids any ambiguity around
+// daylight saving time changes.
+package ds3231
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// Registers.
+const (
+	regSeconds = 0x00 // seconds, minutes, hours, day, date, month, year
+	regAlarm1  = 0x07 // seconds, minutes, hours, day/date
+	regAlarm2  = 0x0B // minutes, hours, day/date
+	regControl = 0x0E
+	regStatus  = 0x0F
+	regAging   = 0x10
+	regTemp    = 0x11 // MSB, LSB
+)
+
+// Control register bits.
+const (
+	ctlBBSQW = 1 << 6
+	ctlCONV  = 1 << 5
+	ctlRS    = 3 << 3
+	ctlINTCN = 1 << 2
+	ctlA2IE  = 1 << 1
+	ctlA1IE  = 1 << 0
+)
+
+// Status register bits.
+const (
+	stOSF     = 1 << 7
+	stEN32kHz = 1 << 3
+	stBSY     = 1 << 2
+	stA2F     = 1 << 1
+	stA1F     = 1 << 0
+)
+
+const (
+	hour12    = 1 << 6 // of the hours register
+	pm        = 1 << 5 // in 12 hour mode
+	century   = 1 << 7 // of the month register
+	alarmMask = 1 << 7 // of the alarm registers
+	dayOfWeek = 1 << 6 // of the alarm day/date registers
+)
+
+// ErrOscillatorStopped is returned by Now when the oscillator stopped
+// since the time was last set, e.g. because the backup battery ran out,
+// so that the time is not valid.
+var ErrOscillatorStopped = errors.New("ds3231: oscillator stopped, time not valid")
+
+// Device is a DS3231.
+type Device struct {
+	v   *i2c.I2C
+	loc *time.Location
+}
+
+// New returns the clock talking through v.
+func New(v *i2c.I2C) *Device {
+	return &Device{v: v, loc: time.UTC}
+}
+
+// SetLocation sets the time zone of the time kept by the clock.
+func (d *Device) SetLocation(loc *time.Location) {
+	d.loc = loc
+}
+
+// Now returns the time of the clock, in the Location of the Device.
+func (d *Device) Now() (time.Time, error) {
+	st, err := d.v.ReadRegU8(regStatus)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if st&stOSF != 0 {
+		return time.Time{}, ErrOscillatorStopped
+	}
+	// a single read, as the clock latches the registers at its start
+	var b [7]byte
+	if _, err := d.v.ReadRegBytesInto(regSeconds, b[:]); err != nil {
+		return time.Time{}, err
+	}
+	sec, err1 := i2c.FromBCD(b[0])
+	minute, err2 := i2c.FromBCD(b[1])
+	hour, err3 := hours(b[2])
+	day, err4 := i2c.FromBCD(b[4])
+	month, err5 := i2c.FromBCD(b[5] &^ century)
+	year, err6 := i2c.FromBCD(b[6])
+	if err := errors.Join(err1, err2, err3, err4, err5, err6); err != nil {
+		return time.Time{}, fmt.Errorf("ds3231: invalid time % x: %w", b, err)
+	}
+	y := 2000 + int(year)
+	if b[5]&century != 0 {
+		y += 100
+	}
+	return time.Date(y, time.Month(month), int(day), int(hour), int(minute), int(sec), 0, d.loc), nil
+}
+
+// hours decodes an hours register, in 12 or 24 hour mode.
+func hours(b byte) (byte, error) {
+	if b&hour12 == 0 {
+		return i2c.FromBCD(b)
+	}
+	h, err := i2c.FromBCD(b & 0x1F)
+	if err != nil || h < 1 || h > 12 {
+		return 0, i2c.ErrBCD
+	}
+	h %= 12
+	if b&pm != 0 {
+		h += 12
+	}
+	return h, nil
+}
+
+// Set sets the clock to t, converted to the Location of the Device and
+// truncated to the second, and clears the oscillator stopped flag. The
+// clock covers the years 2000 to 2199.
+func (d *Device) Set(t time.Time) error {
+	t = t.In(d.loc)
+	y := t.Year() - 2000
+	if y < 0 || y > 199 {
+		return fmt.Errorf("ds3231: year %d out of range", t.Year())
+	}
+	b := []byte{
+		bcd(t.Second()),
+		bcd(t.Minute()),
+		bcd(t.Hour()), // 24 hour mode
+		byte(t.Weekday()) + 1,
+		bcd(t.Day()),
+		bcd(int(t.Month())),
+		bcd(y % 100),
+	}
+	if y >= 100 {
+		b[5] |= century
+	}
+	if _, err := d.v.WriteRegBytes(regSeconds, b); err != nil {
+		return err
+	}
+	return d.v.UpdateRegU8(regStatus, stOSF, 0)
+}
+
+// bcd encodes n, below 100, to packed BCD.
+func bcd(n int) byte {
+	b, _ := i2c.ToBCD(byte(n))
+	return b
+}
+
+// OscillatorStopped reports whether the oscillator stopped since the time
+// was last set.
+func (d *Device) OscillatorStopped() (bool, error) {
+	st, err := d.v.ReadRegU8(regStatus)
+	return st&stOSF != 0, err
+}
+
+// AlarmRate selects the fields of the alarm time to match.
+type AlarmRate uint8
+
+// Alarm rates. Alarm 1 matches seconds, alarm 2 fires at second 00.
+const (
+	EverySecond  AlarmRate = iota // alarm 1 only
+	EveryMinute                   // alarm 2 only
+	MatchSeconds                  // alarm 1 only
+	MatchMinutes                  // and seconds
+	MatchHours                    // and minutes and seconds
+	MatchDate                     // day of month and time of day
+	MatchWeekday                  // day of week and time of day
+)
+
+// masks holds the match bits of the alarm registers by rate, for the
+// seconds, minutes, hours and day/date registers; 1 ignores the field.
+var masks = [...][4]bool{
+	EverySecond:  {true, true, true, true},
+	EveryMinute:  {true, true, true, true},
+	MatchSeconds: {false, true, true, true},
+	MatchMinutes: {false, false, true, true},
+	MatchHours:   {false, false, false, true},
+	MatchDate:    {false, false, false, false},
+	MatchWeekday: {false, false, false, false},
+}
+
+// SetAlarm sets alarm 1 or 2 to fire at the fields of t, converted to the
+// Location of the Device, selected by r. A fired alarm sets its flag,
+// reported by Alarm, and asserts INT/SQW if enabled by
+// SetAlarmInterrupt.
+func (d *Device) SetAlarm(n int, t time.Time, r AlarmRate) error {
+	if r > MatchWeekday {
+		return errors.New("ds3231: invalid alarm rate")
+	}
+	t = t.In(d.loc)
+	day := bcd(t.Day())
+	if r == MatchWeekday {
+		day = byte(t.Weekday()) + 1 | dayOfWeek
+	}
+	b := []byte{bcd(t.Second()), bcd(t.Minute()), bcd(t.Hour()), day}
+	for i, m := range masks[r] {
+		if m {
+			b[i] |= alarmMask
+		}
+	}
+	switch {
+	case n == 1 && r != EveryMinute:
+		_, err := d.v.WriteRegBytes(regAlarm1, b)
+		return err
+	case n == 2 && r != EverySecond && r != MatchSeconds:
+		_, err := d.v.WriteRegBytes(regAlarm2, b[1:])
+		return err
+	}
+	return fmt.Errorf("ds3231: invalid rate %d for alarm %d", r, n)
+}
+
+// alarmBits returns the interrupt enable and flag bits of alarm n.
+func alarmBits(n int) (ie, flag byte, err error) {
+	switch n {
+	case 1:
+		return ctlA1IE, stA1F, nil
+	case 2:
+		return ctlA2IE, stA2F, nil
+	}
+	return 0, 0, fmt.Errorf("ds3231: no alarm %d", n)
+}
+
+// SetAlarmInterrupt enables or disables the assertion of INT/SQW by alarm
+// n. Enabling it switches the pin from square wave output to interrupt
+// output.
+func (d *Device) SetAlarmInterrupt(n int, on bool) error {
+	ie, _, err := alarmBits(n)
+	if err != nil {
+		return err
+	}
+	if !on {
+		return d.v.UpdateRegU8(regControl, ie, 0)
+	}
+	return d.v.UpdateRegU8(regControl, ie|ctlINTCN, ie|ctlINTCN)
+}
+
+// Alarm reports whether alarm n fired since its flag was cleared.
+func (d *Device) Alarm(n int) (bool, error) {
+	_, flag, err := alarmBits(n)
+	if err != nil {
+		return false, err
+	}
+	st, err := d.v.ReadRegU8(regStatus)
+	return st&flag != 0, err
+}
+
+// ClearAlarm clears the flag of alarm n, releasing INT/SQW.
+func (d *Device) ClearAlarm(n int) error {
+	_, flag, err := alarmBits(n)
+	if err != nil {
+		return err
+	}
+	return d.v.UpdateRegU8(regStatus, flag, 0)
+}
+
+// SquareWave is the frequency of the square wave output.
+type SquareWave uint8
+
+// Square wave frequencies.
+const (
+	SquareWave1Hz SquareWave = iota
+	SquareWave1024Hz
+	SquareWave4096Hz
+	SquareWave8192Hz
+)
+
+// SetSquareWave switches INT/SQW to a square wave of frequency f, also
+// on battery power if battery is set. Alarms no longer assert the pin.
+func (d *Device) SetSquareWave(f SquareWave, battery bool) error {
+	if f > SquareWave8192Hz {
+		return errors.New("ds3231: invalid square wave frequency")
+	}
+	c := byte(f) << 3
+	if battery {
+		c |= ctlBBSQW
+	}
+	return d.v.UpdateRegU8(regControl, ctlINTCN|ctlRS|ctlBBSQW, c)
+}
+
+// Set32kHz enables or disables the 32kHz output.
+func (d *Device) Set32kHz(on bool) error {
+	var b byte
+	if on {
+		b = stEN32kHz
+	}
+	return d.v.UpdateRegU8(regStatus, stEN32kHz, b)
+}
+
+// Aging returns the aging offset, trimming the oscillator frequency by
+// about 0.1ppm per step, positive values slowing it down.
+func (d *Device) Aging() (int8, error) {
+	b, err := d.v.ReadRegU8(regAging)
+	return int8(b), err
+}
+
+// SetAging sets the aging offset and starts a temperature conversion to
+// apply it at once.
+func (d *Device) SetAging(offset int8) error {
+	if err := d.v.WriteRegU8(regAging, byte(offset)); err != nil {
+		return err
+	}
+	return d.convert()
+}
+
+// convert starts a temperature conversion, unless one is in progress.
+func (d *Device) convert() error {
+	st, err := d.v.ReadRegU8(regStatus)
+	if err != nil || st&stBSY != 0 {
+		return err
+	}
+	return d.v.UpdateRegU8(regControl, ctlCONV, ctlCONV)
+}
+
+// Temperature returns the temperature of the chip in °C, with a
+// resolution of 0.25°C. The chip converts it every 64 seconds.
+func (d *Device) Temperature() (float64, error) {
+	raw, err := d.v.ReadRegS16BE(regTemp)
+	if err != nil {
+		return 0, err
+	}
+	return float64(raw>>6) / 4, nil
+}
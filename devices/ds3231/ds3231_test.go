@@ -0,0 +1,90 @@
+package ds3231
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/fedeonline/i2c-go/i2ctest"
+)
+
+func TestSetNow(t *testing.T) {
+	v, f := i2ctest.New(t, 0x68, map[byte]byte{regStatus: stOSF})
+	d := New(v)
+	if _, err := d.Now(); err != ErrOscillatorStopped {
+		t.Fatalf("got %v, want ErrOscillatorStopped", err)
+	}
+	tm := time.Date(2124, time.December, 31, 23, 59, 58, 0, time.UTC)
+	if err := d.Set(tm); err != nil {
+		t.Fatal(err)
+	}
+	// Sunday is day 1, the century bit is set past 2099
+	want := []byte{0x58, 0x59, 0x23, 0x01, 0x31, 0x92, 0x24}
+	if got := f.Regs(regSeconds, 7); !bytes.Equal(got, want) {
+		t.Errorf("time registers % X, want % X", got, want)
+	}
+	if f.Reg(regStatus)&stOSF != 0 {
+		t.Error("oscillator stopped flag not cleared")
+	}
+	got, err := d.Now()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(tm) {
+		t.Errorf("got %v, want %v", got, tm)
+	}
+}
+
+func TestHours(t *testing.T) {
+	for _, c := range []struct {
+		reg  byte
+		want byte
+	}{
+		{0x23, 23},
+		{hour12 | 0x12, 0},       // 12 AM
+		{hour12 | pm | 0x12, 12}, // 12 PM
+		{hour12 | pm | 0x11, 23},
+		{hour12 | 0x09, 9},
+	} {
+		h, err := hours(c.reg)
+		if err != nil || h != c.want {
+			t.Errorf("hours 0x%02X: got %d, %v, want %d", c.reg, h, err, c.want)
+		}
+	}
+	if _, err := hours(hour12 | 0x13); err == nil {
+		t.Error("hour 13 in 12 hour mode accepted")
+	}
+}
+
+func TestSetAlarm(t *testing.T) {
+	tm := time.Date(2024, time.March, 7, 6, 30, 15, 0, time.UTC) // a Thursday
+	for _, c := range []struct {
+		n    int
+		r    AlarmRate
+		reg  byte
+		want []byte
+	}{
+		{1, EverySecond, regAlarm1, []byte{0x95, 0xB0, 0x86, 0x87}},
+		{1, MatchMinutes, regAlarm1, []byte{0x15, 0x30, 0x86, 0x87}},
+		{1, MatchDate, regAlarm1, []byte{0x15, 0x30, 0x06, 0x07}},
+		{1, MatchWeekday, regAlarm1, []byte{0x15, 0x30, 0x06, dayOfWeek | 5}},
+		{2, EveryMinute, regAlarm2, []byte{0xB0, 0x86, 0x87}},
+		{2, MatchHours, regAlarm2, []byte{0x30, 0x06, 0x87}},
+	} {
+		v, f := i2ctest.New(t, 0x68, nil)
+		if err := New(v).SetAlarm(c.n, tm, c.r); err != nil {
+			t.Fatalf("alarm %d rate %d: %v", c.n, c.r, err)
+		}
+		if got := f.Regs(c.reg, len(c.want)); !bytes.Equal(got, c.want) {
+			t.Errorf("alarm %d rate %d: registers % X, want % X", c.n, c.r, got, c.want)
+		}
+	}
+	v, _ := i2ctest.New(t, 0x68, nil)
+	d := New(v)
+	if err := d.SetAlarm(2, tm, MatchSeconds); err == nil {
+		t.Error("alarm 2 accepted matching seconds")
+	}
+	if err := d.SetAlarm(1, tm, EveryMinute); err == nil {
+		t.Error("alarm 1 accepted firing every minute")
+	}
+}
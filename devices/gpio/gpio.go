@@ -0,0 +1,51 @@
+// Package gpio defines the pin interface implemented by the GPIO expander
+// drivers, so that application code can use expanded pins without caring
+// about the chip behind them.
+package gpio
+
+import "errors"
+
+// ErrUnsupported is returned when a pin does not support a setting, e.g.
+// a pull-down on a chip only providing pull-ups.
+var ErrUnsupported = errors.New("gpio: unsupported pin setting")
+
+// Level is the logic level of a pin.
+type Level bool
+
+// Levels.
+const (
+	Low  Level = false
+	High Level = true
+)
+
+func (l Level) String() string {
+	if l {
+		return "High"
+	}
+	return "Low"
+}
+
+// Pull is the bias of an input pin.
+type Pull uint8
+
+// Pulls.
+const (
+	Float Pull = iota
+	PullUp
+	PullDown
+)
+
+// Pin is a general purpose input/output pin. As the pins of an expander
+// are accessed through the bus, every operation may fail.
+type Pin interface {
+	// Name returns a name identifying the pin, e.g. "MCP23017_0x20_GPA3".
+	Name() string
+	// Number returns the index of the pin on its chip.
+	Number() int
+	// In configures the pin as an input with the given bias.
+	In(pull Pull) error
+	// Out configures the pin as an output driving l.
+	Out(l Level) error
+	// Read returns the level of the pin.
+	Read() (Level, error)
+}
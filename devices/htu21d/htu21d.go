@@ -0,0 +1,242 @@
+// Package htu21d drives the TE HTU21D, Sensirion SHT21 and Silicon Labs
+// Si7021 humidity and temperature sensors.
+//
+//	v, err := i2c.NewI2C(0x40, 1)
+//	...
+//	d := htu21d.New(v)
+//	r, err := d.Read()
+//	fmt.Printf("%.2f°C %.1f%%\n", r.Temperature, r.Humidity)
+//
+// The sensors take single byte commands and protect their results with a
+// CRC-8, checked by every measurement.
+package htu21d
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// Commands.
+const (
+	cmdTempHold     = 0xE3
+	cmdHumidityHold = 0xE5
+	cmdTemp         = 0xF3
+	cmdHumidity     = 0xF5
+	cmdWriteUser    = 0xE6
+	cmdReadUser     = 0xE7
+	cmdReset        = 0xFE
+)
+
+// User register bits.
+const (
+	userResolution = 0x81
+	userLowBattery = 1 << 6
+	userHeater     = 1 << 2
+)
+
+// ErrCRC is returned when a measurement does not match its checksum.
+var ErrCRC = errors.New("htu21d: crc mismatch")
+
+// Resolution is the measurement resolution of the humidity and the
+// temperature, in bits.
+type Resolution uint8
+
+// Resolutions, values of the user register bits.
+const (
+	RH12T14 Resolution = 0x00
+	RH8T12  Resolution = 0x01
+	RH10T13 Resolution = 0x80
+	RH11T11 Resolution = 0x81
+)
+
+// durations are the maximum measurement durations by resolution.
+var durations = map[Resolution]struct{ humidity, temp time.Duration }{
+	RH12T14: {16 * time.Millisecond, 50 * time.Millisecond},
+	RH8T12:  {3 * time.Millisecond, 13 * time.Millisecond},
+	RH10T13: {5 * time.Millisecond, 25 * time.Millisecond},
+	RH11T11: {8 * time.Millisecond, 7 * time.Millisecond},
+}
+
+// Reading is a measurement.
+type Reading struct {
+	Temperature float64 // °C
+	Humidity    float64 // %RH
+}
+
+// Device is an HTU21D, SHT21 or Si7021 sensor.
+type Device struct {
+	v    *i2c.I2C
+	res  Resolution
+	hold bool
+}
+
+// New returns the sensor talking through v, measuring in no hold mode at
+// the power on resolution, RH12T14.
+func New(v *i2c.I2C) *Device {
+	return &Device{v: v, res: RH12T14}
+}
+
+// SetHold selects how measurements wait for the result. In hold mode the
+// sensor holds the clock low until the result is ready, which the adapter
+// must tolerate; in no hold mode the sensor does not acknowledge its
+// address until then and the measurement polls it.
+func (d *Device) SetHold(on bool) {
+	d.hold = on
+}
+
+// user reads the user register.
+func (d *Device) user() (byte, error) {
+	return d.v.ReadRegU8(cmdReadUser)
+}
+
+// updateUser sets the bits of mask of the user register to value,
+// keeping the reserved bits.
+func (d *Device) updateUser(mask, value byte) error {
+	u, err := d.user()
+	if err != nil {
+		return err
+	}
+	return d.v.WriteRegU8(cmdWriteUser, u&^mask|value&mask)
+}
+
+// SetResolution sets the measurement resolution.
+func (d *Device) SetResolution(r Resolution) error {
+	if _, ok := durations[r]; !ok {
+		return errors.New("htu21d: invalid resolution")
+	}
+	if err := d.updateUser(userResolution, byte(r)); err != nil {
+		return err
+	}
+	d.res = r
+	return nil
+}
+
+// Resolution reads the measurement resolution.
+func (d *Device) Resolution() (Resolution, error) {
+	u, err := d.user()
+	if err != nil {
+		return 0, err
+	}
+	d.res = Resolution(u & userResolution)
+	return d.res, nil
+}
+
+// SetHeater switches the internal heater, used to check the sensor or to
+// evaporate condensation.
+func (d *Device) SetHeater(on bool) error {
+	var h byte
+	if on {
+		h = userHeater
+	}
+	return d.updateUser(userHeater, h)
+}
+
+// LowBattery reports whether the supply voltage fell below 2.25V, after
+// which the measurements are not accurate.
+func (d *Device) LowBattery() (bool, error) {
+	u, err := d.user()
+	return u&userLowBattery != 0, err
+}
+
+// Reset performs a soft reset, restoring the default user register but
+// the heater bit.
+func (d *Device) Reset() error {
+	if _, err := d.v.WriteBytes([]byte{cmdReset}); err != nil {
+		return err
+	}
+	time.Sleep(15 * time.Millisecond)
+	d.res = RH12T14
+	return nil
+}
+
+// Temperature measures the temperature, in °C.
+func (d *Device) Temperature() (float64, error) {
+	cmd := byte(cmdTemp)
+	if d.hold {
+		cmd = cmdTempHold
+	}
+	s, err := d.measure(cmd, durations[d.res].temp)
+	if err != nil {
+		return 0, err
+	}
+	return -46.85 + 175.72*float64(s)/65536, nil
+}
+
+// Humidity measures the relative humidity, in %RH. It is not compensated
+// for the temperature, which TE and Sensirion give as -0.15%RH/°C from
+// 25°C.
+func (d *Device) Humidity() (float64, error) {
+	cmd := byte(cmdHumidity)
+	if d.hold {
+		cmd = cmdHumidityHold
+	}
+	s, err := d.measure(cmd, durations[d.res].humidity)
+	if err != nil {
+		return 0, err
+	}
+	// the formula overshoots near saturation and in very dry air
+	return min(max(-6+125*float64(s)/65536, 0), 100), nil
+}
+
+// Read measures the temperature, then the humidity.
+func (d *Device) Read() (Reading, error) {
+	t, err := d.Temperature()
+	if err != nil {
+		return Reading{}, err
+	}
+	h, err := d.Humidity()
+	if err != nil {
+		return Reading{}, err
+	}
+	return Reading{Temperature: t, Humidity: h}, nil
+}
+
+// measure runs the measurement command cmd lasting at most dur and returns
+// the raw result, without its status bits.
+func (d *Device) measure(cmd byte, dur time.Duration) (uint16, error) {
+	var b [3]byte
+	if d.hold {
+		if err := d.v.Tx([]byte{cmd}, b[:]); err != nil {
+			return 0, err
+		}
+	} else {
+		if _, err := d.v.WriteBytes([]byte{cmd}); err != nil {
+			return 0, err
+		}
+		deadline := time.Now().Add(dur)
+		for {
+			time.Sleep(time.Millisecond)
+			_, err := d.v.ReadBytes(b[:])
+			if err == nil {
+				break
+			}
+			if !i2c.IsNack(err) || time.Now().After(deadline) {
+				return 0, err
+			}
+		}
+	}
+	if crc8(b[:2]) != b[2] {
+		return 0, fmt.Errorf("%w: 0x%02X%02X sum 0x%02X", ErrCRC, b[0], b[1], b[2])
+	}
+	return uint16(b[0])<<8 | uint16(b[1])&^0x03, nil
+}
+
+// crc8 computes the checksum of data with polynomial 0x31 and
+// initialization 0x00.
+func crc8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x31
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
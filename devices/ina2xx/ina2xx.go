@@ -0,0 +1,304 @@
+// Package ina2xx drives the Texas Instruments INA219 and INA226 current,
+// voltage and power monitors.
+//
+//	v, err := i2c.NewI2C(0x40, 1)
+//	...
+//	// 0.1Ω shunt, currents up to 3.2A
+//	d, err := ina2xx.New(v, ina2xx.INA219, 0.1, 3.2)
+//	...
+//	r, err := d.Read()
+//	fmt.Printf("%.3fV %.3fA %.3fW\n", r.Bus, r.Current, r.Power)
+//
+// The chips measure the voltage across a shunt resistor and the bus
+// voltage; the current and power are computed by the chip from the
+// calibration register, set by New from the shunt resistance and the
+// maximum expected current.
+package ina2xx
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// Registers.
+const (
+	regConfig      = 0x00
+	regShunt       = 0x01
+	regBus         = 0x02
+	regPower       = 0x03
+	regCurrent     = 0x04
+	regCalibration = 0x05
+	regMaskEnable  = 0x06 // INA226
+	regAlertLimit  = 0x07 // INA226
+)
+
+const (
+	configReset = 1 << 15
+	// ina219Overflow is the math overflow bit of the INA219 bus register.
+	ina219Overflow = 1 << 0
+)
+
+// ErrOverflow is returned when the current or power computation of the
+// INA219 overflowed, e.g. because the current exceeds the maximum given to
+// New.
+var ErrOverflow = errors.New("ina2xx: math overflow")
+
+// Model is the monitor model.
+type Model uint8
+
+// Models.
+const (
+	INA219 Model = iota
+	INA226
+)
+
+// Reading is a measurement in SI units.
+type Reading struct {
+	Shunt   float64 // V
+	Bus     float64 // V
+	Current float64 // A
+	Power   float64 // W
+}
+
+// Device is an INA219 or INA226.
+type Device struct {
+	v          *i2c.I2C
+	model      Model
+	currentLSB float64 // A
+	powerLSB   float64 // W
+}
+
+// New returns the monitor of the given model talking through v, reset to
+// continuous conversions of the shunt and bus voltages, and calibrated for
+// a shunt of the given resistance in ohms and currents up to maxCurrent
+// amperes. The current resolution is maxCurrent/32768.
+func New(v *i2c.I2C, m Model, shunt, maxCurrent float64) (*Device, error) {
+	if m > INA226 {
+		return nil, errors.New("ina2xx: invalid model")
+	}
+	if shunt <= 0 || maxCurrent <= 0 {
+		return nil, errors.New("ina2xx: shunt and maximum current must be positive")
+	}
+	d := &Device{v: v, model: m, currentLSB: maxCurrent / 32768}
+	if err := d.v.WriteRegU16BE(regConfig, configReset); err != nil {
+		return nil, err
+	}
+	time.Sleep(time.Millisecond)
+	k, pw := 0.04096, 20.0
+	if m == INA226 {
+		k, pw = 0.00512, 25.0
+	}
+	cal := math.Trunc(k / (d.currentLSB * shunt))
+	if cal < 1 || cal > 0x7FFF {
+		return nil, fmt.Errorf("ina2xx: calibration %g out of range, change the maximum current", cal)
+	}
+	d.powerLSB = pw * d.currentLSB
+	if err := d.v.WriteRegU16BE(regCalibration, uint16(cal)); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ShuntVoltage returns the voltage across the shunt.
+func (d *Device) ShuntVoltage() (float64, error) {
+	raw, err := d.v.ReadRegS16BE(regShunt)
+	if err != nil {
+		return 0, err
+	}
+	if d.model == INA226 {
+		return float64(raw) * 2.5e-6, nil
+	}
+	return float64(raw) * 10e-6, nil
+}
+
+// BusVoltage returns the voltage of the bus, between IN- or VBUS and
+// ground.
+func (d *Device) BusVoltage() (float64, error) {
+	raw, err := d.v.ReadRegU16BE(regBus)
+	if err != nil {
+		return 0, err
+	}
+	v, _ := d.bus(raw)
+	return v, nil
+}
+
+// bus decodes a bus register, reporting the overflow flag of the INA219.
+func (d *Device) bus(raw uint16) (float64, bool) {
+	if d.model == INA226 {
+		return float64(raw) * 1.25e-3, false
+	}
+	return float64(raw>>3) * 4e-3, raw&ina219Overflow != 0
+}
+
+// Current returns the current through the shunt.
+func (d *Device) Current() (float64, error) {
+	raw, err := d.v.ReadRegS16BE(regCurrent)
+	if err != nil {
+		return 0, err
+	}
+	return float64(raw) * d.currentLSB, nil
+}
+
+// Power returns the power delivered to the load.
+func (d *Device) Power() (float64, error) {
+	raw, err := d.v.ReadRegU16BE(regPower)
+	if err != nil {
+		return 0, err
+	}
+	return float64(raw) * d.powerLSB, nil
+}
+
+// Read returns all the measurements, read at once. On the INA219 it
+// returns ErrOverflow along with the voltages when the current and power
+// are not valid.
+func (d *Device) Read() (Reading, error) {
+	var b [8]byte
+	regs := []i2c.RegRead{
+		{Reg: regShunt, Buf: b[0:2]},
+		{Reg: regBus, Buf: b[2:4]},
+		{Reg: regPower, Buf: b[4:6]},
+		{Reg: regCurrent, Buf: b[6:8]},
+	}
+	if err := d.v.BatchRead(regs); err != nil {
+		return Reading{}, err
+	}
+	word := func(i int) uint16 { return uint16(b[i])<<8 | uint16(b[i+1]) }
+	var r Reading
+	r.Shunt = float64(int16(word(0))) * 10e-6
+	if d.model == INA226 {
+		r.Shunt = float64(int16(word(0))) * 2.5e-6
+	}
+	bus, ovf := d.bus(word(2))
+	r.Bus = bus
+	if ovf {
+		return r, ErrOverflow
+	}
+	r.Power = float64(word(4)) * d.powerLSB
+	r.Current = float64(int16(word(6))) * d.currentLSB
+	return r, nil
+}
+
+// Gain is the shunt voltage range of the INA219.
+type Gain uint8
+
+// INA219 shunt voltage ranges.
+const (
+	Gain40mV Gain = iota
+	Gain80mV
+	Gain160mV
+	Gain320mV
+)
+
+// SetRange sets the INA219 shunt voltage range, and the bus voltage range,
+// 32V or 16V. Smaller ranges improve the resolution.
+func (d *Device) SetRange(g Gain, bus32V bool) error {
+	if d.model != INA219 {
+		return errors.New("ina2xx: ranges are INA219 only")
+	}
+	if g > Gain320mV {
+		return errors.New("ina2xx: invalid gain")
+	}
+	c := uint16(g) << 11
+	if bus32V {
+		c |= 1 << 13
+	}
+	return d.v.UpdateRegU16BE(regConfig, 0x3800, c)
+}
+
+// averages are the INA226 averaging counts, by AVG field value.
+var averages = []int{1, 4, 16, 64, 128, 256, 512, 1024}
+
+// SetAveraging sets the count of conversions averaged by the INA226 for
+// each result: 1, 4, 16, 64, 128, 256, 512 or 1024.
+func (d *Device) SetAveraging(n int) error {
+	if d.model != INA226 {
+		return errors.New("ina2xx: averaging is INA226 only")
+	}
+	for i, a := range averages {
+		if a == n {
+			return d.v.UpdateRegU16BE(regConfig, 0x0E00, uint16(i)<<9)
+		}
+	}
+	return fmt.Errorf("ina2xx: unsupported averaging count %d", n)
+}
+
+// Alert is the function of the INA226 ALERT pin.
+type Alert uint16
+
+// Alert functions, bits of the mask/enable register. The limit of the
+// over and under functions is set with SetAlert.
+const (
+	AlertOff        Alert = 0
+	ShuntOver       Alert = 1 << 15
+	ShuntUnder      Alert = 1 << 14
+	BusOver         Alert = 1 << 13
+	BusUnder        Alert = 1 << 12
+	PowerOver       Alert = 1 << 11
+	ConversionReady Alert = 1 << 10
+)
+
+// Mask/enable flags.
+const (
+	alertFlag       = 1 << 4
+	alertActiveHigh = 1 << 1
+	alertLatch      = 1 << 0
+)
+
+// SetAlert sets the function of the INA226 ALERT pin, open drain and
+// active low unless activeHigh is set. limit is the threshold of the over
+// and under functions, in volts or watts. With latch the pin and the flag
+// stay asserted until read by AlertFired, otherwise they clear as soon as
+// the condition does.
+func (d *Device) SetAlert(a Alert, limit float64, activeHigh, latch bool) error {
+	if d.model != INA226 {
+		return errors.New("ina2xx: alerts are INA226 only")
+	}
+	var lsb float64
+	switch a {
+	case AlertOff, ConversionReady:
+	case ShuntOver, ShuntUnder:
+		lsb = 2.5e-6
+	case BusOver, BusUnder:
+		lsb = 1.25e-3
+	case PowerOver:
+		lsb = d.powerLSB
+	default:
+		return errors.New("ina2xx: set a single alert function")
+	}
+	if lsb != 0 {
+		raw := math.Round(limit / lsb)
+		if a == ShuntOver || a == ShuntUnder {
+			if raw < math.MinInt16 || raw > math.MaxInt16 {
+				return fmt.Errorf("ina2xx: alert limit %g out of range", limit)
+			}
+			raw = float64(uint16(int16(raw)))
+		} else if raw < 0 || raw > math.MaxUint16 {
+			return fmt.Errorf("ina2xx: alert limit %g out of range", limit)
+		}
+		if err := d.v.WriteRegU16BE(regAlertLimit, uint16(raw)); err != nil {
+			return err
+		}
+	}
+	me := uint16(a)
+	if activeHigh {
+		me |= alertActiveHigh
+	}
+	if latch {
+		me |= alertLatch
+	}
+	return d.v.WriteRegU16BE(regMaskEnable, me)
+}
+
+// AlertFired reports whether the INA226 alert function fired, clearing
+// a latched alert.
+func (d *Device) AlertFired() (bool, error) {
+	if d.model != INA226 {
+		return false, errors.New("ina2xx: alerts are INA226 only")
+	}
+	me, err := d.v.ReadRegU16BE(regMaskEnable)
+	return me&alertFlag != 0, err
+}
@@ -0,0 +1,243 @@
+// Package lm75 drives the LM75 family of temperature sensors and
+// thermostats, and the Texas Instruments TMP102.
+//
+//	v, err := i2c.NewI2C(0x48, 1)
+//	...
+//	d, err := lm75.New(v, lm75.TMP102)
+//	...
+//	t, err := d.Temperature()
+//
+// Temperatures are two's complement words with unused low bits: 9 bits
+// with 0.5°C resolution on the LM75, 12 bits with 0.0625°C resolution on
+// the TMP102, or 13 bits in its extended mode.
+package lm75
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// Registers.
+const (
+	regTemp   = 0x00
+	regConfig = 0x01
+	regLow    = 0x02 // THYST on the LM75
+	regHigh   = 0x03 // TOS on the LM75
+)
+
+// Configuration bits, of the first byte on the TMP102.
+const (
+	cfgShutdown   = 1 << 0
+	cfgInterrupt  = 1 << 1
+	cfgActiveHigh = 1 << 2
+	cfgFaults     = 0x18
+	cfgOneShot    = 1 << 7 // TMP102
+	cfgExtended   = 1 << 4 // TMP102, second byte
+)
+
+// ErrTimeout is returned by OneShot when the conversion does not complete
+// in time.
+var ErrTimeout = errors.New("lm75: conversion timeout")
+
+// Model is the sensor model.
+type Model uint8
+
+// Models.
+const (
+	LM75 Model = iota
+	TMP102
+)
+
+// Device is an LM75 or TMP102.
+type Device struct {
+	v        *i2c.I2C
+	model    Model
+	extended bool
+}
+
+// New returns the sensor of the given model talking through v.
+func New(v *i2c.I2C, m Model) (*Device, error) {
+	if m > TMP102 {
+		return nil, errors.New("lm75: invalid model")
+	}
+	d := &Device{v: v, model: m}
+	if m == TMP102 {
+		c, err := v.ReadRegU16BE(regConfig)
+		if err != nil {
+			return nil, err
+		}
+		d.extended = c&cfgExtended != 0
+	}
+	return d, nil
+}
+
+// format returns the count of unused low bits of the temperature words,
+// and the resolution in °C.
+func (d *Device) format() (uint, float64) {
+	switch {
+	case d.model == LM75:
+		return 7, 0.5
+	case d.extended:
+		return 3, 0.0625
+	}
+	return 4, 0.0625
+}
+
+// decode converts a temperature word to °C.
+func (d *Device) decode(raw uint16) float64 {
+	s, res := d.format()
+	return float64(int16(raw)>>s) * res
+}
+
+// encode converts t to a temperature word, clamped to the range of the
+// sensor.
+func (d *Device) encode(t float64) uint16 {
+	s, res := d.format()
+	n := math.Round(t / res)
+	lim := float64(int(1) << (15 - s))
+	n = min(max(n, -lim), lim-1)
+	return uint16(int16(n) << s)
+}
+
+// Temperature reads the last converted temperature, in °C.
+func (d *Device) Temperature() (float64, error) {
+	raw, err := d.v.ReadRegU16BE(regTemp)
+	if err != nil {
+		return 0, err
+	}
+	return d.decode(raw), nil
+}
+
+// updateConfig sets the bits of mask of the first configuration byte to
+// value.
+func (d *Device) updateConfig(mask, value byte) error {
+	if d.model == TMP102 {
+		return d.v.UpdateRegU16BE(regConfig, uint16(mask)<<8, uint16(value)<<8)
+	}
+	return d.v.UpdateRegU8(regConfig, mask, value)
+}
+
+// SetShutdown shuts the sensor down, stopping conversions until woken up,
+// or wakes it up.
+func (d *Device) SetShutdown(on bool) error {
+	var v byte
+	if on {
+		v = cfgShutdown
+	}
+	return d.updateConfig(cfgShutdown, v)
+}
+
+// OneShot runs a single conversion while the TMP102 is shut down, and
+// returns the temperature.
+func (d *Device) OneShot() (float64, error) {
+	if d.model != TMP102 {
+		return 0, errors.New("lm75: one shot conversions are TMP102 only")
+	}
+	if err := d.updateConfig(cfgOneShot|cfgShutdown, cfgOneShot|cfgShutdown); err != nil {
+		return 0, err
+	}
+	// the bit reads 0 during the 26ms conversion
+	err := d.v.WaitForRegBit(context.Background(), regConfig, cfgOneShot, true, 5*time.Millisecond, 100*time.Millisecond)
+	if err == i2c.ErrPollTimeout {
+		return 0, ErrTimeout
+	}
+	if err != nil {
+		return 0, err
+	}
+	return d.Temperature()
+}
+
+// SetExtended switches the TMP102 extended mode, extending the range from
+// 128°C to 150°C with 13 bit words. The thresholds are kept.
+func (d *Device) SetExtended(on bool) error {
+	if d.model != TMP102 {
+		return errors.New("lm75: extended mode is TMP102 only")
+	}
+	if on == d.extended {
+		return nil
+	}
+	low, high, err := d.Thresholds()
+	if err != nil {
+		return err
+	}
+	var v uint16
+	if on {
+		v = cfgExtended
+	}
+	if err := d.v.UpdateRegU16BE(regConfig, cfgExtended, v); err != nil {
+		return err
+	}
+	d.extended = on
+	return d.SetThresholds(low, high)
+}
+
+// Thermostat is the function of the OS or ALERT pin.
+type Thermostat uint8
+
+// Thermostat modes.
+const (
+	// Comparator asserts the pin while the temperature is above the high
+	// threshold, and until it falls below the low one.
+	Comparator Thermostat = iota
+	// Interrupt asserts the pin when the temperature rises above the high
+	// threshold or falls below the low one, until a register is read.
+	Interrupt
+)
+
+// faultQueues are the fault counts by configuration field value.
+var faultQueues = []int{1, 2, 4, 6}
+
+// SetThermostat sets the thermostat mode, the polarity of its pin,
+// active low by default, and the count of consecutive faults, 1, 2, 4 or
+// 6, triggering it.
+func (d *Device) SetThermostat(t Thermostat, activeHigh bool, faults int) error {
+	if t > Interrupt {
+		return errors.New("lm75: invalid thermostat mode")
+	}
+	for i, n := range faultQueues {
+		if n != faults {
+			continue
+		}
+		v := byte(i) << 3
+		if t == Interrupt {
+			v |= cfgInterrupt
+		}
+		if activeHigh {
+			v |= cfgActiveHigh
+		}
+		return d.updateConfig(cfgInterrupt|cfgActiveHigh|cfgFaults, v)
+	}
+	return fmt.Errorf("lm75: unsupported fault count %d", faults)
+}
+
+// SetThresholds sets the low and high thresholds of the thermostat, in
+// °C, THYST and TOS on the LM75.
+func (d *Device) SetThresholds(low, high float64) error {
+	if low > high {
+		return errors.New("lm75: low threshold above the high one")
+	}
+	if err := d.v.WriteRegU16BE(regLow, d.encode(low)); err != nil {
+		return err
+	}
+	return d.v.WriteRegU16BE(regHigh, d.encode(high))
+}
+
+// Thresholds reads the low and high thresholds of the thermostat, in °C.
+func (d *Device) Thresholds() (low, high float64, err error) {
+	var b [4]byte
+	regs := []i2c.RegRead{
+		{Reg: regLow, Buf: b[0:2]},
+		{Reg: regHigh, Buf: b[2:4]},
+	}
+	if err := d.v.BatchRead(regs); err != nil {
+		return 0, 0, err
+	}
+	low = d.decode(uint16(b[0])<<8 | uint16(b[1]))
+	high = d.decode(uint16(b[2])<<8 | uint16(b[3]))
+	return low, high, nil
+}
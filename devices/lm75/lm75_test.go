@@ -0,0 +1,49 @@
+package lm75
+
+import (
+	"testing"
+
+	"github.com/fedeonline/i2c-go/i2ctest"
+)
+
+func TestTemperature(t *testing.T) {
+	for _, c := range []struct {
+		model    Model
+		extended bool
+		raw      uint16
+		want     float64
+	}{
+		{LM75, false, 0x1900, 25},
+		{LM75, false, 0x0080, 0.5},
+		{LM75, false, 0xFF80, -0.5},
+		{LM75, false, 0xE700, -25},
+		{LM75, false, 0xC900, -55},
+		{TMP102, false, 0x7FF0, 127.9375},
+		{TMP102, false, 0xFFF0, -0.0625},
+		{TMP102, false, 0xE700, -25},
+		{TMP102, true, 0x4B00, 150},
+		{TMP102, true, 0xFFF8, -0.0625},
+		{TMP102, true, 0xE480, -55},
+	} {
+		v, _ := i2ctest.New(t, 0x48, map[byte]byte{
+			regTemp: byte(c.raw >> 8), regTemp + 1: byte(c.raw),
+		})
+		d, err := New(v, c.model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the fake registers are bytes, the configuration word overlaps
+		// the temperature one
+		d.extended = c.extended
+		got, err := d.Temperature()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Errorf("model %d extended %v, 0x%04X: got %v°C, want %v°C", c.model, c.extended, c.raw, got, c.want)
+		}
+		if w := d.encode(c.want); w != c.raw {
+			t.Errorf("model %d extended %v, %v°C: encoded 0x%04X, want 0x%04X", c.model, c.extended, c.want, w, c.raw)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+// Package mcp230xx drives the Microchip MCP23008 and MCP23017 GPIO
+// expanders, with 8 and 16 pins respectively.
+//
+//	v, err := i2c.NewI2C(0x20, 1)
+//	...
+//	d, err := mcp230xx.New(v, mcp230xx.MCP23017, nil)
+//	...
+//	led, button := d.Pin(0), d.Pin(8)
+//	led.Out(gpio.High)
+//	button.In(gpio.PullUp)
+//	l, err := button.Read()
+//
+// Pins are numbered from 0, GPA0, to 15, GPB7. Port wide operations take
+// and return masks with bit n for pin n.
+package mcp230xx
+
+import (
+	"errors"
+	"fmt"
+
+	i2c "github.com/fedeonline/i2c-go"
+	"github.com/fedeonline/i2c-go/devices/gpio"
+)
+
+// Model is the expander model.
+type Model uint8
+
+// Models.
+const (
+	MCP23017 Model = iota
+	MCP23008
+)
+
+// Registers, as indexes in the register block of a port.
+const (
+	regIODIR = iota
+	regIPOL
+	regGPINTEN
+	regDEFVAL
+	regINTCON
+	regIOCON
+	regGPPU
+	regINTF
+	regINTCAP
+	regGPIO
+	regOLAT
+)
+
+// IOCON bits.
+const (
+	ioconBank   = 1 << 7
+	ioconMirror = 1 << 6
+	ioconODR    = 1 << 2
+	ioconINTPOL = 1 << 1
+)
+
+// Config is the configuration of the chip.
+type Config struct {
+	// Bank1 selects the register layout with one block per port
+	// (IOCON.BANK), the default interleaving the registers of the two
+	// ports. The driver handles both; it matters to other software
+	// sharing the chip. MCP23017 only.
+	Bank1 bool
+	// Mirror ORs the interrupts of both ports on both INTA and INTB.
+	// MCP23017 only.
+	Mirror bool
+	// OpenDrain makes the interrupt outputs open drain, overriding
+	// IntActiveHigh.
+	OpenDrain bool
+	// IntActiveHigh makes the interrupt outputs active high.
+	IntActiveHigh bool
+}
+
+// Trigger selects when a pin raises an interrupt.
+type Trigger uint8
+
+// Triggers. IntHigh and IntLow compare the pin against a level and keep
+// raising the interrupt while it holds.
+const (
+	IntOff    Trigger = iota
+	IntChange         // on any change of the pin
+	IntHigh           // while the pin is high
+	IntLow            // while the pin is low
+)
+
+// Device is an MCP23008 or MCP23017.
+type Device struct {
+	v     *i2c.I2C
+	model Model
+	bank1 bool
+}
+
+// New returns the expander of the given model talking through v,
+// configured with cfg, the power on configuration when nil. The chip may
+// be in either register layout, e.g. left in the other by a previous
+// program: New brings it into the configured one. The pins are left as
+// they are.
+func New(v *i2c.I2C, m Model, cfg *Config) (*Device, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if m == MCP23008 && (cfg.Bank1 || cfg.Mirror) {
+		return nil, errors.New("mcp230xx: Bank1 and Mirror need an MCP23017")
+	}
+	var iocon byte
+	if cfg.Bank1 {
+		iocon |= ioconBank
+	}
+	if cfg.Mirror {
+		iocon |= ioconMirror
+	}
+	if cfg.OpenDrain {
+		iocon |= ioconODR
+	}
+	if cfg.IntActiveHigh {
+		iocon |= ioconINTPOL
+	}
+	d := &Device{v: v, model: m}
+	if m == MCP23017 {
+		// IOCON is at 0x05 in bank 1 and at 0x0A in bank 0, where 0x05 is
+		// GPINTENB. Clearing 0x05 switches to bank 0 from either layout,
+		// at worst disabling the interrupts of port B.
+		if err := v.WriteRegU8(0x05, 0); err != nil {
+			return nil, err
+		}
+	}
+	if err := v.WriteRegU8(d.reg(regIOCON, 0), iocon); err != nil {
+		return nil, err
+	}
+	d.bank1 = cfg.Bank1
+	return d, nil
+}
+
+// Pins returns the number of pins of the chip.
+func (d *Device) Pins() int {
+	if d.model == MCP23008 {
+		return 8
+	}
+	return 16
+}
+
+// reg returns the address of register r of port p, 0 for A and 1 for B.
+func (d *Device) reg(r, p int) byte {
+	if d.model == MCP23008 || d.bank1 {
+		return byte(r + 0x10*p)
+	}
+	return byte(2*r + p)
+}
+
+// read reads register r of all the ports.
+func (d *Device) read(r int) (uint16, error) {
+	if d.model == MCP23008 {
+		b, err := d.v.ReadRegU8(d.reg(r, 0))
+		return uint16(b), err
+	}
+	if !d.bank1 {
+		return d.v.ReadRegU16LE(d.reg(r, 0))
+	}
+	a, err := d.v.ReadRegU8(d.reg(r, 0))
+	if err != nil {
+		return 0, err
+	}
+	b, err := d.v.ReadRegU8(d.reg(r, 1))
+	return uint16(b)<<8 | uint16(a), err
+}
+
+// write writes register r of all the ports.
+func (d *Device) write(r int, value uint16) error {
+	if d.model == MCP23008 {
+		return d.v.WriteRegU8(d.reg(r, 0), byte(value))
+	}
+	if !d.bank1 {
+		return d.v.WriteRegU16LE(d.reg(r, 0), value)
+	}
+	if err := d.v.WriteRegU8(d.reg(r, 0), byte(value)); err != nil {
+		return err
+	}
+	return d.v.WriteRegU8(d.reg(r, 1), byte(value>>8))
+}
+
+// update sets the bits of register r selected by mask to value, port by
+// port, each port atomically.
+func (d *Device) update(r int, mask, value uint16) error {
+	for p := 0; p < d.Pins()/8; p++ {
+		m := byte(mask >> (8 * p))
+		if m == 0 {
+			continue
+		}
+		if err := d.v.UpdateRegU8(d.reg(r, p), m, byte(value>>(8*p))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Device) checkMask(mask uint16) error {
+	if d.model == MCP23008 && mask>>8 != 0 {
+		return fmt.Errorf("mcp230xx: pins %#x out of range", mask)
+	}
+	return nil
+}
+
+// SetDirection makes the pins of mask inputs where the corresponding bit
+// of in is set, outputs otherwise.
+func (d *Device) SetDirection(mask, in uint16) error {
+	if err := d.checkMask(mask); err != nil {
+		return err
+	}
+	return d.update(regIODIR, mask, in)
+}
+
+// SetPullUp enables the pull-ups of the pins of mask where the
+// corresponding bit of on is set, disables them otherwise.
+func (d *Device) SetPullUp(mask, on uint16) error {
+	if err := d.checkMask(mask); err != nil {
+		return err
+	}
+	return d.update(regGPPU, mask, on)
+}
+
+// SetInverted inverts the levels read from the pins of mask where the
+// corresponding bit of inv is set.
+func (d *Device) SetInverted(mask, inv uint16) error {
+	if err := d.checkMask(mask); err != nil {
+		return err
+	}
+	return d.update(regIPOL, mask, inv)
+}
+
+// ReadAll returns the levels of all the pins.
+func (d *Device) ReadAll() (uint16, error) {
+	return d.read(regGPIO)
+}
+
+// WriteAll sets the levels of all the output pins.
+func (d *Device) WriteAll(value uint16) error {
+	return d.write(regOLAT, value)
+}
+
+// Write sets the levels of the output pins of mask.
+func (d *Device) Write(mask, value uint16) error {
+	if err := d.checkMask(mask); err != nil {
+		return err
+	}
+	// read-modify-write of OLAT, not GPIO, so that pins pulled by their
+	// load are not latched at the wrong level
+	return d.update(regOLAT, mask, value)
+}
+
+// SetInterrupt sets the trigger of the interrupt of pin n.
+func (d *Device) SetInterrupt(n int, t Trigger) error {
+	if err := d.checkPin(n); err != nil {
+		return err
+	}
+	bit := uint16(1) << n
+	if t == IntOff {
+		return d.update(regGPINTEN, bit, 0)
+	}
+	var intcon, defval uint16
+	switch t {
+	case IntChange:
+	case IntHigh:
+		intcon = bit // compare against DEFVAL low
+	case IntLow:
+		intcon, defval = bit, bit
+	default:
+		return errors.New("mcp230xx: invalid trigger")
+	}
+	if err := d.update(regDEFVAL, bit, defval); err != nil {
+		return err
+	}
+	if err := d.update(regINTCON, bit, intcon); err != nil {
+		return err
+	}
+	return d.update(regGPINTEN, bit, bit)
+}
+
+// Interrupts returns the pins which raised an interrupt and the levels of
+// all the pins captured when it was raised. Reading the capture clears
+// the interrupt.
+func (d *Device) Interrupts() (flags, captured uint16, err error) {
+	if flags, err = d.read(regINTF); err != nil {
+		return 0, 0, err
+	}
+	captured, err = d.read(regINTCAP)
+	return flags, captured, err
+}
+
+func (d *Device) checkPin(n int) error {
+	if n < 0 || n >= d.Pins() {
+		return fmt.Errorf("mcp230xx: no pin %d", n)
+	}
+	return nil
+}
+
+// Pin returns pin n, 0 to 7 for GPA0 to GPA7 and 8 to 15 for GPB0 to
+// GPB7. It panics if the chip has no such pin.
+func (d *Device) Pin(n int) gpio.Pin {
+	if err := d.checkPin(n); err != nil {
+		panic(err)
+	}
+	return &pin{d: d, n: n}
+}
+
+// pin is a pin of a Device.
+type pin struct {
+	d *Device
+	n int
+}
+
+func (p *pin) Name() string {
+	name := "MCP23017"
+	if p.d.model == MCP23008 {
+		name = "MCP23008"
+	}
+	return fmt.Sprintf("%s_0x%02x_GP%c%d", name, p.d.v.Addr(), 'A'+p.n/8, p.n%8)
+}
+
+func (p *pin) Number() int {
+	return p.n
+}
+
+func (p *pin) String() string {
+	return p.Name()
+}
+
+func (p *pin) In(pull gpio.Pull) error {
+	bit := uint16(1) << p.n
+	switch pull {
+	case gpio.Float:
+		if err := p.d.SetPullUp(bit, 0); err != nil {
+			return err
+		}
+	case gpio.PullUp:
+		if err := p.d.SetPullUp(bit, bit); err != nil {
+			return err
+		}
+	default:
+		return gpio.ErrUnsupported
+	}
+	return p.d.SetDirection(bit, bit)
+}
+
+func (p *pin) Out(l gpio.Level) error {
+	bit := uint16(1) << p.n
+	var value uint16
+	if l {
+		value = bit
+	}
+	// set the latch first, not to glitch the output
+	if err := p.d.Write(bit, value); err != nil {
+		return err
+	}
+	return p.d.SetDirection(bit, 0)
+}
+
+func (p *pin) Read() (gpio.Level, error) {
+	b, err := p.d.v.ReadRegU8(p.d.reg(regGPIO, p.n/8))
+	if err != nil {
+		return gpio.Low, err
+	}
+	return gpio.Level(b&(1<<(p.n%8)) != 0), nil
+}
@@ -0,0 +1,156 @@
+// Package mcp4725 drives the Microchip MCP4725 12 bit DAC with EEPROM.
+//
+//	v, err := i2c.NewI2C(0x60, 1)
+//	...
+//	d, err := mcp4725.New(v)
+//	...
+//	err = d.Set(mcp4725.Max / 2) // VDD/2
+//
+// The output is VDD*value/4096. The EEPROM holds the value and power down
+// mode loaded at power on, written along with the DAC by Store.
+package mcp4725
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// cmdWriteDACEEPROM is the command writing the DAC and the EEPROM, in the
+// first byte of a write.
+const cmdWriteDACEEPROM = 0x60
+
+// Status bits, of the first byte read.
+const (
+	statusReady = 1 << 7
+	statusPOR   = 1 << 6
+)
+
+// Max is the highest DAC value, for a VDD*4095/4096 output.
+const Max = 0x0FFF
+
+// eepromTimeout is the maximum duration of an EEPROM write, 25ms typical.
+const eepromTimeout = 50 * time.Millisecond
+
+// PowerDown is the state of the output.
+type PowerDown uint8
+
+// Output states. In power down the output is pulled to ground by a
+// resistor and the DAC keeps its value.
+const (
+	Normal PowerDown = iota
+	PowerDown1k
+	PowerDown100k
+	PowerDown500k
+)
+
+// State is the content of the DAC and EEPROM registers.
+type State struct {
+	Value           uint16
+	PowerDown       PowerDown
+	EEPROMValue     uint16
+	EEPROMPowerDown PowerDown
+	// Busy is set while an EEPROM write is in progress.
+	Busy bool
+	// PowerOnReset is set while the supply is above the power on reset
+	// threshold.
+	PowerOnReset bool
+}
+
+// Device is an MCP4725.
+type Device struct {
+	v     *i2c.I2C
+	value uint16
+	pd    PowerDown
+}
+
+// New returns the DAC talking through v, in its current state.
+func New(v *i2c.I2C) (*Device, error) {
+	d := &Device{v: v}
+	s, err := d.State()
+	if err != nil {
+		return nil, err
+	}
+	d.value, d.pd = s.Value, s.PowerDown
+	return d, nil
+}
+
+// check returns an error for invalid value and pd.
+func check(value uint16, pd PowerDown) error {
+	if value > Max {
+		return fmt.Errorf("mcp4725: value %d above %d", value, Max)
+	}
+	if pd > PowerDown500k {
+		return errors.New("mcp4725: invalid power down mode")
+	}
+	return nil
+}
+
+// fastWrite writes value and pd to the DAC with a two byte fast write.
+func (d *Device) fastWrite(value uint16, pd PowerDown) error {
+	if err := check(value, pd); err != nil {
+		return err
+	}
+	if _, err := d.v.WriteBytes([]byte{byte(pd)<<4 | byte(value>>8), byte(value)}); err != nil {
+		return err
+	}
+	d.value, d.pd = value, pd
+	return nil
+}
+
+// Set sets the DAC to value, 0 to Max, with a fast write, keeping the
+// power down mode.
+func (d *Device) Set(value uint16) error {
+	return d.fastWrite(value, d.pd)
+}
+
+// SetPowerDown sets the power down mode of the output, keeping the DAC
+// value.
+func (d *Device) SetPowerDown(pd PowerDown) error {
+	return d.fastWrite(d.value, pd)
+}
+
+// Store sets the DAC to value and pd and writes them to the EEPROM, to be
+// restored at power on, waiting for the end of the EEPROM write.
+func (d *Device) Store(value uint16, pd PowerDown) error {
+	if err := check(value, pd); err != nil {
+		return err
+	}
+	w := []byte{cmdWriteDACEEPROM | byte(pd)<<1, byte(value >> 4), byte(value << 4)}
+	if _, err := d.v.WriteBytes(w); err != nil {
+		return err
+	}
+	d.value, d.pd = value, pd
+	deadline := time.Now().Add(eepromTimeout)
+	var b [1]byte
+	for {
+		time.Sleep(time.Millisecond)
+		if _, err := d.v.ReadBytes(b[:]); err != nil {
+			return err
+		}
+		if b[0]&statusReady != 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.New("mcp4725: eeprom write timeout")
+		}
+	}
+}
+
+// State reads the DAC and EEPROM registers.
+func (d *Device) State() (State, error) {
+	var b [5]byte
+	if _, err := d.v.ReadBytes(b[:]); err != nil {
+		return State{}, err
+	}
+	return State{
+		Value:           uint16(b[1])<<4 | uint16(b[2])>>4,
+		PowerDown:       PowerDown(b[0]>>1) & 0x03,
+		EEPROMValue:     uint16(b[3]&0x0F)<<8 | uint16(b[4]),
+		EEPROMPowerDown: PowerDown(b[3]>>5) & 0x03,
+		Busy:            b[0]&statusReady == 0,
+		PowerOnReset:    b[0]&statusPOR != 0,
+	}, nil
+}
@@ -0,0 +1,416 @@
+// Package mpu6050 drives the InvenSense MPU6050, MPU6500 and MPU9250
+// accelerometer and gyroscope IMUs. The magnetometer of the MPU9250 is a
+// separate chip and is not covered.
+//
+//	v, err := i2c.NewI2C(0x68, 1)
+//	...
+//	d, err := mpu6050.New(v)
+//	...
+//	s, err := d.Read()
+//
+// For high rate sampling the samples are collected in the FIFO of the
+// chip and read in bursts, many samples per transfer:
+//
+//	d.SetSampleRate(1000)
+//	d.EnableFIFO(mpu6050.FIFOAccel | mpu6050.FIFOGyro)
+//	buf := make([]mpu6050.Sample, 64)
+//	for {
+//		n, err := d.ReadFIFO(buf)
+//		...
+//		process(buf[:n])
+//		time.Sleep(20 * time.Millisecond)
+//	}
+//
+// Readings are in SI units: m/s² and rad/s.
+package mpu6050
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// Registers.
+const (
+	regSmplrtDiv   = 0x19
+	regConfig      = 0x1A
+	regGyroConfig  = 0x1B
+	regAccelConfig = 0x1C
+	regFIFOEn      = 0x23
+	regIntStatus   = 0x3A
+	regAccelXOutH  = 0x3B // accel, temp, gyro, 14 bytes
+	regUserCtrl    = 0x6A
+	regPwrMgmt1    = 0x6B
+	regFIFOCountH  = 0x72
+	regFIFORW      = 0x74
+	regWhoAmI      = 0x75
+)
+
+const (
+	pwrReset        = 1 << 7
+	pwrSleep        = 1 << 6
+	pwrClockPLL     = 1 // PLL with the X gyro as reference
+	userFIFOEn      = 1 << 6
+	userFIFOReset   = 1 << 2
+	intFIFOOverflow = 1 << 4
+)
+
+// Chip IDs.
+const (
+	IDMPU6050 = 0x68
+	IDMPU6500 = 0x70
+	IDMPU9250 = 0x71
+	IDMPU9255 = 0x73
+)
+
+const g = 9.80665 // m/s²
+
+var (
+	// ErrChip is returned when the device does not identify as a
+	// supported IMU.
+	ErrChip = errors.New("mpu6050: unknown chip id")
+	// ErrFIFOOverflow is returned by ReadFIFO when the FIFO overflowed,
+	// losing samples. The FIFO is reset.
+	ErrFIFOOverflow = errors.New("mpu6050: FIFO overflow")
+)
+
+// AccelRange is the full scale range of the accelerometer.
+type AccelRange uint8
+
+// Accelerometer ranges.
+const (
+	Accel2G AccelRange = iota
+	Accel4G
+	Accel8G
+	Accel16G
+)
+
+// GyroRange is the full scale range of the gyroscope.
+type GyroRange uint8
+
+// Gyroscope ranges, in degrees per second.
+const (
+	Gyro250 GyroRange = iota
+	Gyro500
+	Gyro1000
+	Gyro2000
+)
+
+// DLPF is the digital low pass filter setting, named by the accelerometer
+// bandwidth of the MPU6050; the gyroscope and the MPU9250 are close.
+type DLPF uint8
+
+// Filter settings. DLPFOff raises the gyroscope output rate from 1kHz to
+// 8kHz.
+const (
+	DLPFOff DLPF = iota
+	DLPF184Hz
+	DLPF94Hz
+	DLPF44Hz
+	DLPF21Hz
+	DLPF10Hz
+	DLPF5Hz
+)
+
+// FIFOData selects the measurements stored in the FIFO.
+type FIFOData uint8
+
+// FIFO contents, bits of the FIFO_EN register.
+const (
+	FIFOTemp  FIFOData = 1 << 7
+	FIFOGyro  FIFOData = 7 << 4
+	FIFOAccel FIFOData = 1 << 3
+)
+
+// Sample is a measurement. Measurements not stored in the FIFO are zero in
+// the samples of ReadFIFO.
+type Sample struct {
+	Accel       [3]float64 // X, Y, Z, m/s²
+	Gyro        [3]float64 // X, Y, Z, rad/s
+	Temperature float64    // °C
+}
+
+// Device is an MPU6050, MPU6500 or MPU9250.
+type Device struct {
+	v        *i2c.I2C
+	id       byte
+	accelLSB float64 // m/s² per count
+	gyroLSB  float64 // rad/s per count
+	dlpf     DLPF
+	div      int
+	fifo     FIFOData
+	buf      []byte
+}
+
+// New returns the IMU talking through v, after checking its chip id,
+// reset and woken up: ±2g and ±250°/s ranges, 44Hz filter, 100Hz sample
+// rate.
+func New(v *i2c.I2C) (*Device, error) {
+	id, err := v.ReadRegU8(regWhoAmI)
+	if err != nil {
+		return nil, err
+	}
+	switch id {
+	case IDMPU6050, IDMPU6500, IDMPU9250, IDMPU9255:
+	default:
+		return nil, fmt.Errorf("%w 0x%02X", ErrChip, id)
+	}
+	d := &Device{v: v, id: id}
+	if err := v.WriteRegU8(regPwrMgmt1, pwrReset); err != nil {
+		return nil, err
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := v.WriteRegU8(regPwrMgmt1, pwrClockPLL); err != nil {
+		return nil, err
+	}
+	if err := d.SetAccelRange(Accel2G); err != nil {
+		return nil, err
+	}
+	if err := d.SetGyroRange(Gyro250); err != nil {
+		return nil, err
+	}
+	if err := d.SetDLPF(DLPF44Hz); err != nil {
+		return nil, err
+	}
+	if err := d.SetSampleRate(100); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ID returns the chip id.
+func (d *Device) ID() byte {
+	return d.id
+}
+
+// SetSleep puts the IMU to sleep, or wakes it up.
+func (d *Device) SetSleep(on bool) error {
+	var b byte
+	if on {
+		b = pwrSleep
+	}
+	return d.v.UpdateRegU8(regPwrMgmt1, pwrSleep, b)
+}
+
+// SetAccelRange sets the full scale range of the accelerometer.
+func (d *Device) SetAccelRange(r AccelRange) error {
+	if r > Accel16G {
+		return errors.New("mpu6050: invalid accelerometer range")
+	}
+	if err := d.v.WriteRegBits(regAccelConfig, 0x18, 3, byte(r)); err != nil {
+		return err
+	}
+	d.accelLSB = g * float64(int(2)<<r) / 32768
+	return nil
+}
+
+// SetGyroRange sets the full scale range of the gyroscope.
+func (d *Device) SetGyroRange(r GyroRange) error {
+	if r > Gyro2000 {
+		return errors.New("mpu6050: invalid gyroscope range")
+	}
+	if err := d.v.WriteRegBits(regGyroConfig, 0x18, 3, byte(r)); err != nil {
+		return err
+	}
+	d.gyroLSB = float64(int(250)<<r) / 32768 * math.Pi / 180
+	return nil
+}
+
+// SetDLPF sets the digital low pass filter. It changes the base of the
+// sample rate, which SetDLPF keeps when possible.
+func (d *Device) SetDLPF(f DLPF) error {
+	if f > DLPF5Hz {
+		return errors.New("mpu6050: invalid filter")
+	}
+	rate := d.SampleRate()
+	if err := d.v.WriteRegBits(regConfig, 0x07, 0, byte(f)); err != nil {
+		return err
+	}
+	d.dlpf = f
+	if d.div == 0 {
+		// not configured yet
+		return nil
+	}
+	return d.SetSampleRate(rate)
+}
+
+// base returns the gyroscope output rate divided to the sample rate.
+func (d *Device) base() float64 {
+	if d.dlpf == DLPFOff {
+		return 8000
+	}
+	return 1000
+}
+
+// SetSampleRate sets the rate of the samples, in Hz, rounded to a divider
+// of the gyroscope output rate: 1kHz, or 8kHz with DLPFOff. The
+// accelerometer is sampled at 1kHz at most.
+func (d *Device) SetSampleRate(hz float64) error {
+	div := math.Round(d.base() / hz)
+	if hz <= 0 || div < 1 || div > 256 {
+		return fmt.Errorf("mpu6050: sample rate %gHz out of range", hz)
+	}
+	if err := d.v.WriteRegU8(regSmplrtDiv, byte(div-1)); err != nil {
+		return err
+	}
+	d.div = int(div)
+	return nil
+}
+
+// SampleRate returns the sample rate in Hz.
+func (d *Device) SampleRate() float64 {
+	if d.div == 0 {
+		return 0
+	}
+	return d.base() / float64(d.div)
+}
+
+// temperature converts a raw temperature.
+func (d *Device) temperature(raw int16) float64 {
+	if d.id == IDMPU6050 {
+		return float64(raw)/340 + 36.53
+	}
+	return float64(raw)/333.87 + 21
+}
+
+func word(b []byte) int16 {
+	return int16(uint16(b[0])<<8 | uint16(b[1]))
+}
+
+// Read returns the latest measurement, read in one transfer.
+func (d *Device) Read() (Sample, error) {
+	var b [14]byte
+	if _, err := d.v.ReadRegBytesInto(regAccelXOutH, b[:]); err != nil {
+		return Sample{}, err
+	}
+	var s Sample
+	for i := 0; i < 3; i++ {
+		s.Accel[i] = float64(word(b[2*i:])) * d.accelLSB
+		s.Gyro[i] = float64(word(b[8+2*i:])) * d.gyroLSB
+	}
+	s.Temperature = d.temperature(word(b[6:]))
+	return s, nil
+}
+
+// frameSize returns the size of a sample in the FIFO.
+func (d *Device) frameSize() int {
+	n := 0
+	if d.fifo&FIFOAccel != 0 {
+		n += 6
+	}
+	if d.fifo&FIFOTemp != 0 {
+		n += 2
+	}
+	if d.fifo&FIFOGyro != 0 {
+		n += 6
+	}
+	return n
+}
+
+// fifoSize returns the capacity of the FIFO in bytes.
+func (d *Device) fifoSize() int {
+	if d.id == IDMPU6050 {
+		return 1024
+	}
+	return 512
+}
+
+// EnableFIFO resets the FIFO and starts storing the measurements of data
+// in it at every sample, or stops the FIFO when data is zero.
+func (d *Device) EnableFIFO(data FIFOData) error {
+	if data&^(FIFOTemp|FIFOGyro|FIFOAccel) != 0 {
+		return errors.New("mpu6050: invalid FIFO data")
+	}
+	if err := d.v.WriteRegU8(regFIFOEn, 0); err != nil {
+		return err
+	}
+	d.fifo = data
+	if err := d.ResetFIFO(); err != nil {
+		return err
+	}
+	if data == 0 {
+		return d.v.UpdateRegU8(regUserCtrl, userFIFOEn, 0)
+	}
+	if err := d.v.UpdateRegU8(regUserCtrl, userFIFOEn, userFIFOEn); err != nil {
+		return err
+	}
+	return d.v.WriteRegU8(regFIFOEn, byte(data))
+}
+
+// ResetFIFO discards the contents of the FIFO.
+func (d *Device) ResetFIFO() error {
+	return d.v.UpdateRegU8(regUserCtrl, userFIFOReset, userFIFOReset)
+}
+
+// FIFOCount returns the count of complete samples in the FIFO.
+func (d *Device) FIFOCount() (int, error) {
+	n, err := d.v.ReadRegU16BE(regFIFOCountH)
+	if err != nil || d.fifo == 0 {
+		return 0, err
+	}
+	return int(n) / d.frameSize(), nil
+}
+
+// ReadFIFO reads up to len(dst) samples from the FIFO, oldest first, in
+// a single burst, and returns their count. It returns ErrFIFOOverflow,
+// resetting the FIFO, when samples were lost since the previous call.
+func (d *Device) ReadFIFO(dst []Sample) (int, error) {
+	if d.fifo == 0 {
+		return 0, errors.New("mpu6050: FIFO not enabled")
+	}
+	count, err := d.v.ReadRegU16BE(regFIFOCountH)
+	if err != nil {
+		return 0, err
+	}
+	frame := d.frameSize()
+	if int(count) >= d.fifoSize() {
+		// the oldest samples were overwritten, losing the frame alignment
+		st, err := d.v.ReadRegU8(regIntStatus)
+		if err != nil {
+			return 0, err
+		}
+		if st&intFIFOOverflow != 0 {
+			if err := d.ResetFIFO(); err != nil {
+				return 0, err
+			}
+			return 0, ErrFIFOOverflow
+		}
+	}
+	n := min(int(count)/frame, len(dst))
+	if n == 0 {
+		return 0, nil
+	}
+	if cap(d.buf) < n*frame {
+		d.buf = make([]byte, n*frame)
+	}
+	buf := d.buf[:n*frame]
+	// the FIFO register does not auto-increment: a single read, not
+	// split at register boundaries
+	if err := d.v.Tx([]byte{regFIFORW}, buf); err != nil {
+		return 0, err
+	}
+	for i := 0; i < n; i++ {
+		b := buf[i*frame:]
+		s := Sample{}
+		if d.fifo&FIFOAccel != 0 {
+			for j := 0; j < 3; j++ {
+				s.Accel[j] = float64(word(b[2*j:])) * d.accelLSB
+			}
+			b = b[6:]
+		}
+		if d.fifo&FIFOTemp != 0 {
+			s.Temperature = d.temperature(word(b))
+			b = b[2:]
+		}
+		if d.fifo&FIFOGyro != 0 {
+			for j := 0; j < 3; j++ {
+				s.Gyro[j] = float64(word(b[2*j:])) * d.gyroLSB
+			}
+		}
+		dst[i] = s
+	}
+	return n, nil
+}
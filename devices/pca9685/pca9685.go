@@ -0,0 +1,295 @@
+// Package pca9685 drives the NXP PCA9685 16 channel, 12 bit PWM
+// controller, commonly used for servos and LEDs.
+//
+//	v, err := i2c.NewI2C(0x40, 1)
+//	...
+//	d, err := pca9685.New(v)
+//	...
+//	d.SetFrequency(50)
+//	s := d.Servo(0)
+//	s.SetAngle(90)
+//	d.SetDuty(15, 0.25)
+//
+// Each period is divided in 4096 ticks; a channel output turns on at its
+// on tick and off at its off tick.
+package pca9685
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// Registers.
+const (
+	regMode1    = 0x00
+	regMode2    = 0x01
+	regLED0     = 0x06 // ON_L, ON_H, OFF_L, OFF_H, then the next channel
+	regAllLED   = 0xFA
+	regPrescale = 0xFE
+)
+
+// MODE1 bits.
+const (
+	mode1Restart = 1 << 7
+	mode1AI      = 1 << 5
+	mode1Sleep   = 1 << 4
+	mode1AllCall = 1 << 0
+)
+
+// MODE2 bits.
+const (
+	mode2Invert = 1 << 4
+	mode2OutDrv = 1 << 2
+)
+
+const (
+	// fullTick is the bit of ON_H and OFF_H turning the output fully on
+	// or off.
+	fullTick = 0x1000
+	// TicksPerPeriod is the number of ticks of a period.
+	TicksPerPeriod = 4096
+	// InternalOscillator is the frequency of the internal oscillator.
+	InternalOscillator = 25000000
+	// oscStart is the oscillator start up time after leaving sleep.
+	oscStart = 500 * time.Microsecond
+)
+
+// AllChannels addresses all the channels at once.
+const AllChannels = 16
+
+// Device is a PCA9685.
+type Device struct {
+	v    *i2c.I2C
+	osc  float64
+	freq float64
+}
+
+// New returns the controller talking through v, woken up with the power
+// on frequency of 200Hz and totem pole outputs.
+func New(v *i2c.I2C) (*Device, error) {
+	d := &Device{v: v, osc: InternalOscillator}
+	if err := v.WriteRegU8(regMode2, mode2OutDrv); err != nil {
+		return nil, err
+	}
+	if err := v.WriteRegU8(regMode1, mode1AI|mode1AllCall); err != nil {
+		return nil, err
+	}
+	time.Sleep(oscStart)
+	pre, err := v.ReadRegU8(regPrescale)
+	if err != nil {
+		return nil, err
+	}
+	d.freq = d.osc / (TicksPerPeriod * (float64(pre) + 1))
+	return d, nil
+}
+
+// SetOscillator sets the frequency of the oscillator used to compute the
+// prescaler, e.g. to calibrate the internal oscillator, which is only
+// accurate to a few percent, or for an external clock on EXTCLK.
+func (d *Device) SetOscillator(hz float64) {
+	d.osc = hz
+}
+
+// SetOutput configures the outputs: inverted, e.g. for LEDs connected
+// between the supply and the output, and open drain instead of totem
+// pole.
+func (d *Device) SetOutput(invert, openDrain bool) error {
+	var m byte
+	if invert {
+		m |= mode2Invert
+	}
+	if !openDrain {
+		m |= mode2OutDrv
+	}
+	return d.v.WriteRegU8(regMode2, m)
+}
+
+// SetFrequency sets the PWM frequency, 24Hz to 1526Hz with the internal
+// oscillator. The prescaler only accepts changes in sleep mode, so the
+// outputs pause for about a millisecond.
+func (d *Device) SetFrequency(hz float64) error {
+	pre := math.Round(d.osc/(TicksPerPeriod*hz)) - 1
+	if pre < 3 || pre > 255 {
+		return fmt.Errorf("pca9685: frequency %gHz out of range", hz)
+	}
+	mode, err := d.v.ReadRegU8(regMode1)
+	if err != nil {
+		return err
+	}
+	mode &^= mode1Restart
+	if err := d.v.WriteRegU8(regMode1, mode|mode1Sleep); err != nil {
+		return err
+	}
+	if err := d.v.WriteRegU8(regPrescale, byte(pre)); err != nil {
+		return err
+	}
+	if err := d.v.WriteRegU8(regMode1, mode); err != nil {
+		return err
+	}
+	if mode&mode1Sleep == 0 {
+		if err := d.restart(mode); err != nil {
+			return err
+		}
+	}
+	d.freq = d.osc / (TicksPerPeriod * (pre + 1))
+	return nil
+}
+
+// restart resumes the PWM outputs as they were before sleep, once the
+// oscillator is up again.
+func (d *Device) restart(mode byte) error {
+	time.Sleep(oscStart)
+	return d.v.WriteRegU8(regMode1, mode|mode1Restart)
+}
+
+// Frequency returns the PWM frequency, as obtained from the prescaler.
+func (d *Device) Frequency() float64 {
+	return d.freq
+}
+
+// Sleep stops the oscillator, turning all the outputs off, to save power.
+func (d *Device) Sleep() error {
+	return d.v.UpdateRegU8(regMode1, mode1Sleep|mode1Restart, mode1Sleep)
+}
+
+// Wake leaves sleep mode, restoring the outputs as they were.
+func (d *Device) Wake() error {
+	mode, err := d.v.ReadRegU8(regMode1)
+	if err != nil {
+		return err
+	}
+	if mode&mode1Sleep == 0 {
+		return nil
+	}
+	// RESTART reads 1 when there are channels to resume; writing it back
+	// resumes them, writing 0 leaves it unchanged
+	restart := mode&mode1Restart != 0
+	mode &^= mode1Sleep | mode1Restart
+	if err := d.v.WriteRegU8(regMode1, mode); err != nil {
+		return err
+	}
+	if !restart {
+		time.Sleep(oscStart)
+		return nil
+	}
+	return d.restart(mode)
+}
+
+// reg returns the first register of channel ch.
+func reg(ch int) (byte, error) {
+	switch {
+	case ch == AllChannels:
+		return regAllLED, nil
+	case ch < 0 || ch > AllChannels:
+		return 0, fmt.Errorf("pca9685: no channel %d", ch)
+	}
+	return byte(regLED0 + 4*ch), nil
+}
+
+// setRaw writes the ON and OFF registers of channel ch.
+func (d *Device) setRaw(ch int, on, off uint16) error {
+	r, err := reg(ch)
+	if err != nil {
+		return err
+	}
+	_, err = d.v.WriteRegBytes(r, []byte{byte(on), byte(on >> 8), byte(off), byte(off >> 8)})
+	return err
+}
+
+// SetTicks sets channel ch, or AllChannels, to turn on at tick on and off
+// at tick off of every period, 0 to 4095.
+func (d *Device) SetTicks(ch int, on, off uint16) error {
+	if on >= TicksPerPeriod || off >= TicksPerPeriod {
+		return errors.New("pca9685: tick out of range")
+	}
+	return d.setRaw(ch, on, off)
+}
+
+// Ticks returns the on and off ticks of channel ch. Fully on and fully
+// off channels are reported with full set.
+func (d *Device) Ticks(ch int) (on, off uint16, full bool, err error) {
+	if ch == AllChannels {
+		return 0, 0, false, errors.New("pca9685: all channels registers are write only")
+	}
+	r, err := reg(ch)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	var b [4]byte
+	if _, err := d.v.ReadRegBytesInto(r, b[:]); err != nil {
+		return 0, 0, false, err
+	}
+	on = uint16(b[0]) | uint16(b[1])<<8
+	off = uint16(b[2]) | uint16(b[3])<<8
+	full = (on|off)&fullTick != 0
+	return on &^ fullTick, off &^ fullTick, full, nil
+}
+
+// SetFullOn turns channel ch, or AllChannels, fully on.
+func (d *Device) SetFullOn(ch int) error {
+	return d.setRaw(ch, fullTick, 0)
+}
+
+// SetFullOff turns channel ch, or AllChannels, fully off.
+func (d *Device) SetFullOff(ch int) error {
+	return d.setRaw(ch, 0, fullTick)
+}
+
+// SetDuty sets the duty cycle of channel ch, or AllChannels, from 0 to 1,
+// e.g. for the brightness of a LED. 0 and 1 turn it fully off and on.
+func (d *Device) SetDuty(ch int, duty float64) error {
+	switch {
+	case duty <= 0:
+		return d.SetFullOff(ch)
+	case duty >= 1:
+		return d.SetFullOn(ch)
+	}
+	return d.setRaw(ch, 0, uint16(min(math.Round(duty*TicksPerPeriod), TicksPerPeriod-1)))
+}
+
+// SetPulse sets channel ch, or AllChannels, to pulses of width w at the
+// start of each period, e.g. for servos.
+func (d *Device) SetPulse(ch int, w time.Duration) error {
+	period := time.Duration(float64(time.Second) / d.freq)
+	if w < 0 || w > period {
+		return fmt.Errorf("pca9685: pulse %v out of the %v period", w, period)
+	}
+	return d.SetDuty(ch, float64(w)/float64(period))
+}
+
+// Servo is a servo connected to a channel. The fields describe the servo,
+// and may be changed before use.
+type Servo struct {
+	d  *Device
+	ch int
+	// MinPulse and MaxPulse are the pulse widths of the ends of the
+	// travel.
+	MinPulse, MaxPulse time.Duration
+	// Range is the travel in degrees.
+	Range float64
+}
+
+// Servo returns the servo connected to channel ch, with 1ms to 2ms pulses
+// for a 180° travel. The frequency should be set to the one expected by
+// the servo, usually 50Hz.
+func (d *Device) Servo(ch int) *Servo {
+	return &Servo{d: d, ch: ch, MinPulse: time.Millisecond, MaxPulse: 2 * time.Millisecond, Range: 180}
+}
+
+// SetAngle moves the servo to deg degrees, 0 to Range.
+func (s *Servo) SetAngle(deg float64) error {
+	if deg < 0 || deg > s.Range {
+		return fmt.Errorf("pca9685: angle %g out of range", deg)
+	}
+	w := s.MinPulse + time.Duration(deg/s.Range*float64(s.MaxPulse-s.MinPulse))
+	return s.d.SetPulse(s.ch, w)
+}
+
+// Off stops the pulses, letting the servo move freely.
+func (s *Servo) Off() error {
+	return s.d.SetFullOff(s.ch)
+}
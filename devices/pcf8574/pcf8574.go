@@ -0,0 +1,208 @@
+// Package pcf8574 drives the NXP and Texas Instruments PCF8574 and
+// PCF8574A 8 pin GPIO expanders, the same chip at addresses 0x20 to 0x27
+// and 0x38 to 0x3F respectively.
+//
+//	v, err := i2c.NewI2C(0x20, 1)
+//	...
+//	d := pcf8574.New(v)
+//	led, button := d.Pin(0), d.Pin(7)
+//	led.Out(gpio.Low)
+//	button.In(gpio.PullUp)
+//	l, err := button.Read()
+//
+// The pins are quasi-bidirectional: there is no direction register, a pin
+// latched high is only pulled up by a weak current source and reads as
+// the level driven on it, while a pin latched low sinks current and reads
+// low. A pin is thus an input once written high. The chip cannot report
+// its latch, which the Device keeps instead.
+//
+// The INT output goes low when an input changes from its level at the
+// last read, and is released by the next read or write. WaitChange uses
+// it, when set with SetIntPin, to report changes without polling.
+package pcf8574
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+	"github.com/fedeonline/i2c-go/devices/gpio"
+)
+
+// Pins is the number of pins of the chip.
+const Pins = 8
+
+// IntPin is the input connected to the INT pin. It is satisfied by
+// periph.io gpio.PinIn pins configured for falling edges.
+type IntPin interface {
+	WaitForEdge(timeout time.Duration) bool
+}
+
+// Device is a PCF8574 or PCF8574A.
+type Device struct {
+	v     *i2c.I2C
+	mu    sync.Mutex
+	latch byte
+	last  byte // levels at the last read
+	irq   IntPin
+}
+
+// New returns the expander talking through v. It assumes the power on
+// state, all the pins latched high, until the first write.
+func New(v *i2c.I2C) *Device {
+	return &Device{v: v, latch: 0xFF, last: 0xFF}
+}
+
+// SetIntPin sets the pin connected to INT, nil to poll the pins instead.
+func (d *Device) SetIntPin(p IntPin) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.irq = p
+}
+
+// readLocked reads the levels of all the pins, releasing INT.
+func (d *Device) readLocked() (byte, error) {
+	var b [1]byte
+	if _, err := d.v.ReadBytes(b[:]); err != nil {
+		return 0, err
+	}
+	d.last = b[0]
+	return b[0], nil
+}
+
+// writeLocked latches value.
+func (d *Device) writeLocked(value byte) error {
+	if _, err := d.v.WriteBytes([]byte{value}); err != nil {
+		return err
+	}
+	d.latch = value
+	return nil
+}
+
+// ReadAll returns the levels of all the pins, bit n for pin n.
+func (d *Device) ReadAll() (byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.readLocked()
+}
+
+// WriteAll latches all the pins, bit n for pin n: pins written high
+// become inputs.
+func (d *Device) WriteAll(value byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.writeLocked(value)
+}
+
+// Write latches the pins of mask, keeping the others.
+func (d *Device) Write(mask, value byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.writeLocked(d.latch&^mask | value&mask)
+}
+
+// Latch returns the latched levels of the pins.
+func (d *Device) Latch() byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.latch
+}
+
+// WaitChange waits until pins change from their levels at the last read,
+// and returns the pins which changed and the levels of all the pins. It
+// waits for INT when set with SetIntPin, otherwise polls the pins every
+// millisecond. Changes reverted before the read are not reported.
+func (d *Device) WaitChange(ctx context.Context) (changed, levels byte, err error) {
+	for {
+		d.mu.Lock()
+		irq := d.irq
+		d.mu.Unlock()
+		if irq != nil {
+			// bounded waits, to notice ctx being done
+			if !irq.WaitForEdge(100 * time.Millisecond) {
+				if err := ctx.Err(); err != nil {
+					return 0, 0, err
+				}
+				continue
+			}
+		} else {
+			select {
+			case <-ctx.Done():
+				return 0, 0, ctx.Err()
+			case <-time.After(time.Millisecond):
+			}
+		}
+		d.mu.Lock()
+		prev := d.last
+		l, err := d.readLocked()
+		d.mu.Unlock()
+		if err != nil {
+			return 0, 0, err
+		}
+		if c := l ^ prev; c != 0 {
+			return c, l, nil
+		}
+	}
+}
+
+// Pin returns pin n, 0 to 7 for P0 to P7. It panics if there is no such
+// pin.
+func (d *Device) Pin(n int) gpio.Pin {
+	if n < 0 || n >= Pins {
+		panic(fmt.Sprintf("pcf8574: no pin %d", n))
+	}
+	return &pin{d: d, n: n}
+}
+
+// pin is a pin of a Device.
+type pin struct {
+	d *Device
+	n int
+}
+
+func (p *pin) Name() string {
+	name := "PCF8574"
+	if a := p.d.v.Addr(); a >= 0x38 && a <= 0x3F {
+		name = "PCF8574A"
+	}
+	return fmt.Sprintf("%s_0x%02x_P%d", name, p.d.v.Addr(), p.n)
+}
+
+func (p *pin) Number() int {
+	return p.n
+}
+
+func (p *pin) String() string {
+	return p.Name()
+}
+
+// In latches the pin high, leaving it pulled up by the weak current
+// source of the chip, the only bias available.
+func (p *pin) In(pull gpio.Pull) error {
+	if pull != gpio.PullUp {
+		return gpio.ErrUnsupported
+	}
+	bit := byte(1) << p.n
+	return p.d.Write(bit, bit)
+}
+
+// Out latches l. A high output is only pulled up weakly: loads must be
+// driven by sinking current.
+func (p *pin) Out(l gpio.Level) error {
+	bit := byte(1) << p.n
+	var value byte
+	if l {
+		value = bit
+	}
+	return p.d.Write(bit, value)
+}
+
+func (p *pin) Read() (gpio.Level, error) {
+	b, err := p.d.ReadAll()
+	if err != nil {
+		return gpio.Low, err
+	}
+	return gpio.Level(b&(1<<p.n) != 0), nil
+}
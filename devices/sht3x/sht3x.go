@@ -0,0 +1,244 @@
+// Package sht3x drives the Sensirion SHT30, SHT31 and SHT35 humidity and
+// temperature sensors.
+//
+//	v, err := i2c.NewI2C(0x44, 1)
+//	...
+//	d := sht3x.New(v)
+//	r, err := d.Read()
+//	fmt.Printf("%.2f°C %.1f%%\n", r.Temperature, r.Humidity)
+//
+// The sensors take 16 bit commands instead of register addresses, and
+// protect every returned word with a CRC-8, see package cmdword.
+package sht3x
+
+import (
+	"errors"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+	"github.com/fedeonline/i2c-go/cmdword"
+)
+
+// Commands.
+const (
+	cmdFetch        = 0xE000
+	cmdBreak        = 0x3093
+	cmdReset        = 0x30A2
+	cmdHeaterOn     = 0x306D
+	cmdHeaterOff    = 0x3066
+	cmdStatus       = 0xF32D
+	cmdClearStatus  = 0x3041
+	cmdSerialNumber = 0x3780
+)
+
+// ErrNotReady is returned by Fetch when no new measurement is available.
+var ErrNotReady = errors.New("sht3x: no measurement available")
+
+// Repeatability trades the duration and consumption of a measurement for
+// its noise.
+type Repeatability uint8
+
+// Repeatability settings.
+const (
+	High Repeatability = iota
+	Medium
+	Low
+)
+
+// singleShot holds the single shot commands by repeatability, with clock
+// stretching and without.
+var singleShot = [3][2]uint16{
+	High:   {0x2400, 0x2C06},
+	Medium: {0x240B, 0x2C0D},
+	Low:    {0x2416, 0x2C10},
+}
+
+// duration holds the maximum measurement duration by repeatability.
+var duration = [3]time.Duration{
+	High:   15500 * time.Microsecond,
+	Medium: 6500 * time.Microsecond,
+	Low:    4500 * time.Microsecond,
+}
+
+// Rate is the number of measurements per second in periodic mode.
+type Rate uint8
+
+// Rates.
+const (
+	Rate05 Rate = iota // one measurement every 2s
+	Rate1
+	Rate2
+	Rate4
+	Rate10
+)
+
+// periodic holds the periodic mode commands by rate and repeatability.
+var periodic = [5][3]uint16{
+	Rate05: {0x2032, 0x2024, 0x202F},
+	Rate1:  {0x2130, 0x2126, 0x212D},
+	Rate2:  {0x2236, 0x2220, 0x222B},
+	Rate4:  {0x2334, 0x2322, 0x2329},
+	Rate10: {0x2737, 0x2721, 0x272A},
+}
+
+// Reading is a measurement.
+type Reading struct {
+	Temperature float64 // °C
+	Humidity    float64 // %RH
+}
+
+// Status is the status register.
+type Status uint16
+
+// Status bits.
+const (
+	AlertPending  Status = 1 << 15
+	HeaterOn      Status = 1 << 13
+	HumidityAlert Status = 1 << 11
+	TempAlert     Status = 1 << 10
+	ResetDetected Status = 1 << 4
+	CommandFailed Status = 1 << 1
+	WriteCRCError Status = 1 << 0
+)
+
+// Device is an SHT3x sensor.
+type Device struct {
+	v       *i2c.I2C
+	rep     Repeatability
+	stretch bool
+}
+
+// New returns the sensor talking through v, measuring with high
+// repeatability without clock stretching.
+func New(v *i2c.I2C) *Device {
+	return &Device{v: v}
+}
+
+// SetRepeatability sets the repeatability of the following measurements.
+// It is applied to periodic mode by the next call to StartPeriodic.
+func (d *Device) SetRepeatability(r Repeatability) {
+	d.rep = r
+}
+
+// SetClockStretching selects how single shot measurements wait for the
+// result. With stretching the sensor holds the clock low until the result
+// is ready, which the adapter must tolerate; without, the sensor does not
+// acknowledge its address until then and Read polls it.
+func (d *Device) SetClockStretching(on bool) {
+	d.stretch = on
+}
+
+// Read performs a single shot measurement.
+func (d *Device) Read() (Reading, error) {
+	if d.stretch {
+		w, err := cmdword.Read(d.v, singleShot[d.rep][1], 0, 2)
+		if err != nil {
+			return Reading{}, err
+		}
+		return convert(w), nil
+	}
+	if err := cmdword.Send(d.v, singleShot[d.rep][0]); err != nil {
+		return Reading{}, err
+	}
+	// the sensor does not answer before the typical duration, a third
+	// of the maximum
+	time.Sleep(duration[d.rep] / 3)
+	deadline := time.Now().Add(duration[d.rep])
+	for {
+		w, err := cmdword.ReadWords(d.v, 2)
+		if err == nil {
+			return convert(w), nil
+		}
+		if !i2c.IsNack(err) || time.Now().After(deadline) {
+			return Reading{}, err
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// StartPeriodic starts measuring periodically at rate, with the current
+// repeatability. Measurements are then read with Fetch, and only Fetch,
+// Stop, Reset and SetHeater are accepted until Stop.
+func (d *Device) StartPeriodic(rate Rate) error {
+	if rate > Rate10 {
+		return errors.New("sht3x: invalid rate")
+	}
+	return cmdword.Send(d.v, periodic[rate][d.rep])
+}
+
+// Fetch returns the latest periodic measurement, clearing it, or
+// ErrNotReady when there is none.
+func (d *Device) Fetch() (Reading, error) {
+	if err := cmdword.Send(d.v, cmdFetch); err != nil {
+		return Reading{}, err
+	}
+	w, err := cmdword.ReadWords(d.v, 2)
+	if i2c.IsNack(err) {
+		return Reading{}, ErrNotReady
+	}
+	if err != nil {
+		return Reading{}, err
+	}
+	return convert(w), nil
+}
+
+// Stop stops periodic measurements, returning the sensor to single shot
+// mode.
+func (d *Device) Stop() error {
+	if err := cmdword.Send(d.v, cmdBreak); err != nil {
+		return err
+	}
+	time.Sleep(time.Millisecond)
+	return nil
+}
+
+// SetHeater switches the internal heater, used to check the sensor or to
+// evaporate condensation. It raises the temperature by a few degrees.
+func (d *Device) SetHeater(on bool) error {
+	cmd := uint16(cmdHeaterOff)
+	if on {
+		cmd = cmdHeaterOn
+	}
+	return cmdword.Send(d.v, cmd)
+}
+
+// Status reads the status register.
+func (d *Device) Status() (Status, error) {
+	w, err := cmdword.Read(d.v, cmdStatus, 0, 1)
+	if err != nil {
+		return 0, err
+	}
+	return Status(w[0]), nil
+}
+
+// ClearStatus clears the alert and reset flags of the status register.
+func (d *Device) ClearStatus() error {
+	return cmdword.Send(d.v, cmdClearStatus)
+}
+
+// SerialNumber reads the unique serial number of the sensor.
+func (d *Device) SerialNumber() (uint32, error) {
+	w, err := cmdword.Read(d.v, cmdSerialNumber, time.Millisecond, 2)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(w[0])<<16 | uint32(w[1]), nil
+}
+
+// Reset performs a soft reset, stopping periodic measurements and
+// switching the heater off.
+func (d *Device) Reset() error {
+	if err := cmdword.Send(d.v, cmdReset); err != nil {
+		return err
+	}
+	time.Sleep(2 * time.Millisecond)
+	return nil
+}
+
+// convert converts the raw temperature and humidity words.
+func convert(w []uint16) Reading {
+	return Reading{
+		Temperature: -45 + 175*float64(w[0])/65535,
+		Humidity:    100 * float64(w[1]) / 65535,
+	}
+}
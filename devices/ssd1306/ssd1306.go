@@ -0,0 +1,345 @@
+// Package ssd1306 drives monochrome OLED displays based on the Solomon
+// Systech SSD1306 and the Sino Wealth SH1106 controllers.
+//
+//	v, err := i2c.NewI2C(0x3C, 1)
+//	...
+//	d, err := ssd1306.New(v, nil)
+//	...
+//	draw.Draw(d, d.Bounds(), img, image.Point{}, draw.Src)
+//	err = d.Flush()
+//
+// The Device is a draw.Image backed by a framebuffer in memory: drawing
+// only changes the framebuffer, and Flush sends the region changed since
+// the previous Flush to the display.
+package ssd1306
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// Control bytes, starting every transfer.
+const (
+	ctrlCommand = 0x00
+	ctrlData    = 0x40
+)
+
+// Commands.
+const (
+	cmdContrast     = 0x81
+	cmdNormal       = 0xA6
+	cmdInverse      = 0xA7
+	cmdDisplayOff   = 0xAE
+	cmdDisplayOn    = 0xAF
+	cmdSegRemap     = 0xA0 // | 1 to mirror the columns
+	cmdCOMScanInc   = 0xC0
+	cmdCOMScanDec   = 0xC8
+	cmdColumnAddr   = 0x21 // SSD1306
+	cmdPageAddr     = 0x22 // SSD1306
+	cmdPageStart    = 0xB0 // SH1106, | page
+	cmdLowColumn    = 0x00 // SH1106, | low nibble
+	cmdHighColumn   = 0x10 // SH1106, | high nibble
+	sh1106ColOffset = 2    // the 128 columns are centered in 132
+)
+
+// Model is the display controller.
+type Model uint8
+
+// Models.
+const (
+	SSD1306 Model = iota
+	SH1106
+)
+
+// Rotation is the orientation of the image on the display.
+type Rotation uint8
+
+// Rotations, clockwise. Rotate0 and Rotate180 are done by the
+// controller; Rotate90 and Rotate270 swap the width and height of the
+// image.
+const (
+	Rotate0 Rotation = iota
+	Rotate90
+	Rotate180
+	Rotate270
+)
+
+// Config is the configuration of the display.
+type Config struct {
+	Model Model
+	// Width and Height are the size of the panel in pixels, 128x64 when
+	// zero. Height must be a multiple of 8.
+	Width, Height int
+	Rotation      Rotation
+	// Chunk is the count of bytes sent per transfer, 32 when zero. Larger
+	// chunks are faster on adapters supporting them.
+	Chunk int
+}
+
+// Device is a display.
+type Device struct {
+	v      *i2c.I2C
+	model  Model
+	w, h   int // of the panel
+	rot    Rotation
+	chunk  int
+	buf    []byte // one byte per column of each page, LSB on top
+	x0, x1 int    // dirty columns, empty when x0 > x1
+	p0, p1 int    // dirty pages
+}
+
+// New returns the display talking through v, initialized with cfg, a
+// 128x64 SSD1306 when nil, and cleared.
+func New(v *i2c.I2C, cfg *Config) (*Device, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	d := &Device{v: v, model: cfg.Model, w: cfg.Width, h: cfg.Height, chunk: cfg.Chunk, x0: 1}
+	if d.w == 0 {
+		d.w, d.h = 128, 64
+	}
+	if d.w <= 0 || d.w > 128 || d.h <= 0 || d.h > 64 || d.h%8 != 0 {
+		return nil, errors.New("ssd1306: invalid display size")
+	}
+	d.buf = make([]byte, d.w*d.h/8)
+	comPins := byte(0x12) // alternative, for 64 rows
+	if d.h < 64 {
+		comPins = 0x02
+	}
+	seq := []byte{
+		cmdDisplayOff,
+		0xD5, 0x80, // clock
+		0xA8, byte(d.h - 1), // multiplex ratio
+		0xD3, 0x00, // display offset
+		0x40, // start line 0
+		0xDA, comPins,
+		cmdContrast, 0xCF,
+		0xD9, 0xF1, // precharge
+		0xDB, 0x40, // VCOMH
+		0xA4, // display the RAM
+		cmdNormal,
+	}
+	if d.model == SH1106 {
+		seq = append(seq, 0xAD, 0x8B) // DC-DC converter on
+	} else {
+		seq = append(seq,
+			0x8D, 0x14, // charge pump on
+			0x20, 0x00, // horizontal addressing
+		)
+	}
+	if err := d.command(seq...); err != nil {
+		return nil, err
+	}
+	if err := d.SetRotation(cfg.Rotation); err != nil {
+		return nil, err
+	}
+	d.Invalidate()
+	if err := d.Flush(); err != nil {
+		return nil, err
+	}
+	if err := d.command(cmdDisplayOn); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// command sends command bytes.
+func (d *Device) command(c ...byte) error {
+	_, err := d.v.WriteBytes(append([]byte{ctrlCommand}, c...))
+	return err
+}
+
+// data sends display data in chunks.
+func (d *Device) data(b []byte) error {
+	_, err := d.v.WriteLarge(context.Background(), b, i2c.LargeTransfer{
+		Chunk:  d.chunk,
+		Prefix: func(int) []byte { return []byte{ctrlData} },
+	})
+	return err
+}
+
+// SetContrast sets the contrast, i.e. the brightness, of the display.
+func (d *Device) SetContrast(c byte) error {
+	return d.command(cmdContrast, c)
+}
+
+// SetInverted inverts the display, lighting the pixels which are off.
+func (d *Device) SetInverted(on bool) error {
+	c := byte(cmdNormal)
+	if on {
+		c = cmdInverse
+	}
+	return d.command(c)
+}
+
+// SetPower switches the display on or off. The framebuffer and the
+// display memory are kept while off.
+func (d *Device) SetPower(on bool) error {
+	c := byte(cmdDisplayOff)
+	if on {
+		c = cmdDisplayOn
+	}
+	return d.command(c)
+}
+
+// SetRotation sets the orientation of the image. The display keeps its
+// content, so the framebuffer should be redrawn when the bounds change.
+func (d *Device) SetRotation(r Rotation) error {
+	if r > Rotate270 {
+		return errors.New("ssd1306: invalid rotation")
+	}
+	// the default mounting has the columns mirrored and the rows scanned
+	// from the bottom; a half turn undoes both
+	seg, com := byte(cmdSegRemap|1), byte(cmdCOMScanDec)
+	if r == Rotate180 {
+		seg, com = cmdSegRemap, cmdCOMScanInc
+	}
+	if err := d.command(seg, com); err != nil {
+		return err
+	}
+	d.rot = r
+	d.Invalidate()
+	return nil
+}
+
+// ColorModel returns the model of the display, converting colors to black
+// or white.
+func (d *Device) ColorModel() color.Model {
+	return model
+}
+
+// model converts to color.Gray, either off (black) or on (white).
+var model = color.ModelFunc(func(c color.Color) color.Color {
+	if on(c) {
+		return color.White
+	}
+	return color.Black
+})
+
+// on reports whether c lights a pixel, i.e. its luminance is at least
+// half.
+func on(c color.Color) bool {
+	return color.GrayModel.Convert(c).(color.Gray).Y >= 0x80
+}
+
+// Bounds returns the bounds of the image, which depend on the rotation.
+func (d *Device) Bounds() image.Rectangle {
+	if d.rot == Rotate90 || d.rot == Rotate270 {
+		return image.Rect(0, 0, d.h, d.w)
+	}
+	return image.Rect(0, 0, d.w, d.h)
+}
+
+// panel returns the panel coordinates of the pixel at x, y of the image.
+func (d *Device) panel(x, y int) (int, int) {
+	switch d.rot {
+	case Rotate90:
+		return d.w - 1 - y, x
+	case Rotate270:
+		return y, d.h - 1 - x
+	}
+	return x, y
+}
+
+// At returns the color of the pixel at x, y of the framebuffer.
+func (d *Device) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(d.Bounds())) {
+		return color.Black
+	}
+	px, py := d.panel(x, y)
+	if d.buf[py/8*d.w+px]&(1<<(py%8)) != 0 {
+		return color.White
+	}
+	return color.Black
+}
+
+// Set sets the pixel at x, y of the framebuffer, on when c is light.
+func (d *Device) Set(x, y int, c color.Color) {
+	d.SetPixel(x, y, on(c))
+}
+
+// SetPixel sets the pixel at x, y of the framebuffer on or off.
+func (d *Device) SetPixel(x, y int, lit bool) {
+	if !(image.Point{x, y}.In(d.Bounds())) {
+		return
+	}
+	px, py := d.panel(x, y)
+	i, bit := py/8*d.w+px, byte(1)<<(py%8)
+	b := d.buf[i] &^ bit
+	if lit {
+		b |= bit
+	}
+	if b != d.buf[i] {
+		d.buf[i] = b
+		d.dirty(px, px, py/8, py/8)
+	}
+}
+
+// Clear turns all the pixels of the framebuffer off.
+func (d *Device) Clear() {
+	for i := range d.buf {
+		if d.buf[i] != 0 {
+			d.buf[i] = 0
+			d.dirty(i%d.w, i%d.w, i/d.w, i/d.w)
+		}
+	}
+}
+
+// Invalidate marks the whole framebuffer to be sent by the next Flush.
+func (d *Device) Invalidate() {
+	d.dirty(0, d.w-1, 0, d.h/8-1)
+}
+
+// dirty extends the dirty region to columns x0 to x1 of pages p0 to p1.
+func (d *Device) dirty(x0, x1, p0, p1 int) {
+	if d.x0 > d.x1 {
+		d.x0, d.x1, d.p0, d.p1 = x0, x1, p0, p1
+		return
+	}
+	d.x0, d.x1 = min(d.x0, x0), max(d.x1, x1)
+	d.p0, d.p1 = min(d.p0, p0), max(d.p1, p1)
+}
+
+// Flush sends the region of the framebuffer changed since the previous
+// Flush to the display.
+func (d *Device) Flush() error {
+	if d.x0 > d.x1 {
+		return nil
+	}
+	if d.model == SH1106 {
+		// page addressing only: one transfer per page
+		for p := d.p0; p <= d.p1; p++ {
+			col := d.x0 + sh1106ColOffset
+			if err := d.command(cmdPageStart|byte(p), cmdLowColumn|byte(col&0x0F), cmdHighColumn|byte(col>>4)); err != nil {
+				return err
+			}
+			if err := d.data(d.buf[p*d.w+d.x0 : p*d.w+d.x1+1]); err != nil {
+				return err
+			}
+		}
+	} else {
+		err := d.command(cmdColumnAddr, byte(d.x0), byte(d.x1), cmdPageAddr, byte(d.p0), byte(d.p1))
+		if err != nil {
+			return err
+		}
+		b := d.buf
+		if d.x0 != 0 || d.x1 != d.w-1 {
+			// gather the columns of the window, sent in sequence
+			b = make([]byte, 0, (d.x1-d.x0+1)*(d.p1-d.p0+1))
+			for p := d.p0; p <= d.p1; p++ {
+				b = append(b, d.buf[p*d.w+d.x0:p*d.w+d.x1+1]...)
+			}
+		} else {
+			b = b[d.p0*d.w : (d.p1+1)*d.w]
+		}
+		if err := d.data(b); err != nil {
+			return err
+		}
+	}
+	d.x0, d.x1 = 1, 0
+	return nil
+}
@@ -0,0 +1,138 @@
+// Package tca9548a drives the Texas Instruments TCA9548A and NXP PCA9548A
+// eight channel i2c multiplexers.
+//
+// Besides selecting channels by hand, the Device routes transfers
+// transparently: each Channel is an i2c.Bus selecting itself before every
+// transfer, so devices behind the mux are opened like any other and used
+// from any goroutine without caring about the mux state:
+//
+//	v, err := i2c.NewI2C(0x70, 1)
+//	...
+//	mux := tca9548a.New(v)
+//	left := i2c.OpenBus(mux.Channel(0), 0x76)
+//	right := i2c.OpenBus(mux.Channel(1), 0x76)
+//	go poll(left)
+//	go poll(right)
+//
+// Channel transfers reach the devices with I2C.Transfer of the mux
+// connection, so it must be able to address other devices: a Linux bus
+// or a connection opened with OpenBus, e.g. on the Channel of another mux
+// for cascaded muxes.
+package tca9548a
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// Channels is the count of channels of the mux.
+const Channels = 8
+
+// Device is a TCA9548A. Transfers through its Channels are serialized
+// with the selection of their channel, so the mux must only be driven
+// through one Device, and not by a kernel mux driver.
+type Device struct {
+	v   *i2c.I2C
+	mu  sync.Mutex
+	sel byte
+	// known is cleared when the selection of the mux is unknown, e.g.
+	// after a failed write.
+	known bool
+}
+
+// New returns the mux talking through v.
+func New(v *i2c.I2C) *Device {
+	return &Device{v: v}
+}
+
+// selectLocked sets the mask of the enabled channels, unless already set.
+func (d *Device) selectLocked(mask byte) error {
+	if d.known && d.sel == mask {
+		return nil
+	}
+	d.known = false
+	if _, err := d.v.WriteBytes([]byte{mask}); err != nil {
+		return err
+	}
+	d.sel, d.known = mask, true
+	return nil
+}
+
+// Select enables the channels of mask, bit n enabling channel n, and
+// disables the others. Several channels enabled at once form a single
+// bus, e.g. to broadcast to identical devices.
+func (d *Device) Select(mask byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.selectLocked(mask)
+}
+
+// Selected reads the mask of the enabled channels from the mux.
+func (d *Device) Selected() (byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var b [1]byte
+	if _, err := d.v.ReadBytes(b[:]); err != nil {
+		return 0, err
+	}
+	d.sel, d.known = b[0], true
+	return b[0], nil
+}
+
+// Disable disables all the channels.
+func (d *Device) Disable() error {
+	return d.Select(0)
+}
+
+// Channel returns channel n, 0 to 7. It panics if there is no such
+// channel.
+func (d *Device) Channel(n int) *Channel {
+	if n < 0 || n >= Channels {
+		panic(fmt.Sprintf("tca9548a: no channel %d", n))
+	}
+	return &Channel{d: d, n: n}
+}
+
+// Channel is a channel of the mux, implementing i2c.Bus.
+type Channel struct {
+	d *Device
+	n int
+}
+
+// Tx selects the channel, then writes w to the device at addr and reads r
+// in one combined transfer. No other transfer of the mux can happen in
+// between.
+func (c *Channel) Tx(addr uint16, w, r []byte) error {
+	if addr > 0x7F {
+		return errors.New("tca9548a: 10 bit addresses are not supported")
+	}
+	a := uint8(addr)
+	switch a {
+	case 0:
+		// Transfer reads it as the address of the connection
+		return errors.New("tca9548a: general call is not supported")
+	case c.d.v.Addr():
+		return fmt.Errorf("tca9548a: device at 0x%02X conflicts with the mux", a)
+	}
+	msgs := make([]i2c.Msg, 0, 2)
+	if len(w) > 0 || len(r) == 0 {
+		msgs = append(msgs, i2c.Msg{Addr: a, Buf: w})
+	}
+	if len(r) > 0 {
+		msgs = append(msgs, i2c.Msg{Addr: a, Read: true, Buf: r})
+	}
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	if err := c.d.selectLocked(1 << c.n); err != nil {
+		return fmt.Errorf("tca9548a: select channel %d: %w", c.n, err)
+	}
+	return c.d.v.Transfer(msgs...)
+}
+
+// Open returns a connection to the device at addr behind the channel.
+func (c *Channel) Open(addr uint8) *i2c.I2C {
+	return i2c.OpenBus(c, addr)
+}
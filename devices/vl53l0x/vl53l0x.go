@@ -0,0 +1,571 @@
+// Package vl53l0x drives the STMicroelectronics VL53L0X time of flight
+// ranging sensor.
+//
+//	v, err := i2c.NewI2C(0x29, 1)
+//	...
+//	d, err := vl53l0x.New(v)
+//	...
+//	mm, err := d.Range()
+//
+// ST documents the chip through its API library rather than its
+// registers; the initialization and tuning sequences below follow the
+// register level port of that library by Pololu.
+//
+// All the sensors answer at 0x29 after power on. To use several on one
+// bus, hold all but one in reset with their XSHUT pins, move it to another
+// address with SetAddress, and repeat releasing the next one.
+package vl53l0x
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// Registers.
+const (
+	regSysrangeStart              = 0x00
+	regSystemSequenceConfig       = 0x01
+	regSystemIntermeasurement     = 0x04
+	regSystemInterruptConfigGPIO  = 0x0A
+	regSystemInterruptClear       = 0x0B
+	regResultInterruptStatus      = 0x13
+	regResultRange                = 0x1E
+	regFinalRangeMinCountRateRtn  = 0x44
+	regMsrcConfigTimeoutMacrop    = 0x46
+	regPreRangeVcselPeriod        = 0x50
+	regPreRangeTimeoutHi          = 0x51
+	regMsrcConfigControl          = 0x60
+	regFinalRangeVcselPeriod      = 0x70
+	regFinalRangeTimeoutHi        = 0x71
+	regGPIOHVMuxActiveHigh        = 0x84
+	regVHVConfigPadSCLSDAExtsupHV = 0x89
+	regI2CSlaveDeviceAddress      = 0x8A
+	regGlobalConfigSpadEnablesRef = 0xB0
+	regGlobalConfigRefEnStart     = 0xB6
+	regDynamicSpadNumRequested    = 0x4E
+	regDynamicSpadRefEnStart      = 0x4F
+	regIdentificationModelID      = 0xC0
+	regOscCalibrateVal            = 0xF8
+)
+
+// Sequence steps, bits of SYSTEM_SEQUENCE_CONFIG.
+const (
+	stepTCC        = 1 << 4
+	stepDSS        = 1 << 3
+	stepMSRC       = 1 << 2
+	stepPreRange   = 1 << 6
+	stepFinalRange = 1 << 7
+)
+
+// Timing budget overheads in µs.
+const (
+	startOverhead      = 1910
+	setStartOverhead   = 1320 // not the same as for reading the budget
+	endOverhead        = 960
+	msrcOverhead       = 660
+	tccOverhead        = 590
+	dssOverhead        = 690
+	preRangeOverhead   = 660
+	finalRangeOverhead = 550
+)
+
+// MinTimingBudget is the shortest measurement timing budget.
+const MinTimingBudget = 20 * time.Millisecond
+
+const (
+	modelID = 0xEE
+	// outOfRange is the range reported when no target was detected.
+	outOfRange = 8190
+)
+
+var (
+	// ErrChip is returned when the device does not identify as a VL53L0X.
+	ErrChip = errors.New("vl53l0x: unknown model id")
+	// ErrTimeout is returned when the sensor does not complete an
+	// operation in time.
+	ErrTimeout = errors.New("vl53l0x: timeout")
+	// ErrOutOfRange is returned when no target is in range.
+	ErrOutOfRange = errors.New("vl53l0x: out of range")
+)
+
+// Device is a VL53L0X.
+type Device struct {
+	v       *i2c.I2C
+	stop    byte // stop variable, from the data init
+	timeout time.Duration
+}
+
+// New returns the sensor talking through v, initialized for 2.8V I/O, as
+// on most breakout boards, with the default 33ms timing budget.
+func New(v *i2c.I2C) (*Device, error) {
+	id, err := v.ReadRegU8(regIdentificationModelID)
+	if err != nil {
+		return nil, err
+	}
+	if id != modelID {
+		return nil, fmt.Errorf("%w 0x%02X", ErrChip, id)
+	}
+	d := &Device{v: v, timeout: 500 * time.Millisecond}
+	if err := d.init(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// SetTimeout sets the maximum duration of the operations of the sensor,
+// 500ms by default.
+func (d *Device) SetTimeout(t time.Duration) {
+	d.timeout = t
+}
+
+// write writes the register value pairs of seq in order.
+func (d *Device) write(seq ...[2]byte) error {
+	for _, rv := range seq {
+		if err := d.v.WriteRegU8(rv[0], rv[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// poll reads reg until done returns true for its value.
+func (d *Device) poll(reg byte, done func(b byte) bool) error {
+	deadline := time.Now().Add(d.timeout)
+	for {
+		b, err := d.v.ReadRegU8(reg)
+		if err != nil {
+			return err
+		}
+		if done(b) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return ErrTimeout
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// init performs the data init, static init and reference calibration of
+// the ST library.
+func (d *Device) init() error {
+	// 2.8V I/O
+	if err := d.v.UpdateRegU8(regVHVConfigPadSCLSDAExtsupHV, 0x01, 0x01); err != nil {
+		return err
+	}
+	// standard i2c mode
+	if err := d.write([2]byte{0x88, 0x00}, [2]byte{0x80, 0x01}, [2]byte{0xFF, 0x01}, [2]byte{0x00, 0x00}); err != nil {
+		return err
+	}
+	stop, err := d.v.ReadRegU8(0x91)
+	if err != nil {
+		return err
+	}
+	d.stop = stop
+	if err := d.write([2]byte{0x00, 0x01}, [2]byte{0xFF, 0x00}, [2]byte{0x80, 0x00}); err != nil {
+		return err
+	}
+	// disable the MSRC and pre-range signal rate limit checks
+	if err := d.v.UpdateRegU8(regMsrcConfigControl, 0x12, 0x12); err != nil {
+		return err
+	}
+	if err := d.SetSignalRateLimit(0.25); err != nil {
+		return err
+	}
+	if err := d.v.WriteRegU8(regSystemSequenceConfig, 0xFF); err != nil {
+		return err
+	}
+	if err := d.initSpads(); err != nil {
+		return err
+	}
+	if err := d.write(tuning...); err != nil {
+		return err
+	}
+	// interrupt on new sample ready, active low
+	if err := d.v.WriteRegU8(regSystemInterruptConfigGPIO, 0x04); err != nil {
+		return err
+	}
+	if err := d.v.UpdateRegU8(regGPIOHVMuxActiveHigh, 0x10, 0); err != nil {
+		return err
+	}
+	if err := d.v.WriteRegU8(regSystemInterruptClear, 0x01); err != nil {
+		return err
+	}
+	budget, err := d.TimingBudget()
+	if err != nil {
+		return err
+	}
+	// disable the MSRC and TCC steps by default
+	if err := d.v.WriteRegU8(regSystemSequenceConfig, 0xE8); err != nil {
+		return err
+	}
+	// recalculate the timing budget with the steps left
+	if err := d.SetTimingBudget(budget); err != nil {
+		return err
+	}
+	// VHV and phase calibrations
+	if err := d.v.WriteRegU8(regSystemSequenceConfig, 0x01); err != nil {
+		return err
+	}
+	if err := d.refCalibration(0x40); err != nil {
+		return err
+	}
+	if err := d.v.WriteRegU8(regSystemSequenceConfig, 0x02); err != nil {
+		return err
+	}
+	if err := d.refCalibration(0x00); err != nil {
+		return err
+	}
+	return d.v.WriteRegU8(regSystemSequenceConfig, 0xE8)
+}
+
+// initSpads reads the reference SPAD count and type from the NVM and
+// enables them.
+func (d *Device) initSpads() error {
+	count, aperture, err := d.spadInfo()
+	if err != nil {
+		return err
+	}
+	var m [6]byte
+	if _, err := d.v.ReadRegBytesInto(regGlobalConfigSpadEnablesRef, m[:]); err != nil {
+		return err
+	}
+	err = d.write(
+		[2]byte{0xFF, 0x01},
+		[2]byte{regDynamicSpadRefEnStart, 0x00},
+		[2]byte{regDynamicSpadNumRequested, 0x2C},
+		[2]byte{0xFF, 0x00},
+		[2]byte{regGlobalConfigRefEnStart, 0xB4},
+	)
+	if err != nil {
+		return err
+	}
+	first := 0
+	if aperture {
+		// the aperture SPADs start at 12
+		first = 12
+	}
+	enabled := 0
+	for i := 0; i < 48; i++ {
+		bit := byte(1) << (i % 8)
+		if i < first || enabled == count {
+			m[i/8] &^= bit
+		} else if m[i/8]&bit != 0 {
+			enabled++
+		}
+	}
+	_, err = d.v.WriteRegBytes(regGlobalConfigSpadEnablesRef, m[:])
+	return err
+}
+
+// spadInfo returns the reference SPAD count and type.
+func (d *Device) spadInfo() (count int, aperture bool, err error) {
+	err = d.write([2]byte{0x80, 0x01}, [2]byte{0xFF, 0x01}, [2]byte{0x00, 0x00}, [2]byte{0xFF, 0x06})
+	if err != nil {
+		return 0, false, err
+	}
+	if err := d.v.UpdateRegU8(0x83, 0x04, 0x04); err != nil {
+		return 0, false, err
+	}
+	err = d.write([2]byte{0xFF, 0x07}, [2]byte{0x81, 0x01}, [2]byte{0x80, 0x01}, [2]byte{0x94, 0x6B}, [2]byte{0x83, 0x00})
+	if err != nil {
+		return 0, false, err
+	}
+	if err := d.poll(0x83, func(b byte) bool { return b != 0 }); err != nil {
+		return 0, false, err
+	}
+	if err := d.v.WriteRegU8(0x83, 0x01); err != nil {
+		return 0, false, err
+	}
+	b, err := d.v.ReadRegU8(0x92)
+	if err != nil {
+		return 0, false, err
+	}
+	if err := d.write([2]byte{0x81, 0x00}, [2]byte{0xFF, 0x06}); err != nil {
+		return 0, false, err
+	}
+	if err := d.v.UpdateRegU8(0x83, 0x04, 0); err != nil {
+		return 0, false, err
+	}
+	err = d.write([2]byte{0xFF, 0x01}, [2]byte{0x00, 0x01}, [2]byte{0xFF, 0x00}, [2]byte{0x80, 0x00})
+	return int(b & 0x7F), b&0x80 != 0, err
+}
+
+// refCalibration performs a single reference calibration.
+func (d *Device) refCalibration(vhvInit byte) error {
+	if err := d.v.WriteRegU8(regSysrangeStart, 0x01|vhvInit); err != nil {
+		return err
+	}
+	if err := d.poll(regResultInterruptStatus, func(b byte) bool { return b&0x07 != 0 }); err != nil {
+		return err
+	}
+	return d.write([2]byte{regSystemInterruptClear, 0x01}, [2]byte{regSysrangeStart, 0x00})
+}
+
+// tuning are the default tuning settings of the ST library.
+var tuning = [][2]byte{
+	{0xFF, 0x01}, {0x00, 0x00},
+	{0xFF, 0x00}, {0x09, 0x00}, {0x10, 0x00}, {0x11, 0x00},
+	{0x24, 0x01}, {0x25, 0xFF}, {0x75, 0x00},
+	{0xFF, 0x01}, {0x4E, 0x2C}, {0x48, 0x00}, {0x30, 0x20},
+	{0xFF, 0x00}, {0x30, 0x09}, {0x54, 0x00}, {0x31, 0x04},
+	{0x32, 0x03}, {0x40, 0x83}, {0x46, 0x25}, {0x60, 0x00},
+	{0x27, 0x00}, {0x50, 0x06}, {0x51, 0x00}, {0x52, 0x96},
+	{0x56, 0x08}, {0x57, 0x30}, {0x61, 0x00}, {0x62, 0x00},
+	{0x64, 0x00}, {0x65, 0x00}, {0x66, 0xA0},
+	{0xFF, 0x01}, {0x22, 0x32}, {0x47, 0x14}, {0x49, 0xFF},
+	{0x4A, 0x00},
+	{0xFF, 0x00}, {0x7A, 0x0A}, {0x7B, 0x00}, {0x78, 0x21},
+	{0xFF, 0x01}, {0x23, 0x34}, {0x42, 0x00}, {0x44, 0xFF},
+	{0x45, 0x26}, {0x46, 0x05}, {0x40, 0x40}, {0x0E, 0x06},
+	{0x20, 0x1A}, {0x43, 0x40},
+	{0xFF, 0x00}, {0x34, 0x03}, {0x35, 0x44},
+	{0xFF, 0x01}, {0x31, 0x04}, {0x4B, 0x09}, {0x4C, 0x05},
+	{0x4D, 0x04},
+	{0xFF, 0x00}, {0x44, 0x00}, {0x45, 0x20}, {0x47, 0x08},
+	{0x48, 0x28}, {0x67, 0x00}, {0x70, 0x04}, {0x71, 0x01},
+	{0x72, 0xFE}, {0x76, 0x00}, {0x77, 0x00},
+	{0xFF, 0x01}, {0x0D, 0x01},
+	{0xFF, 0x00}, {0x80, 0x01}, {0x01, 0xF8},
+	{0xFF, 0x01}, {0x8E, 0x01}, {0x00, 0x01},
+	{0xFF, 0x00}, {0x80, 0x00},
+}
+
+// SetSignalRateLimit sets the minimum return signal rate of a valid
+// measurement, in mega counts per second, 0.25 by default. Lower limits
+// extend the range at the cost of accuracy and false detections.
+func (d *Device) SetSignalRateLimit(mcps float64) error {
+	if mcps < 0 || mcps > 511.99 {
+		return errors.New("vl53l0x: signal rate limit out of range")
+	}
+	// Q9.7
+	return d.v.WriteRegU16BE(regFinalRangeMinCountRateRtn, uint16(mcps*(1<<7)))
+}
+
+// SetAddress moves the sensor to addr and retargets the connection.
+// The address is kept until the sensor is reset or powered off.
+func (d *Device) SetAddress(addr uint8) error {
+	if err := d.v.WriteRegU8(regI2CSlaveDeviceAddress, addr&0x7F); err != nil {
+		return err
+	}
+	return d.v.SetAddr(addr)
+}
+
+// steps are the enabled sequence steps and their timeouts.
+type steps struct {
+	config                  byte
+	preRangeVcsel           uint32 // PCLKs
+	finalRangeVcsel         uint32
+	msrcDssTcc              uint32 // µs
+	preRangeMclks, preRange uint32 // µs
+	finalRange              uint32 // µs
+}
+
+// decodeVcsel decodes a VCSEL pulse period register to PCLKs.
+func decodeVcsel(b byte) uint32 {
+	return (uint32(b) + 1) << 1
+}
+
+// macroPeriod returns the macro period in ns for a VCSEL period in PCLKs.
+func macroPeriod(vcsel uint32) uint32 {
+	return (2304*vcsel*1655 + 500) / 1000
+}
+
+func mclksToMicroseconds(mclks, vcsel uint32) uint32 {
+	mp := macroPeriod(vcsel)
+	return (mclks*mp + 500) / 1000
+}
+
+func microsecondsToMclks(us, vcsel uint32) uint32 {
+	mp := macroPeriod(vcsel)
+	return (us*1000 + mp/2) / mp
+}
+
+// decodeTimeout decodes a timeout register, LSB * 2^MSB + 1, to MCLKs.
+func decodeTimeout(r uint16) uint32 {
+	return uint32(r&0xFF)<<(r>>8) + 1
+}
+
+// encodeTimeout encodes a timeout in MCLKs to a register.
+func encodeTimeout(mclks uint32) uint16 {
+	if mclks == 0 {
+		return 0
+	}
+	ls, ms := mclks-1, uint16(0)
+	for ls&^0xFF != 0 {
+		ls >>= 1
+		ms++
+	}
+	return ms<<8 | uint16(ls)
+}
+
+// readSteps reads the enabled sequence steps and their timeouts.
+func (d *Device) readSteps() (steps, error) {
+	var s steps
+	var err error
+	read := func(reg byte) byte {
+		var b byte
+		if err == nil {
+			b, err = d.v.ReadRegU8(reg)
+		}
+		return b
+	}
+	read16 := func(reg byte) uint16 {
+		var w uint16
+		if err == nil {
+			w, err = d.v.ReadRegU16BE(reg)
+		}
+		return w
+	}
+	s.config = read(regSystemSequenceConfig)
+	s.preRangeVcsel = decodeVcsel(read(regPreRangeVcselPeriod))
+	s.msrcDssTcc = mclksToMicroseconds(uint32(read(regMsrcConfigTimeoutMacrop))+1, s.preRangeVcsel)
+	s.preRangeMclks = decodeTimeout(read16(regPreRangeTimeoutHi))
+	s.preRange = mclksToMicroseconds(s.preRangeMclks, s.preRangeVcsel)
+	s.finalRangeVcsel = decodeVcsel(read(regFinalRangeVcselPeriod))
+	final := decodeTimeout(read16(regFinalRangeTimeoutHi))
+	if s.config&stepPreRange != 0 {
+		final -= s.preRangeMclks
+	}
+	s.finalRange = mclksToMicroseconds(final, s.finalRangeVcsel)
+	return s, err
+}
+
+// overhead returns the duration of the steps of s but the final range,
+// starting from start µs.
+func (s *steps) overhead(start uint32) uint32 {
+	us := start + endOverhead
+	if s.config&stepTCC != 0 {
+		us += s.msrcDssTcc + tccOverhead
+	}
+	if s.config&stepDSS != 0 {
+		us += 2 * (s.msrcDssTcc + dssOverhead)
+	} else if s.config&stepMSRC != 0 {
+		us += s.msrcDssTcc + msrcOverhead
+	}
+	if s.config&stepPreRange != 0 {
+		us += s.preRange + preRangeOverhead
+	}
+	return us
+}
+
+// TimingBudget returns the measurement timing budget, the duration of a
+// measurement.
+func (d *Device) TimingBudget() (time.Duration, error) {
+	s, err := d.readSteps()
+	if err != nil {
+		return 0, err
+	}
+	us := s.overhead(startOverhead)
+	if s.config&stepFinalRange != 0 {
+		us += s.finalRange + finalRangeOverhead
+	}
+	return time.Duration(us) * time.Microsecond, nil
+}
+
+// SetTimingBudget sets the measurement timing budget, at least
+// MinTimingBudget. Longer budgets give more accurate measurements: e.g.
+// 200ms for high accuracy, 20ms for high speed.
+func (d *Device) SetTimingBudget(budget time.Duration) error {
+	if budget < MinTimingBudget {
+		return fmt.Errorf("vl53l0x: timing budget %v below %v", budget, MinTimingBudget)
+	}
+	s, err := d.readSteps()
+	if err != nil {
+		return err
+	}
+	if s.config&stepFinalRange == 0 {
+		return nil
+	}
+	used := s.overhead(setStartOverhead) + finalRangeOverhead
+	b := uint32(budget / time.Microsecond)
+	if used > b {
+		return fmt.Errorf("vl53l0x: timing budget %v too short for the enabled steps", budget)
+	}
+	mclks := microsecondsToMclks(b-used, s.finalRangeVcsel)
+	if s.config&stepPreRange != 0 {
+		mclks += s.preRangeMclks
+	}
+	return d.v.WriteRegU16BE(regFinalRangeTimeoutHi, encodeTimeout(mclks))
+}
+
+// restoreStop writes the stop variable read at init, before starting
+// measurements.
+func (d *Device) restoreStop() error {
+	return d.write(
+		[2]byte{0x80, 0x01}, [2]byte{0xFF, 0x01}, [2]byte{0x00, 0x00},
+		[2]byte{0x91, d.stop},
+		[2]byte{0x00, 0x01}, [2]byte{0xFF, 0x00}, [2]byte{0x80, 0x00},
+	)
+}
+
+// Range performs a single measurement and returns the distance in
+// millimeters. It returns ErrOutOfRange when no target was detected.
+func (d *Device) Range() (int, error) {
+	if err := d.restoreStop(); err != nil {
+		return 0, err
+	}
+	if err := d.v.WriteRegU8(regSysrangeStart, 0x01); err != nil {
+		return 0, err
+	}
+	if err := d.poll(regSysrangeStart, func(b byte) bool { return b&0x01 == 0 }); err != nil {
+		return 0, err
+	}
+	return d.Next()
+}
+
+// StartContinuous starts measuring continuously, back to back when
+// period is zero, otherwise every period. The measurements are read with
+// Next.
+func (d *Device) StartContinuous(period time.Duration) error {
+	if err := d.restoreStop(); err != nil {
+		return err
+	}
+	if period == 0 {
+		return d.v.WriteRegU8(regSysrangeStart, 0x02)
+	}
+	ms := uint32(period / time.Millisecond)
+	osc, err := d.v.ReadRegU16BE(regOscCalibrateVal)
+	if err != nil {
+		return err
+	}
+	if osc != 0 {
+		ms *= uint32(osc)
+	}
+	if _, err := d.v.WriteRegBytes(regSystemIntermeasurement, []byte{byte(ms >> 24), byte(ms >> 16), byte(ms >> 8), byte(ms)}); err != nil {
+		return err
+	}
+	return d.v.WriteRegU8(regSysrangeStart, 0x04)
+}
+
+// StopContinuous stops continuous measurements.
+func (d *Device) StopContinuous() error {
+	return d.write(
+		[2]byte{regSysrangeStart, 0x01},
+		[2]byte{0xFF, 0x01}, [2]byte{0x00, 0x00}, [2]byte{0x91, 0x00},
+		[2]byte{0x00, 0x01}, [2]byte{0xFF, 0x00},
+	)
+}
+
+// Next waits for the next continuous measurement and returns the
+// distance in millimeters. It returns ErrOutOfRange when no target was
+// detected.
+func (d *Device) Next() (int, error) {
+	if err := d.poll(regResultInterruptStatus, func(b byte) bool { return b&0x07 != 0 }); err != nil {
+		return 0, err
+	}
+	mm, err := d.v.ReadRegU16BE(regResultRange)
+	if err != nil {
+		return 0, err
+	}
+	if err := d.v.WriteRegU8(regSystemInterruptClear, 0x01); err != nil {
+		return 0, err
+	}
+	if mm >= outOfRange {
+		return 0, ErrOutOfRange
+	}
+	return int(mm), nil
+}
@@ -0,0 +1,64 @@
+package i2c
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrBusNotFound is returned when no bus matches a name.
+var ErrBusNotFound = errors.New("i2c: bus not found")
+
+const (
+	sysBusDevices = "/sys/bus/i2c/devices"
+	dtBase        = "/sys/firmware/devicetree/base"
+)
+
+// ResolveBus returns the number of the bus named name, so that
+// configurations can name buses stably while the numbering shifts with
+// device-tree overlays. name is one of
+//
+//	"1", "i2c-1"         a bus number
+//	"i2c1"               a device-tree alias
+//	"/soc/i2c@7e804000"  a device-tree node path
+//
+// Aliases and node paths are matched against the of_node of the adapters
+// in /sys/bus/i2c/devices.
+func ResolveBus(name string) (int, error) {
+	if n, err := strconv.Atoi(name); err == nil && n >= 0 {
+		return n, nil
+	}
+	if n, ok := busNumber(name); ok {
+		return n, nil
+	}
+	node := name
+	if !strings.HasPrefix(name, "/") {
+		b, err := os.ReadFile(filepath.Join(dtBase, "aliases", name))
+		if err != nil {
+			return -1, fmt.Errorf("%w: %q: %v", ErrBusNotFound, name, err)
+		}
+		node = strings.TrimRight(string(b), "\x00\n")
+	}
+	want, err := filepath.EvalSymlinks(filepath.Join(dtBase, node))
+	if err != nil {
+		return -1, fmt.Errorf("%w: %q: %v", ErrBusNotFound, name, err)
+	}
+	ents, err := os.ReadDir(sysBusDevices)
+	if err != nil {
+		return -1, err
+	}
+	for _, e := range ents {
+		n, ok := busNumber(e.Name())
+		if !ok {
+			continue
+		}
+		of, err := filepath.EvalSymlinks(filepath.Join(sysBusDevices, e.Name(), "of_node"))
+		if err == nil && of == want {
+			return n, nil
+		}
+	}
+	return -1, fmt.Errorf("%w: %q", ErrBusNotFound, name)
+}
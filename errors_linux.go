@@ -0,0 +1,19 @@
+//go:build !baremetal
+
+package i2c
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// IsNack reports whether err is the device not acknowledging a transfer.
+// Depending on the adapter driver this surfaces as ENXIO, EREMOTEIO or EIO.
+func IsNack(err error) bool {
+	var errno unix.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	return errno == unix.ENXIO || errno == unix.EREMOTEIO || errno == unix.EIO
+}
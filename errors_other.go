@@ -0,0 +1,9 @@
+//go:build !linux || baremetal
+
+package i2c
+
+// IsNack reports whether err is the device not acknowledging a transfer.
+// It always returns false outside of linux.
+func IsNack(err error) bool {
+	return false
+}
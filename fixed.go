@@ -0,0 +1,60 @@
+package i2c
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrQRange is returned when a value cannot be represented in a fixed
+// point format.
+var ErrQRange = errors.New("i2c: value out of fixed point range")
+
+// Q is a fixed point format with M integer and N fractional bits, M+N
+// bits wide at most 64. For signed formats the sign bit is counted in M,
+// as in the datasheets: Q8.8 and Q2.14 are both 16 bits wide.
+type Q struct {
+	M, N     uint
+	Unsigned bool
+}
+
+func (q Q) String() string {
+	s := fmt.Sprintf("Q%d.%d", q.M, q.N)
+	if q.Unsigned {
+		s = "U" + s
+	}
+	return s
+}
+
+// Float decodes the low M+N bits of raw.
+func (q Q) Float(raw uint64) float64 {
+	bits := q.M + q.N
+	var x float64
+	if q.Unsigned {
+		x = float64(ZeroExtend(raw, bits, 0))
+	} else {
+		x = float64(SignExtend(raw, bits, 0))
+	}
+	return math.Ldexp(x, -int(q.N))
+}
+
+// Raw encodes f, rounded to the nearest representable value, in the low
+// M+N bits of the result.
+func (q Q) Raw(f float64) (uint64, error) {
+	bits := q.M + q.N
+	x := math.Round(math.Ldexp(f, int(q.N)))
+	lo, hi := -math.Ldexp(1, int(bits)-1), math.Ldexp(1, int(bits)-1)
+	if q.Unsigned {
+		lo, hi = 0, math.Ldexp(1, int(bits))
+	}
+	if math.IsNaN(x) || x < lo || x >= hi {
+		return 0, fmt.Errorf("%w: %v in %v", ErrQRange, f, q)
+	}
+	var raw uint64
+	if x < 0 {
+		raw = uint64(int64(x))
+	} else {
+		raw = uint64(x)
+	}
+	return ZeroExtend(raw, bits, 0), nil
+}
@@ -0,0 +1,56 @@
+package i2c
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQ(t *testing.T) {
+	q88, q214 := Q{M: 8, N: 8}, Q{M: 2, N: 14}
+	uq88, uq016 := Q{M: 8, N: 8, Unsigned: true}, Q{M: 0, N: 16, Unsigned: true}
+	for _, c := range []struct {
+		q   Q
+		f   float64
+		raw uint64
+	}{
+		{q88, 1, 0x0100},
+		{q88, -1, 0xFF00},
+		{q88, 127.99609375, 0x7FFF},
+		{q88, -128, 0x8000},
+		{q88, 0.00390625, 0x0001},
+		{q214, 1, 0x4000},
+		{q214, -2, 0x8000},
+		{q214, 1.99993896484375, 0x7FFF},
+		{q214, -0.00006103515625, 0xFFFF},
+		{uq88, 255.99609375, 0xFFFF},
+		{uq88, 128, 0x8000},
+		{uq88, 0, 0x0000},
+		{uq016, 0.5, 0x8000},
+		{uq016, 0.9999847412109375, 0xFFFF},
+	} {
+		if got := c.q.Float(c.raw); got != c.f {
+			t.Errorf("%v.Float(0x%04X) = %v, want %v", c.q, c.raw, got, c.f)
+		}
+		raw, err := c.q.Raw(c.f)
+		if err != nil || raw != c.raw {
+			t.Errorf("%v.Raw(%v) = 0x%04X, %v, want 0x%04X", c.q, c.f, raw, err, c.raw)
+		}
+	}
+}
+
+func TestQRange(t *testing.T) {
+	for _, c := range []struct {
+		q Q
+		f float64
+	}{
+		{Q{M: 8, N: 8}, 128},
+		{Q{M: 8, N: 8}, -128.00390625},
+		{Q{M: 2, N: 14}, 2},
+		{Q{M: 8, N: 8, Unsigned: true}, 256},
+		{Q{M: 8, N: 8, Unsigned: true}, -0.00390625},
+	} {
+		if _, err := c.q.Raw(c.f); !errors.Is(err, ErrQRange) {
+			t.Errorf("%v.Raw(%v): got %v, want ErrQRange", c.q, c.f, err)
+		}
+	}
+}
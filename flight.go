@@ -0,0 +1,61 @@
+package i2c
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrQueueFull is returned when an operation cannot be queued because the
+// device has reached its limit of outstanding operations.
+var ErrQueueFull = errors.New("i2c: device operation queue full")
+
+// flight caps the count of operations outstanding on a device.
+type flight struct {
+	mu      sync.Mutex
+	cond    sync.Cond
+	max     int
+	queue   int
+	active  int
+	waiting int
+}
+
+func (f *flight) acquire() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.max > 0 && f.active >= f.max {
+		if f.waiting >= f.queue {
+			return ErrQueueFull
+		}
+		f.waiting++
+		for f.max > 0 && f.active >= f.max {
+			f.cond.Wait()
+		}
+		f.waiting--
+	}
+	f.active++
+	return nil
+}
+
+func (f *flight) release() {
+	f.mu.Lock()
+	f.active--
+	f.mu.Unlock()
+	f.cond.Signal()
+}
+
+// SetConcurrencyLimit caps to inFlight the operations outstanding at the
+// same time on the device, across all the connections to it, for chips
+// misbehaving when a command arrives before the previous one completes.
+// Up to queue further operations wait for their turn, beyond that they
+// fail with ErrQueueFull. An inFlight of 0 removes the limit.
+func (v *I2C) SetConcurrencyLimit(inFlight, queue int) {
+	d := v.dev.Load()
+	if d == nil {
+		return
+	}
+	f := &d.flight
+	f.mu.Lock()
+	f.max, f.queue = inFlight, queue
+	f.mu.Unlock()
+	f.cond.Broadcast()
+}
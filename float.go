@@ -0,0 +1,22 @@
+package i2c
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// ReadRegFloat32 read IEEE-754 single precision value stored with the
+// given byte order from i2c device starting from address specified in reg.
+func (v *I2C) ReadRegFloat32(reg byte, order binary.ByteOrder) (float32, error) {
+	w, err := v.ReadRegU32(reg, order)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float32frombits(w), nil
+}
+
+// WriteRegFloat32 write IEEE-754 single precision value with the given
+// byte order to i2c device starting from address specified in reg.
+func (v *I2C) WriteRegFloat32(reg byte, value float32, order binary.ByteOrder) error {
+	return v.WriteRegU32(reg, math.Float32bits(value), order)
+}
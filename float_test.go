@@ -0,0 +1,43 @@
+package i2c
+
+import (
+	"encoding/binary"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestRegFloat32(t *testing.T) {
+	for _, c := range []struct {
+		f    float32
+		bits uint32
+	}{
+		{1, 0x3F800000},
+		{-2, 0xC0000000},
+		{0.15625, 0x3E200000},
+		{float32(math.Inf(1)), 0x7F800000},
+		{math.SmallestNonzeroFloat32, 0x00000001},
+	} {
+		for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+			var b [4]byte
+			order.PutUint32(b[:], c.bits)
+			d := NewDryRun(map[byte]byte{0x10: b[0], 0x11: b[1], 0x12: b[2], 0x13: b[3]})
+			v := NewI2CConn(d, 0x40)
+			f, err := v.ReadRegFloat32(0x10, order)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f != c.f {
+				t.Errorf("read 0x%08X %v: got %v, want %v", c.bits, order, f, c.f)
+			}
+			if err := v.WriteRegFloat32(0x20, c.f, order); err != nil {
+				t.Fatal(err)
+			}
+			writes := d.Writes()
+			if got, want := writes[len(writes)-1], append([]byte{0x20}, b[:]...); !slices.Equal(got, want) {
+				t.Errorf("write %v %v: got % X, want % X", c.f, order, got, want)
+			}
+			v.Close()
+		}
+	}
+}
@@ -0,0 +1,68 @@
+//go:build !baremetal
+
+package i2c
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// procLock is an advisory lock shared with other processes.
+type procLock struct {
+	fd int
+	f  *os.File // lock file, nil when locking the bus node
+}
+
+func (l *procLock) lock() error {
+	for {
+		err := unix.Flock(l.fd, unix.LOCK_EX)
+		if err != unix.EINTR {
+			return err
+		}
+	}
+}
+
+func (l *procLock) unlock() {
+	unix.Flock(l.fd, unix.LOCK_UN)
+}
+
+func (l *procLock) close() {
+	if l.f != nil {
+		l.f.Close()
+	}
+}
+
+// SetProcessLock enables or disables advisory locking (flock) around each
+// operation, so that independent processes using this package on the
+// same bus do not interleave their transactions. The lock is taken on the
+// file at path, created if missing, or on the bus device node when path
+// is empty. Tools not taking the lock, such as i2c-tools, are not kept
+// out.
+func (v *I2C) SetProcessLock(on bool, path string) error {
+	var l *procLock
+	if on {
+		if path == "" {
+			err := v.control(func(fd uintptr) error {
+				l = &procLock{fd: int(fd)}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		} else {
+			f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+			if err != nil {
+				return err
+			}
+			l = &procLock{fd: int(f.Fd()), f: f}
+		}
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.plock != nil {
+		v.plock.close()
+	}
+	v.plock = l
+	return nil
+}
@@ -0,0 +1,22 @@
+//go:build !linux || baremetal
+
+package i2c
+
+type procLock struct{}
+
+func (l *procLock) lock() error {
+	return ErrUnsupported
+}
+
+func (l *procLock) unlock() {}
+
+func (l *procLock) close() {}
+
+// SetProcessLock enables or disables advisory locking around each
+// operation. It is only supported on linux.
+func (v *I2C) SetProcessLock(on bool, path string) error {
+	if on {
+		return ErrUnsupported
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+// Package ftdi implements an i2c bus master on the MPSSE engine of FTDI
+// USB chips (FT232H, FT2232H, FT4232H), so that drivers built on package
+// i2c run from a desktop through a USB cable.
+//
+// The package speaks the MPSSE command set over a Device, the raw byte
+// channel to the chip in MPSSE mode opened by a USB library (e.g. D2XX,
+// libftdi or gousb), with the modem status bytes stripped. Wiring follows
+// FTDI AN_255: AD0 is SCL, AD1 and AD2 are tied together as SDA, both
+// with pull-up resistors.
+//
+//	bus, err := ftdi.NewBus(dev, ftdi.Config{Freq: 400000})
+//	if err != nil {
+//		return err
+//	}
+//	v := bus.Open(0x76)
+package ftdi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"syscall"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+var (
+	// ErrNack is returned when the device does not acknowledge its
+	// address or a written byte. On Linux i2c.IsNack reports true for it.
+	ErrNack error = syscall.ENXIO
+	// ErrSync is returned when the chip does not answer like an MPSSE.
+	ErrSync = errors.New("ftdi: mpsse synchronization failed")
+)
+
+// Device is the byte channel to an FTDI chip in MPSSE mode.
+type Device interface {
+	io.ReadWriter
+}
+
+// MPSSE commands, from FTDI AN_108.
+const (
+	cmdBytesOutFall  = 0x11 // clock bytes out on falling edge, MSB first
+	cmdBitsOutFall   = 0x13 // clock bits out on falling edge, MSB first
+	cmdBytesInRise   = 0x20 // clock bytes in on rising edge, MSB first
+	cmdBitsInRise    = 0x22 // clock bits in on rising edge, MSB first
+	cmdSetLow        = 0x80 // set value and direction of AD0-AD7
+	cmdLoopbackOff   = 0x85
+	cmdClockDivisor  = 0x86
+	cmdSendImmediate = 0x87
+	cmdDiv5Off       = 0x8A
+	cmdThreePhaseOn  = 0x8C
+	cmdAdaptiveOff   = 0x97
+	cmdDriveZero     = 0x9E // open drain outputs, FT232H only
+	cmdBogus         = 0xAA
+)
+
+// AD pins.
+const (
+	pinSCL = 1 << 0
+	pinSDA = 1 << 1
+)
+
+// Config configures a Bus.
+type Config struct {
+	// Freq is the clock frequency in Hz, 100kHz when zero.
+	Freq int
+	// DriveZero enables the open drain outputs of the FT232H. Without it
+	// SDA is released by switching it to input.
+	DriveZero bool
+}
+
+// Bus is an i2c bus mastered by an FTDI MPSSE engine.
+type Bus struct {
+	mu        sync.Mutex
+	dev       Device
+	driveZero bool
+	cmd       []byte
+}
+
+// NewBus configures dev as an i2c master.
+func NewBus(dev Device, cfg Config) (*Bus, error) {
+	if cfg.Freq <= 0 {
+		cfg.Freq = 100000
+	}
+	b := &Bus{dev: dev, driveZero: cfg.DriveZero}
+	if err := b.sync(); err != nil {
+		return nil, err
+	}
+	// 60MHz base clock, three phase clocking for i2c data hold time: the
+	// clock is 60MHz / ((1 + div) * 2) * 2 / 3
+	div := max(60000000/(3*cfg.Freq)-1, 0)
+	b.cmd = append(b.cmd[:0], cmdDiv5Off, cmdAdaptiveOff, cmdThreePhaseOn,
+		cmdClockDivisor, byte(div), byte(div>>8), cmdLoopbackOff)
+	if b.driveZero {
+		b.cmd = append(b.cmd, cmdDriveZero, pinSCL|pinSDA, 0)
+	}
+	b.pins(true, true)
+	return b, b.flush()
+}
+
+// sync checks that the chip is in MPSSE mode, which answers a bogus
+// command with 0xFA followed by the command.
+func (b *Bus) sync() error {
+	if _, err := b.dev.Write([]byte{cmdBogus, cmdSendImmediate}); err != nil {
+		return err
+	}
+	var r [2]byte
+	if _, err := io.ReadFull(b.dev, r[:]); err != nil {
+		return err
+	}
+	if r != [2]byte{0xFA, cmdBogus} {
+		return fmt.Errorf("%w: got % x", ErrSync, r)
+	}
+	return nil
+}
+
+// pins queues setting SCL and SDA, a high line is released.
+func (b *Bus) pins(scl, sda bool) {
+	var val, dir byte = 0, pinSCL | pinSDA
+	if scl {
+		val |= pinSCL
+	}
+	if sda {
+		val |= pinSDA
+		if !b.driveZero {
+			dir &^= pinSDA
+		}
+	}
+	// repeat to hold the state for at least 600ns
+	for i := 0; i < 4; i++ {
+		b.cmd = append(b.cmd, cmdSetLow, val, dir)
+	}
+}
+
+// flush sends the queued commands.
+func (b *Bus) flush() error {
+	_, err := b.dev.Write(b.cmd)
+	b.cmd = b.cmd[:0]
+	return err
+}
+
+func (b *Bus) start() {
+	b.pins(true, true)
+	b.pins(true, false)
+	b.pins(false, false)
+}
+
+func (b *Bus) stop() {
+	b.pins(false, false)
+	b.pins(true, false)
+	b.pins(true, true)
+}
+
+// writeByte sends c and reports whether the device acknowledged it.
+func (b *Bus) writeByte(c byte) (bool, error) {
+	b.cmd = append(b.cmd, cmdBytesOutFall, 0, 0, c)
+	// release SDA and clock in the acknowledge bit
+	b.pins(false, true)
+	b.cmd = append(b.cmd, cmdBitsInRise, 0, cmdSendImmediate)
+	if err := b.flush(); err != nil {
+		return false, err
+	}
+	var r [1]byte
+	if _, err := io.ReadFull(b.dev, r[:]); err != nil {
+		return false, err
+	}
+	b.pins(false, false)
+	return r[0]&1 == 0, nil
+}
+
+// readBytes receives p, acknowledging every byte but the last.
+func (b *Bus) readBytes(p []byte) error {
+	for i := range p {
+		b.pins(false, true)
+		b.cmd = append(b.cmd, cmdBytesInRise, 0, 0)
+		ack := byte(0x00)
+		if i == len(p)-1 {
+			ack = 0x80
+		}
+		b.pins(false, ack == 0x80)
+		b.cmd = append(b.cmd, cmdBitsOutFall, 0, ack)
+	}
+	b.cmd = append(b.cmd, cmdSendImmediate)
+	if err := b.flush(); err != nil {
+		return err
+	}
+	_, err := io.ReadFull(b.dev, p)
+	return err
+}
+
+// transfer runs fn between a start and a stop condition, after
+// addressing the device.
+func (b *Bus) transfer(addr byte, fn func() (int, error)) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cmd = b.cmd[:0]
+	b.start()
+	n := 0
+	ack, err := b.writeByte(addr)
+	if err == nil && !ack {
+		err = ErrNack
+	}
+	if err == nil {
+		n, err = fn()
+	}
+	b.stop()
+	if ferr := b.flush(); err == nil {
+		err = ferr
+	}
+	return n, err
+}
+
+// Conn returns a transport to addr on the bus, for i2c.NewI2CConn.
+func (b *Bus) Conn(addr uint8) *Conn {
+	return &Conn{bus: b, addr: addr}
+}
+
+// Open returns a connection to the device at addr on the bus.
+func (b *Bus) Open(addr uint8) *i2c.I2C {
+	return i2c.NewI2CConn(b.Conn(addr), addr)
+}
+
+// Conn is an i2c.Conn to an address of a Bus. It implements
+// i2c.Addresser, so connections can be retargeted with SetAddr.
+type Conn struct {
+	bus  *Bus
+	mu   sync.Mutex
+	addr uint8
+}
+
+func (c *Conn) target() uint8 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.addr
+}
+
+// Write sends p to the device in one transfer.
+func (c *Conn) Write(p []byte) (int, error) {
+	return c.bus.transfer(c.target()<<1, func() (int, error) {
+		for i, x := range p {
+			ack, err := c.bus.writeByte(x)
+			if err != nil {
+				return i, err
+			}
+			if !ack {
+				return i, ErrNack
+			}
+		}
+		return len(p), nil
+	})
+}
+
+// Read receives p from the device in one transfer.
+func (c *Conn) Read(p []byte) (int, error) {
+	return c.bus.transfer(c.target()<<1|1, func() (int, error) {
+		if err := c.bus.readBytes(p); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	})
+}
+
+// SetAddr retargets the connection to addr.
+func (c *Conn) SetAddr(addr uint8) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addr = addr
+	return nil
+}
+
+// Close does nothing, the Device is owned by the caller.
+func (c *Conn) Close() error {
+	return nil
+}
@@ -0,0 +1,28 @@
+package i2c
+
+import (
+	"encoding/binary"
+	"unsafe"
+)
+
+// Integer is the set of fixed width integer types usable with ReadReg and
+// WriteReg. The width of the type sets the count of register bytes.
+type Integer interface {
+	~int8 | ~int16 | ~int32 | ~int64 | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// ReadReg read a T stored with the given byte order from i2c device
+// starting from address specified in reg, e.g.
+//
+//	t, err := i2c.ReadReg[int16](v, 0x00, binary.BigEndian)
+func ReadReg[T Integer](v *I2C, reg byte, order binary.ByteOrder) (T, error) {
+	var x T
+	w, err := v.readRegOrder(reg, int(unsafe.Sizeof(x)), order)
+	return T(w), err
+}
+
+// WriteReg write value with the given byte order to i2c device starting
+// from address specified in reg.
+func WriteReg[T Integer](v *I2C, reg byte, value T, order binary.ByteOrder) error {
+	return v.writeRegOrder(reg, uint64(value), int(unsafe.Sizeof(value)), order)
+}
@@ -0,0 +1,8 @@
+module github.com/fedeonline/i2c-go
+
+go 1.25.0
+
+require (
+	golang.org/x/sys v0.47.0
+	golang.org/x/term v0.33.0
+)
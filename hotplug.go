@@ -0,0 +1,20 @@
+package i2c
+
+import "context"
+
+// BusEvent reports a bus node appearing or disappearing, e.g. when a USB
+// to i2c adapter is plugged or unplugged.
+type BusEvent struct {
+	Bus   int
+	Added bool
+}
+
+// WatchBuses reports the /dev/i2c-N nodes created and removed until ctx
+// is done, when the returned channel is closed, so that long running
+// services can attach and detach devices as adapters come and go. Nodes
+// existing when the watch starts are not reported, see ListBuses. udev
+// may adjust the node permissions shortly after its creation, so opening
+// it can fail transiently with a permission error.
+func WatchBuses(ctx context.Context) (<-chan BusEvent, error) {
+	return watchBuses(ctx)
+}
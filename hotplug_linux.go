@@ -0,0 +1,54 @@
+//go:build !baremetal
+
+package i2c
+
+import (
+	"context"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+func watchBuses(ctx context.Context) (<-chan BusEvent, error) {
+	fd, err := unix.InotifyInit1(unix.IN_NONBLOCK | unix.IN_CLOEXEC)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	if _, err := unix.InotifyAddWatch(fd, "/dev", unix.IN_CREATE|unix.IN_DELETE); err != nil {
+		unix.Close(fd)
+		return nil, os.NewSyscallError("inotify_add_watch", err)
+	}
+	// a non blocking descriptor uses the runtime poller, so Close
+	// interrupts a pending Read
+	f := os.NewFile(uintptr(fd), "inotify")
+	stop := context.AfterFunc(ctx, func() { f.Close() })
+	ch := make(chan BusEvent)
+	go func() {
+		defer close(ch)
+		defer stop()
+		defer f.Close()
+		buf := make([]byte, 4096)
+		for {
+			n, err := f.Read(buf)
+			if err != nil {
+				return
+			}
+			for off := 0; off+unix.SizeofInotifyEvent <= n; {
+				ev := (*unix.InotifyEvent)(unsafe.Pointer(&buf[off]))
+				name := buf[off+unix.SizeofInotifyEvent : off+unix.SizeofInotifyEvent+int(ev.Len)]
+				off += unix.SizeofInotifyEvent + int(ev.Len)
+				bus, ok := busNumber(unix.ByteSliceToString(name))
+				if !ok || ev.Mask&unix.IN_ISDIR != 0 {
+					continue
+				}
+				select {
+				case ch <- BusEvent{Bus: bus, Added: ev.Mask&unix.IN_CREATE != 0}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux || baremetal
+
+package i2c
+
+import "context"
+
+// watchBuses is only supported on Linux.
+func watchBuses(ctx context.Context) (<-chan BusEvent, error) {
+	return nil, ErrUnsupported
+}
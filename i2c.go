@@ -2,199 +2,396 @@
 //
 // Each i2c bus can address 127 independent i2c devices, and most
 // linux systems contain several buses.
+//
+// The package builds on every platform, but outside of linux the
+// functions opening a bus return ErrUnsupported. On microcontrollers,
+// build with TinyGo and open connections with OpenBus on a machine.I2C:
+// the Linux specific files are excluded by the baremetal build tag.
+//
+// Connections are safe for concurrent use. Every operation, be it a raw
+// read or write or a register access made of a register pointer write
+// followed by a read, runs under a lock shared by all the connections
+// to the same bus, so operations issued by different goroutines never
+// interleave on the wire. Sequences of operations are not atomic: use
+// the read-modify-write helpers or synchronize them in the caller.
+//
+// Version 2 of the API, package github.com/fedeonline/i2c-go/v2, separates
+// buses from the devices on them and replaces the fixed width register
+// helpers, such as ReadRegU16BE, by generic ones. It is implemented on top
+// of this package, which stays supported: new code should prefer version
+// 2, existing code may migrate incrementally.
 package i2c
 
 import (
+	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
-	"os"
+	"sync"
+	"sync/atomic"
 	"syscall"
 )
 
-const (
-	i2cSlave = 0x0703
-)
+// ErrUnsupported is returned when the requested operation is not
+// available on the running platform. It matches errors.ErrUnsupported.
+var ErrUnsupported = fmt.Errorf("i2c: %w", errors.ErrUnsupported)
+
+// scratchSize is the size of the per connection buffer used by the
+// register helpers to avoid allocations: a register address, dummy bytes
+// and a block of up to 32 bytes.
+const scratchSize = 40
 
 // I2C represents a connection to an i2c device.
 type I2C struct {
-	rc *os.File
-}
-
-// NewI2C opens a connection to an i2c device.
-func NewI2C(addr uint8, bus int) (*I2C, error) {
-	f, err := os.OpenFile(fmt.Sprintf("/dev/i2c-%d", bus), os.O_RDWR, 0600)
-	if err != nil {
-		return nil, err
-	}
-	if err := ioctl(f.Fd(), i2cSlave, uintptr(addr)); err != nil {
-		return nil, err
-	}
-	v := &I2C{rc: f}
-	return v, nil
-}
-
-func (v *I2C) write(buf []byte) (int, error) {
-	return v.rc.Write(buf)
+	rc      Conn
+	bus     int
+	addr    uint8
+	mu      *sync.Mutex // bus lock, guards the fields below
+	tx      Transaction
+	ctx     context.Context // context of the running operation
+	try     int             // attempt of the running operation
+	regBuf  [1]byte
+	msgs    [2]Msg // messages of the combined register reads and Tx
+	scratch [scratchSize]byte
+	plock   *procLock
+	mw      middlewares
+	dev     atomic.Pointer[device]
+	devs    map[uint8]*device // states by address, on bus -1
+	retry   atomic.Pointer[RetryPolicy]
+	verify  atomic.Bool
+	quirks  atomic.Pointer[Quirks]
+	cache   regCache
+	open    *openConn
+	shape   shaping
+	stats   stats
+	turn    atomic.Int64
+	rebind  func() error
 }
 
 // WriteBytes sends buf to the remote i2c device. The interpretation of
 // the message is implementation dependant.
-func (v *I2C) WriteBytes(buf []byte) (int, error) {
-	return v.write(buf)
-}
-
-func (v *I2C) read(buf []byte) (int, error) {
-	return v.rc.Read(buf)
+func (v *I2C) WriteBytes(buf []byte) (n int, err error) {
+	err = v.do(func() error {
+		n, err = v.xfer(OpWrite, NoReg, buf)
+		return err
+	})
+	return n, err
 }
 
 // ReadBytes read buf from the remote i2c device. The interpretation of
 // the message is implementation dependant.
-func (v *I2C) ReadBytes(buf []byte) (int, error) {
-	n, err := v.read(buf)
+func (v *I2C) ReadBytes(buf []byte) (n int, err error) {
+	if err := v.Ready(); err != nil {
+		return 0, err
+	}
+	err = v.do(func() error {
+		n, err = v.xfer(OpRead, NoReg, buf)
+		return err
+	})
 	return n, err
 }
 
 // Close close a connection to an i2c device.
 func (v *I2C) Close() error {
+	unregisterAll(v)
+	v.mu.Lock()
+	if v.plock != nil {
+		v.plock.close()
+		v.plock = nil
+	}
+	rebind := v.rebind
+	v.rebind = nil
+	v.mu.Unlock()
+	err := v.closeConn()
+	if rebind != nil {
+		if rerr := rebind(); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+// closeConn closes the underlying connection.
+func (v *I2C) closeConn() error {
+	if v.open != nil {
+		v.open.cleanup.Stop()
+		if !untrack(v.open) {
+			// already closed
+			return nil
+		}
+	}
 	return v.rc.Close()
 }
 
+// SyscallConn returns a raw connection to the underlying bus descriptor,
+// which can be used to issue adapter specific ioctls not covered by this
+// package. The descriptor stays owned by the I2C connection and must not
+// be closed or retargeted through the raw connection. It returns
+// ErrUnsupported when the connection does not use a descriptor.
+func (v *I2C) SyscallConn() (syscall.RawConn, error) {
+	sc, ok := v.rc.(syscall.Conn)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return sc.SyscallConn()
+}
+
 // ReadRegBytes read count of n byte's sequence from i2c device
-// starting from reg address.
+// starting from reg address. It allocates the returned slice on every
+// call, high frequency readers should use ReadRegBytesInto.
 func (v *I2C) ReadRegBytes(reg byte, n int) ([]byte, int, error) {
-	_, err := v.WriteBytes([]byte{reg})
-	if err != nil {
-		return nil, 0, err
-	}
 	buf := make([]byte, n)
-	c, err := v.ReadBytes(buf)
+	c, err := v.ReadRegBytesInto(reg, buf)
 	if err != nil {
 		return nil, 0, err
 	}
 	return buf, c, nil
+}
+
+// ReadRegBytesInto read len(buf) bytes from i2c device starting from reg
+// address into buf, returning the count of bytes read. Blocks past
+// register 0xFF fail with ErrRegisterRange.
+func (v *I2C) ReadRegBytesInto(reg byte, buf []byte) (n int, err error) {
+	return v.readBlock(context.Background(), reg, buf)
+}
+
+// ReadRegBytesContext is ReadRegBytesInto for an operation with context
+// ctx, carried by its transactions to the middlewares, e.g. to trace them
+// within the caller's trace. Retries stop when ctx is done.
+func (v *I2C) ReadRegBytesContext(ctx context.Context, reg byte, buf []byte) (int, error) {
+	return v.readBlock(ctx, reg, buf)
+}
+
+// WriteRegBytes write buf to i2c device starting from reg address,
+// returning the count of bytes of buf written. Payloads up to 32 bytes
+// are sent without allocating. Blocks past register 0xFF fail with
+// ErrRegisterRange.
+func (v *I2C) WriteRegBytes(reg byte, buf []byte) (int, error) {
+	return v.writeBlock(context.Background(), reg, buf)
+}
 
+// WriteRegBytesContext is WriteRegBytes for an operation with context
+// ctx, see ReadRegBytesContext.
+func (v *I2C) WriteRegBytesContext(ctx context.Context, reg byte, buf []byte) (int, error) {
+	return v.writeBlock(ctx, reg, buf)
 }
 
 // ReadRegU8 read byte from i2c device register specified in reg.
 func (v *I2C) ReadRegU8(reg byte) (byte, error) {
-	_, err := v.WriteBytes([]byte{reg})
+	w, err := v.readRegN(reg, 1)
 	if err != nil {
 		return 0, err
 	}
-	buf := make([]byte, 1)
-	_, err = v.ReadBytes(buf)
-	if err != nil {
-		return 0, err
-	}
-	return buf[0], nil
+	return byte(w), nil
 }
 
 // WriteRegU8 write byte to i2c device register specified in reg.
 func (v *I2C) WriteRegU8(reg byte, value byte) error {
-	buf := []byte{reg, value}
-	_, err := v.WriteBytes(buf)
-	if err != nil {
-		return err
-	}
-	return nil
+	return v.writeRegN(reg, uint64(value), 1)
 }
 
 // ReadRegU16BE read unsigned big endian word (16 bits) from i2c device
 // starting from address specified in reg.
+//
+// Deprecated: use ReadReg[uint16](d, reg, binary.BigEndian) of
+// github.com/fedeonline/i2c-go/v2.
 func (v *I2C) ReadRegU16BE(reg byte) (uint16, error) {
-	_, err := v.WriteBytes([]byte{reg})
+	w, err := v.readRegN(reg, 2)
 	if err != nil {
 		return 0, err
 	}
-	buf := make([]byte, 2)
-	_, err = v.ReadBytes(buf)
-	if err != nil {
-		return 0, err
-	}
-	w := uint16(buf[0])<<8 + uint16(buf[1])
-	return w, nil
+	return uint16(w), nil
 }
 
 // ReadRegU16LE read unsigned little endian word (16 bits) from i2c device
 // starting from address specified in reg.
+//
+// Deprecated: use ReadReg[uint16](d, reg, binary.LittleEndian) of
+// github.com/fedeonline/i2c-go/v2.
 func (v *I2C) ReadRegU16LE(reg byte) (uint16, error) {
-	w, err := v.ReadRegU16BE(reg)
-	if err != nil {
-		return 0, err
-	}
-	// exchange bytes
-	w = (w&0xFF)<<8 + w>>8
-	return w, nil
+	return v.ReadRegU16(reg, binary.LittleEndian)
 }
 
 // ReadRegS16BE read signed big endian word (16 bits) from i2c device
 // starting from address specified in reg.
+//
+// Deprecated: use ReadReg[int16](d, reg, binary.BigEndian) of
+// github.com/fedeonline/i2c-go/v2.
 func (v *I2C) ReadRegS16BE(reg byte) (int16, error) {
-	_, err := v.WriteBytes([]byte{reg})
+	w, err := v.readRegN(reg, 2)
 	if err != nil {
 		return 0, err
 	}
-	buf := make([]byte, 2)
-	_, err = v.ReadBytes(buf)
-	if err != nil {
-		return 0, err
-	}
-	w := int16(buf[0])<<8 + int16(buf[1])
-	return w, nil
+	return int16(w), nil
 }
 
-// ReadRegS16LE read unsigned little endian word (16 bits) from i2c device
+// ReadRegS16LE read signed little endian word (16 bits) from i2c device
 // starting from address specified in reg.
+//
+// Deprecated: use ReadReg[int16](d, reg, binary.LittleEndian) of
+// github.com/fedeonline/i2c-go/v2.
 func (v *I2C) ReadRegS16LE(reg byte) (int16, error) {
-	w, err := v.ReadRegS16BE(reg)
-	if err != nil {
-		return 0, err
-	}
-	// exchange bytes
-	w = (w&0xFF)<<8 + w>>8
-	return w, nil
-
+	return v.ReadRegS16(reg, binary.LittleEndian)
 }
 
 // WriteRegU16BE write unsigned big endian word (16 bits) value to i2c device
 // starting from address specified in reg.
+//
+// Deprecated: use WriteReg(d, reg, value, binary.BigEndian) of
+// github.com/fedeonline/i2c-go/v2.
 func (v *I2C) WriteRegU16BE(reg byte, value uint16) error {
-	buf := []byte{reg, byte((value & 0xFF00) >> 8), byte(value & 0xFF)}
-	_, err := v.WriteBytes(buf)
-	if err != nil {
-		return err
-	}
-	return nil
+	return v.writeRegN(reg, uint64(value), 2)
 }
 
-// WriteRegU16LE write unsigned big endian word (16 bits) value to i2c device
+// WriteRegU16LE write unsigned little endian word (16 bits) value to i2c device
 // starting from address specified in reg.
+//
+// Deprecated: use WriteReg(d, reg, value, binary.LittleEndian) of
+// github.com/fedeonline/i2c-go/v2.
 func (v *I2C) WriteRegU16LE(reg byte, value uint16) error {
-	w := (value*0xFF00)>>8 + value<<8
-	return v.WriteRegU16BE(reg, w)
+	return v.WriteRegU16(reg, value, binary.LittleEndian)
 }
 
 // WriteRegS16BE write signed big endian word (16 bits) value to i2c device
 // starting from address specified in reg.
+//
+// Deprecated: use WriteReg(d, reg, value, binary.BigEndian) of
+// github.com/fedeonline/i2c-go/v2.
 func (v *I2C) WriteRegS16BE(reg byte, value int16) error {
-	buf := []byte{reg, byte((uint16(value) & 0xFF00) >> 8), byte(value & 0xFF)}
-	_, err := v.WriteBytes(buf)
-	if err != nil {
-		return err
-	}
-	return nil
+	return v.writeRegN(reg, uint64(uint16(value)), 2)
 }
 
-// WriteRegS16LE write signed big endian word (16 bits) value to i2c device
+// WriteRegS16LE write signed little endian word (16 bits) value to i2c device
 // starting from address specified in reg.
+//
+// Deprecated: use WriteReg(d, reg, value, binary.LittleEndian) of
+// github.com/fedeonline/i2c-go/v2.
 func (v *I2C) WriteRegS16LE(reg byte, value int16) error {
-	w := int16((uint16(value)*0xFF00)>>8) + value<<8
-	return v.WriteRegS16BE(reg, w)
+	return v.WriteRegS16(reg, value, binary.LittleEndian)
 }
 
-func ioctl(fd, cmd, arg uintptr) error {
-	_, _, err := syscall.Syscall6(syscall.SYS_IOCTL, fd, cmd, arg, 0, 0, 0)
-	if err != 0 {
-		return err
-	}
-	return nil
+// ReadRegU24BE read unsigned big endian 24 bits value from i2c device
+// starting from address specified in reg.
+func (v *I2C) ReadRegU24BE(reg byte) (uint32, error) {
+	return v.ReadRegU24(reg, binary.BigEndian)
+}
+
+// ReadRegU24LE read unsigned little endian 24 bits value from i2c device
+// starting from address specified in reg.
+func (v *I2C) ReadRegU24LE(reg byte) (uint32, error) {
+	return v.ReadRegU24(reg, binary.LittleEndian)
+}
+
+// WriteRegU24BE write unsigned big endian 24 bits value to i2c device
+// starting from address specified in reg.
+func (v *I2C) WriteRegU24BE(reg byte, value uint32) error {
+	return v.WriteRegU24(reg, value, binary.BigEndian)
+}
+
+// WriteRegU24LE write unsigned little endian 24 bits value to i2c device
+// starting from address specified in reg.
+func (v *I2C) WriteRegU24LE(reg byte, value uint32) error {
+	return v.WriteRegU24(reg, value, binary.LittleEndian)
+}
+
+// ReadRegU32BE read unsigned big endian double word (32 bits) from i2c
+// device starting from address specified in reg.
+//
+// Deprecated: use ReadReg[uint32](d, reg, binary.BigEndian) of
+// github.com/fedeonline/i2c-go/v2.
+func (v *I2C) ReadRegU32BE(reg byte) (uint32, error) {
+	return v.ReadRegU32(reg, binary.BigEndian)
+}
+
+// ReadRegU32LE read unsigned little endian double word (32 bits) from i2c
+// device starting from address specified in reg.
+//
+// Deprecated: use ReadReg[uint32](d, reg, binary.LittleEndian) of
+// github.com/fedeonline/i2c-go/v2.
+func (v *I2C) ReadRegU32LE(reg byte) (uint32, error) {
+	return v.ReadRegU32(reg, binary.LittleEndian)
+}
+
+// WriteRegU32BE write unsigned big endian double word (32 bits) value to
+// i2c device starting from address specified in reg.
+//
+// Deprecated: use WriteReg(d, reg, value, binary.BigEndian) of
+// github.com/fedeonline/i2c-go/v2.
+func (v *I2C) WriteRegU32BE(reg byte, value uint32) error {
+	return v.WriteRegU32(reg, value, binary.BigEndian)
+}
+
+// WriteRegU32LE write unsigned little endian double word (32 bits) value
+// to i2c device starting from address specified in reg.
+//
+// Deprecated: use WriteReg(d, reg, value, binary.LittleEndian) of
+// github.com/fedeonline/i2c-go/v2.
+func (v *I2C) WriteRegU32LE(reg byte, value uint32) error {
+	return v.WriteRegU32(reg, value, binary.LittleEndian)
+}
+
+// ReadRegS24BE read signed big endian 24 bits value from i2c device
+// starting from address specified in reg.
+func (v *I2C) ReadRegS24BE(reg byte) (int32, error) {
+	return v.ReadRegS24(reg, binary.BigEndian)
+}
+
+// ReadRegS24LE read signed little endian 24 bits value from i2c device
+// starting from address specified in reg.
+func (v *I2C) ReadRegS24LE(reg byte) (int32, error) {
+	return v.ReadRegS24(reg, binary.LittleEndian)
+}
+
+// ReadRegS32BE read signed big endian double word (32 bits) from i2c
+// device starting from address specified in reg.
+//
+// Deprecated: use ReadReg[int32](d, reg, binary.BigEndian) of
+// github.com/fedeonline/i2c-go/v2.
+func (v *I2C) ReadRegS32BE(reg byte) (int32, error) {
+	return v.ReadRegS32(reg, binary.BigEndian)
+}
+
+// ReadRegS32LE read signed little endian double word (32 bits) from i2c
+// device starting from address specified in reg.
+//
+// Deprecated: use ReadReg[int32](d, reg, binary.LittleEndian) of
+// github.com/fedeonline/i2c-go/v2.
+func (v *I2C) ReadRegS32LE(reg byte) (int32, error) {
+	return v.ReadRegS32(reg, binary.LittleEndian)
+}
+
+// ReadRegU64BE read unsigned big endian quad word (64 bits) from i2c
+// device starting from address specified in reg.
+//
+// Deprecated: use ReadReg[uint64](d, reg, binary.BigEndian) of
+// github.com/fedeonline/i2c-go/v2.
+func (v *I2C) ReadRegU64BE(reg byte) (uint64, error) {
+	return v.ReadRegU64(reg, binary.BigEndian)
+}
+
+// ReadRegU64LE read unsigned little endian quad word (64 bits) from i2c
+// device starting from address specified in reg.
+//
+// Deprecated: use ReadReg[uint64](d, reg, binary.LittleEndian) of
+// github.com/fedeonline/i2c-go/v2.
+func (v *I2C) ReadRegU64LE(reg byte) (uint64, error) {
+	return v.ReadRegU64(reg, binary.LittleEndian)
+}
+
+// WriteRegU64BE write unsigned big endian quad word (64 bits) value to
+// i2c device starting from address specified in reg.
+//
+// Deprecated: use WriteReg(d, reg, value, binary.BigEndian) of
+// github.com/fedeonline/i2c-go/v2.
+func (v *I2C) WriteRegU64BE(reg byte, value uint64) error {
+	return v.WriteRegU64(reg, value, binary.BigEndian)
+}
+
+// WriteRegU64LE write unsigned little endian quad word (64 bits) value to
+// i2c device starting from address specified in reg.
+//
+// Deprecated: use WriteReg(d, reg, value, binary.LittleEndian) of
+// github.com/fedeonline/i2c-go/v2.
+func (v *I2C) WriteRegU64LE(reg byte, value uint64) error {
+	return v.WriteRegU64(reg, value, binary.LittleEndian)
 }
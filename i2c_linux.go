@@ -0,0 +1,37 @@
+//go:build !baremetal
+
+package i2c
+
+import (
+	"fmt"
+	"os"
+)
+
+// NewI2C opens a connection to an i2c device.
+//
+// Deprecated: use OpenBus(bus).Open(addr) of
+// github.com/fedeonline/i2c-go/v2.
+func NewI2C(addr uint8, bus int) (*I2C, error) {
+	if err := ValidateAddr(addr); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(fmt.Sprintf("/dev/i2c-%d", bus), os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := ioctl(f.Fd(), i2cSlave, uintptr(addr)); err != nil {
+		f.Close()
+		return nil, err
+	}
+	v := &I2C{rc: f, bus: bus, addr: addr, mu: busLock(bus)}
+	v.dev.Store(sharedDevice(bus, addr))
+	track(v)
+	return v, nil
+}
+
+// setSlave retargets the descriptor of the connection to addr.
+func (v *I2C) setSlave(addr uint8) error {
+	return v.control(func(fd uintptr) error {
+		return ioctl(fd, i2cSlave, uintptr(addr))
+	})
+}
@@ -0,0 +1,23 @@
+//go:build !linux || baremetal
+
+package i2c
+
+// NewI2C opens a connection to an i2c device. It is only supported on
+// linux and returns ErrUnsupported elsewhere.
+//
+// Deprecated: use OpenBus(bus).Open(addr) of
+// github.com/fedeonline/i2c-go/v2.
+func NewI2C(addr uint8, bus int) (*I2C, error) {
+	return nil, ErrUnsupported
+}
+
+// OpenSMBus opens a connection to an i2c device performing SMBus
+// transfers. It is only supported on linux and returns ErrUnsupported
+// elsewhere.
+func OpenSMBus(addr uint8, bus int) (*I2C, error) {
+	return nil, ErrUnsupported
+}
+
+func (v *I2C) setSlave(addr uint8) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,21 @@
+// Module i2cgobot is versioned apart from the library, which then only
+// depends on golang.org/x/sys.
+module github.com/fedeonline/i2c-go/i2cgobot
+
+go 1.25.0
+
+require (
+	github.com/fedeonline/i2c-go v1.0.0
+	gobot.io/x/gobot/v2 v2.6.0
+)
+
+require (
+	github.com/gofrs/uuid v4.4.0+incompatible // indirect
+	github.com/hashicorp/errwrap v1.1.0 // indirect
+	github.com/hashicorp/go-multierror v1.1.1 // indirect
+	github.com/sigurn/crc8 v0.0.0-20220107193325-2243fe600f9f // indirect
+	github.com/warthog618/go-gpiocdev v0.9.1 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	periph.io/x/conn/v3 v3.7.3 // indirect
+	periph.io/x/host/v3 v3.8.5 // indirect
+)
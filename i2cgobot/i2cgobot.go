@@ -0,0 +1,141 @@
+//go:build linux
+
+// Package i2cgobot runs gobot i2c drivers over the connections of package
+// i2c, with their locking, retries and instrumentation.
+//
+//	c := &i2cgobot.Connector{Bus: 1}
+//	d := i2c.NewBME280Driver(c)
+//
+// where i2c is gobot.io/x/gobot/v2/drivers/i2c. The package is Linux
+// only, as gobot's i2c drivers are.
+package i2cgobot
+
+import (
+	"io"
+
+	i2c "github.com/fedeonline/i2c-go"
+	gi2c "gobot.io/x/gobot/v2/drivers/i2c"
+)
+
+// Connector implements gobot's i2c.Connector, opening connections with
+// i2c.NewI2C.
+type Connector struct {
+	// Bus is the default bus of the drivers.
+	Bus int
+	// Setup, if not nil, is called with each connection after opening it,
+	// e.g. to add middlewares or a retry policy.
+	Setup func(v *i2c.I2C)
+}
+
+var _ gi2c.Connector = (*Connector)(nil)
+
+// GetI2cConnection opens the device at address on bus busNr.
+func (c *Connector) GetI2cConnection(address int, busNr int) (gi2c.Connection, error) {
+	if address < 0 || address > 0x7F {
+		return nil, i2c.ErrAddress
+	}
+	v, err := i2c.NewI2C(uint8(address), busNr)
+	if err != nil {
+		return nil, err
+	}
+	if c.Setup != nil {
+		c.Setup(v)
+	}
+	return NewConnection(v), nil
+}
+
+// DefaultI2cBus returns the default bus.
+func (c *Connector) DefaultI2cBus() int {
+	return c.Bus
+}
+
+// Connection implements gobot's i2c.Connection over an i2c.I2C. SMBus
+// word data is little endian.
+type Connection struct {
+	v *i2c.I2C
+}
+
+var _ gi2c.Connection = (*Connection)(nil)
+
+// NewConnection returns the gobot connection talking through v. Closing it
+// closes v.
+func NewConnection(v *i2c.I2C) *Connection {
+	return &Connection{v: v}
+}
+
+// Read reads data from the device.
+func (c *Connection) Read(data []byte) (int, error) {
+	return c.v.ReadBytes(data)
+}
+
+// Write writes data to the device.
+func (c *Connection) Write(data []byte) (int, error) {
+	return c.v.WriteBytes(data)
+}
+
+// Close closes the underlying connection.
+func (c *Connection) Close() error {
+	return c.v.Close()
+}
+
+// ReadByte reads a byte from the current register.
+func (c *Connection) ReadByte() (byte, error) {
+	var b [1]byte
+	n, err := c.v.ReadBytes(b[:])
+	if err == nil && n < 1 {
+		err = io.ErrUnexpectedEOF
+	}
+	return b[0], err
+}
+
+// ReadByteData reads register reg.
+func (c *Connection) ReadByteData(reg uint8) (uint8, error) {
+	return c.v.ReadRegU8(reg)
+}
+
+// ReadWordData reads the little endian word starting at register reg.
+func (c *Connection) ReadWordData(reg uint8) (uint16, error) {
+	return c.v.ReadRegU16LE(reg)
+}
+
+// ReadBlockData fills data from the registers starting at reg.
+func (c *Connection) ReadBlockData(reg uint8, data []byte) error {
+	n, err := c.v.ReadRegBytesInto(reg, data)
+	if err == nil && n < len(data) {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// WriteByte writes val, e.g. setting the current register.
+func (c *Connection) WriteByte(val byte) error {
+	return c.WriteBytes([]byte{val})
+}
+
+// WriteBytes writes data.
+func (c *Connection) WriteBytes(data []byte) error {
+	n, err := c.v.WriteBytes(data)
+	if err == nil && n < len(data) {
+		err = io.ErrShortWrite
+	}
+	return err
+}
+
+// WriteByteData writes val to register reg.
+func (c *Connection) WriteByteData(reg uint8, val uint8) error {
+	return c.v.WriteRegU8(reg, val)
+}
+
+// WriteWordData writes val little endian starting at register reg.
+func (c *Connection) WriteWordData(reg uint8, val uint16) error {
+	return c.v.WriteRegU16LE(reg, val)
+}
+
+// WriteBlockData writes data to the registers starting at reg.
+func (c *Connection) WriteBlockData(reg uint8, data []byte) error {
+	n, err := c.v.WriteRegBytes(reg, data)
+	if err == nil && n < len(data) {
+		err = io.ErrShortWrite
+	}
+	return err
+}
@@ -0,0 +1,20 @@
+// Module i2cotel is versioned apart from the library, which then only
+// depends on golang.org/x/sys.
+module github.com/fedeonline/i2c-go/i2cotel
+
+go 1.25.0
+
+require (
+	github.com/fedeonline/i2c-go v1.0.0
+	go.opentelemetry.io/otel v1.46.0
+	go.opentelemetry.io/otel/trace v1.46.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/go-logr/logr v1.4.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
+	go.opentelemetry.io/otel/metric v1.46.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+)
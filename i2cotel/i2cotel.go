@@ -0,0 +1,68 @@
+// Package i2cotel provides OpenTelemetry tracing for i2c transactions.
+//
+// Spans are children of the span in the context of the operation, so
+// that i2c latency shows within the caller's traces: use the Context
+// variants of the operations, e.g.
+//
+//	v.Use(i2cotel.Middleware(nil))
+//	err := v.TxContext(ctx, cmd, reply)
+//
+// Operations without a context start a new trace per transfer.
+package i2cotel
+
+import (
+	"context"
+	"errors"
+	"syscall"
+
+	i2c "github.com/fedeonline/i2c-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const scope = "github.com/fedeonline/i2c-go/i2cotel"
+
+// Middleware returns an i2c middleware emitting a span per transaction
+// using tp, or the global tracer provider when tp is nil. Spans carry the
+// bus, address, register, direction, byte count, retries and errno
+// attributes.
+func Middleware(tp trace.TracerProvider) i2c.Middleware {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	tracer := tp.Tracer(scope)
+	return func(next i2c.Handler) i2c.Handler {
+		return func(t *i2c.Transaction) {
+			ctx := t.Context
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			_, span := tracer.Start(ctx, "i2c."+t.Op.String(),
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.Int("i2c.bus", t.Bus),
+					attribute.Int("i2c.address", int(t.Addr)),
+					attribute.Int("i2c.retries", t.Attempt-1),
+				))
+			defer span.End()
+			if t.Reg != i2c.NoReg {
+				span.SetAttributes(attribute.Int("i2c.register", t.Reg))
+			}
+			if t.Op == i2c.OpCombined {
+				span.SetAttributes(attribute.Int("i2c.messages", len(t.Msgs)))
+			}
+			next(t)
+			span.SetAttributes(attribute.Int("i2c.bytes", t.N))
+			if t.Err != nil {
+				var errno syscall.Errno
+				if errors.As(t.Err, &errno) {
+					span.SetAttributes(attribute.Int("i2c.errno", int(errno)))
+				}
+				span.RecordError(t.Err)
+				span.SetStatus(codes.Error, t.Err.Error())
+			}
+		}
+	}
+}
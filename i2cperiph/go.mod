@@ -0,0 +1,12 @@
+// Module i2cperiph is versioned apart from the library, which then only
+// depends on golang.org/x/sys.
+module github.com/fedeonline/i2c-go/i2cperiph
+
+go 1.25.0
+
+require (
+	github.com/fedeonline/i2c-go v1.0.0
+	periph.io/x/conn/v3 v3.7.3
+)
+
+require golang.org/x/sys v0.47.0 // indirect
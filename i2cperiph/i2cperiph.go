@@ -0,0 +1,102 @@
+// Package i2cperiph bridges package i2c and periph.io, so periph device
+// drivers run over the connections of this package, with their locking,
+// retries and instrumentation, and periph buses serve as transports of
+// i2c.I2C connections.
+//
+//	b := i2cperiph.NewBus(1)
+//	b.Setup = func(v *i2c.I2C) { v.SetRetryPolicy(policy) }
+//	defer b.Close()
+//	dev, err := bmxx80.NewI2C(b, 0x76, &bmxx80.DefaultOpts)
+package i2cperiph
+
+import (
+	"fmt"
+	"sync"
+
+	i2c "github.com/fedeonline/i2c-go"
+	pi2c "periph.io/x/conn/v3/i2c"
+	"periph.io/x/conn/v3/physic"
+)
+
+// Bus is a bus of package i2c implementing periph's i2c.BusCloser. The
+// connection to each address is opened on first use.
+type Bus struct {
+	// Setup, if not nil, is called with each connection after opening it,
+	// e.g. to add middlewares or a retry policy.
+	Setup func(v *i2c.I2C)
+
+	bus   int
+	mu    sync.Mutex
+	conns map[uint16]*i2c.I2C
+}
+
+var _ pi2c.BusCloser = (*Bus)(nil)
+
+// NewBus returns the periph bus of /dev/i2c-bus.
+func NewBus(bus int) *Bus {
+	return &Bus{bus: bus, conns: make(map[uint16]*i2c.I2C)}
+}
+
+func (b *Bus) String() string {
+	return fmt.Sprintf("I2C%d", b.bus)
+}
+
+func (b *Bus) conn(addr uint16) (*i2c.I2C, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if v := b.conns[addr]; v != nil {
+		return v, nil
+	}
+	if addr > 0x7F {
+		return nil, i2c.ErrAddress
+	}
+	v, err := i2c.NewI2C(uint8(addr), b.bus)
+	if err != nil {
+		return nil, err
+	}
+	if b.Setup != nil {
+		b.Setup(v)
+	}
+	b.conns[addr] = v
+	return v, nil
+}
+
+// Tx writes w then reads r from the device at addr, see (*i2c.I2C).Tx.
+func (b *Bus) Tx(addr uint16, w, r []byte) error {
+	v, err := b.conn(addr)
+	if err != nil {
+		return err
+	}
+	return v.Tx(w, r)
+}
+
+// SetSpeed is not supported, the speed of i2c-dev buses is set by the
+// device tree or the adapter driver.
+func (b *Bus) SetSpeed(f physic.Frequency) error {
+	return i2c.ErrUnsupported
+}
+
+// Close closes the connections opened by the bus.
+func (b *Bus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var first error
+	for addr, v := range b.conns {
+		if err := v.Close(); err != nil && first == nil {
+			first = err
+		}
+		delete(b.conns, addr)
+	}
+	return first
+}
+
+// NewConn returns a transport to addr on the periph bus b, for
+// i2c.NewI2CConn.
+func NewConn(b pi2c.Bus, addr uint8) *i2c.BusConn {
+	return i2c.NewBusConn(b, addr)
+}
+
+// Open returns a connection to the device at addr on the periph bus b.
+func Open(b pi2c.Bus, addr uint8) *i2c.I2C {
+	return i2c.OpenBus(b, addr)
+}
@@ -0,0 +1,105 @@
+// Package i2cprom exports i2c transaction metrics to Prometheus.
+package i2cprom
+
+import (
+	"strconv"
+
+	i2c "github.com/fedeonline/i2c-go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "i2c"
+
+// Metrics holds the collectors fed by the middleware. A single Metrics
+// value is meant to be shared by all the connections of a program, the
+// bus and address labels tell them apart.
+type Metrics struct {
+	transfers  *prometheus.CounterVec
+	bytes      *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+	nacks      *prometheus.CounterVec
+	reconnects *prometheus.CounterVec
+}
+
+// NewMetrics creates the collectors and registers them against r.
+func NewMetrics(r prometheus.Registerer) (*Metrics, error) {
+	labels := []string{"bus", "addr", "direction"}
+	m := &Metrics{
+		transfers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transfers_total",
+			Help:      "Count of i2c transfers by direction and result.",
+		}, append(labels, "result")),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "bytes_total",
+			Help:      "Count of bytes transferred on the i2c bus.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "transfer_duration_seconds",
+			Help:      "Duration of i2c transfers.",
+			Buckets:   prometheus.ExponentialBuckets(50e-6, 2, 12),
+		}, labels),
+		nacks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "nack_total",
+			Help:      "Count of transfers not acknowledged by the device.",
+		}, []string{"bus", "addr"}),
+		reconnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reconnects_total",
+			Help:      "Count of reconnections to an i2c device.",
+		}, []string{"bus", "addr"}),
+	}
+	for _, c := range []prometheus.Collector{m.transfers, m.bytes, m.duration, m.nacks, m.reconnects} {
+		if err := r.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Middleware returns an i2c middleware feeding the collectors.
+func (m *Metrics) Middleware() i2c.Middleware {
+	return func(next i2c.Handler) i2c.Handler {
+		return func(t *i2c.Transaction) {
+			next(t)
+			bus, addr, dir := strconv.Itoa(t.Bus), addrLabel(t.Addr), t.Op.String()
+			result := "ok"
+			if t.Err != nil {
+				result = "error"
+				if i2c.IsNack(t.Err) {
+					result = "nack"
+					m.nacks.WithLabelValues(bus, addr).Inc()
+				}
+			}
+			m.transfers.WithLabelValues(bus, addr, dir, result).Inc()
+			if t.Op == i2c.OpCombined {
+				// the bytes count by the direction of the messages
+				if t.Err == nil {
+					for _, msg := range t.Msgs {
+						d := i2c.OpWrite
+						if msg.Read {
+							d = i2c.OpRead
+						}
+						m.bytes.WithLabelValues(bus, addr, d.String()).Add(float64(len(msg.Buf)))
+					}
+				}
+			} else {
+				m.bytes.WithLabelValues(bus, addr, dir).Add(float64(t.N))
+			}
+			m.duration.WithLabelValues(bus, addr, dir).Observe(t.Duration.Seconds())
+		}
+	}
+}
+
+// Reconnected records a reconnection to the device at addr on bus. The
+// library does not reconnect on its own, so this is left to the caller.
+func (m *Metrics) Reconnected(bus int, addr uint8) {
+	m.reconnects.WithLabelValues(strconv.Itoa(bus), addrLabel(addr)).Inc()
+}
+
+func addrLabel(addr uint8) string {
+	return "0x" + strconv.FormatUint(uint64(addr), 16)
+}
@@ -0,0 +1,176 @@
+// Package i2csim simulates i2c devices in process, for integration tests
+// of applications built on package i2c. Devices implement a small
+// interface and are attached to a simulated Bus, whose connections are
+// accepted wherever a real one is:
+//
+//	// adc models a converter taking 10ms per conversion, started by
+//	// writing 1 to register 0.
+//	type adc struct {
+//		i2csim.Regs
+//		done time.Time
+//	}
+//
+//	func (a *adc) HandleWrite(p []byte) error {
+//		if len(p) == 2 && p[0] == 0 && p[1] == 1 {
+//			a.done = time.Now().Add(10 * time.Millisecond)
+//		}
+//		return a.Regs.HandleWrite(p)
+//	}
+//
+//	func (a *adc) HandleRead(p []byte) error {
+//		if time.Now().Before(a.done) {
+//			a.Mem[0] = 1 // busy
+//		} else {
+//			a.Mem[0] = 0
+//		}
+//		return a.Regs.HandleRead(p)
+//	}
+//
+//	bus := i2csim.NewBus()
+//	bus.Attach(0x48, &adc{})
+//	v := bus.Open(0x48)
+package i2csim
+
+import (
+	"sync"
+	"syscall"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// ErrNack is returned by transfers addressing no attached device. On
+// Linux i2c.IsNack reports true for it.
+var ErrNack error = syscall.ENXIO
+
+// Device is a simulated device. Each call is a transfer addressed to it:
+// HandleWrite receives the bytes written, HandleRead fills p with the
+// bytes to return. Returning ErrNack models a device not acknowledging,
+// e.g. while busy. Calls are serialized by the Bus.
+type Device interface {
+	HandleWrite(p []byte) error
+	HandleRead(p []byte) error
+}
+
+// Regs is a Device with 256 registers and an auto-incrementing register
+// pointer set by the first byte of every write, the model of most
+// register based chips. It is meant to be embedded by devices adding
+// behavior.
+type Regs struct {
+	Mem [256]byte
+	Ptr byte
+}
+
+// HandleWrite sets the register pointer to p[0] and stores the rest of p
+// in the registers.
+func (r *Regs) HandleWrite(p []byte) error {
+	if len(p) == 0 {
+		return nil
+	}
+	r.Ptr = p[0]
+	for _, b := range p[1:] {
+		r.Mem[r.Ptr] = b
+		r.Ptr++
+	}
+	return nil
+}
+
+// HandleRead fills p from the registers starting at the register pointer.
+func (r *Regs) HandleRead(p []byte) error {
+	for i := range p {
+		p[i] = r.Mem[r.Ptr]
+		r.Ptr++
+	}
+	return nil
+}
+
+// Bus is a simulated bus holding devices at addresses.
+type Bus struct {
+	mu   sync.Mutex
+	devs map[uint8]Device
+}
+
+// NewBus returns an empty Bus.
+func NewBus() *Bus {
+	return &Bus{devs: make(map[uint8]Device)}
+}
+
+// Attach places d at addr, replacing the device there if any.
+func (b *Bus) Attach(addr uint8, d Device) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.devs[addr] = d
+}
+
+// Detach removes the device at addr, as if unplugged.
+func (b *Bus) Detach(addr uint8) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.devs, addr)
+}
+
+// Conn returns a transport to addr on the bus, for i2c.NewI2CConn.
+func (b *Bus) Conn(addr uint8) *Conn {
+	return &Conn{bus: b, addr: addr}
+}
+
+// Open returns a connection to the device at addr on the bus. The device
+// need not be attached yet.
+func (b *Bus) Open(addr uint8) *i2c.I2C {
+	return i2c.NewI2CConn(b.Conn(addr), addr)
+}
+
+// transfer runs fn on the device at addr.
+func (b *Bus) transfer(addr uint8, fn func(d Device) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	d := b.devs[addr]
+	if d == nil {
+		return ErrNack
+	}
+	return fn(d)
+}
+
+// Conn is an i2c.Conn to an address of a Bus. It implements
+// i2c.Addresser, so connections can be retargeted with SetAddr.
+type Conn struct {
+	bus  *Bus
+	mu   sync.Mutex
+	addr uint8
+}
+
+func (c *Conn) target() uint8 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.addr
+}
+
+// Write transfers p to the device.
+func (c *Conn) Write(p []byte) (int, error) {
+	err := c.bus.transfer(c.target(), func(d Device) error { return d.HandleWrite(p) })
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Read transfers p from the device.
+func (c *Conn) Read(p []byte) (int, error) {
+	err := c.bus.transfer(c.target(), func(d Device) error { return d.HandleRead(p) })
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// SetAddr retargets the connection to addr.
+func (c *Conn) SetAddr(addr uint8) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addr = addr
+	return nil
+}
+
+// Close does nothing.
+func (c *Conn) Close() error {
+	return nil
+}
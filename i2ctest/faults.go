@@ -0,0 +1,79 @@
+package i2ctest
+
+import (
+	"math/rand/v2"
+	"sync"
+	"syscall"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// ErrTimeout is injected by Faults for timed out transfers. It reports
+// true from its Timeout method.
+var ErrTimeout error = syscall.ETIMEDOUT
+
+// Faults configures the faults injected in the transactions of a
+// connection, to exercise retry and recovery logic. Probabilities are per
+// transaction, from 0 to 1. Faults are drawn from a generator seeded with
+// Seed, so a run is reproducible given the same sequence of transactions.
+type Faults struct {
+	Seed uint64
+	// Nack and Timeout fail the transaction, without reaching the device,
+	// with ErrNack and ErrTimeout.
+	Nack    float64
+	Timeout float64
+	// ShortRead truncates reads to a random shorter count.
+	ShortRead float64
+	// BitFlip flips a random bit of the bytes read or written.
+	BitFlip float64
+	// MaxLatency delays each transaction by a random duration up to it.
+	MaxLatency time.Duration
+}
+
+// Middleware returns a middleware injecting the faults, for i2c.I2C.Use.
+func (f Faults) Middleware() i2c.Middleware {
+	var mu sync.Mutex
+	rng := rand.New(rand.NewPCG(f.Seed, f.Seed))
+	hit := func(p float64) bool { return p > 0 && rng.Float64() < p }
+	return func(next i2c.Handler) i2c.Handler {
+		return func(t *i2c.Transaction) {
+			mu.Lock()
+			var delay time.Duration
+			if f.MaxLatency > 0 {
+				delay = time.Duration(rng.Int64N(int64(f.MaxLatency) + 1))
+			}
+			nack, timeout := hit(f.Nack), hit(f.Timeout)
+			short, flip := hit(f.ShortRead), hit(f.BitFlip)
+			bit := rng.Uint64()
+			cut := rng.Uint64()
+			mu.Unlock()
+
+			time.Sleep(delay)
+			switch {
+			case nack:
+				t.N, t.Err = 0, ErrNack
+				return
+			case timeout:
+				t.N, t.Err = 0, ErrTimeout
+				return
+			}
+			if flip && t.Op == i2c.OpWrite && len(t.Buf) > 0 {
+				// flip a copy, the buffer belongs to the caller
+				buf := append([]byte(nil), t.Buf...)
+				buf[bit/8%uint64(len(buf))] ^= 1 << (bit % 8)
+				t.Buf = buf
+			}
+			next(t)
+			if t.Op != i2c.OpRead || t.Err != nil || t.N <= 0 {
+				return
+			}
+			if flip {
+				t.Buf[bit/8%uint64(t.N)] ^= 1 << (bit % 8)
+			}
+			if short {
+				t.N = int(cut % uint64(t.N))
+			}
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Package i2ctest provides an in-memory fake device for testing drivers
+// built on package i2c without hardware.
+//
+//	func TestDriver(t *testing.T) {
+//		v, dev := i2ctest.New(t, 0x76, map[byte]byte{0xD0: 0x60})
+//		cfg := bme280.DefaultConfig
+//		cfg.Mode = bme280.Normal
+//		drv, err := bme280.New(v, &cfg)
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		...
+//		if dev.Reg(0xF4) != 0x27 {
+//			t.Error("bad measurement mode")
+//		}
+//	}
+package i2ctest
+
+import (
+	"sync"
+	"syscall"
+	"testing"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// ErrNack is returned by the transfers of an absent device. On Linux
+// i2c.IsNack reports true for it.
+var ErrNack error = syscall.ENXIO
+
+// Fake is an i2c.Conn backed by a 256 register map, modeling a device
+// with an auto-incrementing 8 bit register pointer set by the first byte
+// of every write. Hooks customize single registers, e.g. to model status
+// flags, read-to-clear or self-clearing bits.
+type Fake struct {
+	mu      sync.Mutex
+	regs    [256]byte
+	ptr     byte
+	ro      [256]bool
+	onRead  map[byte]func(regs *[256]byte) byte
+	onWrite map[byte]func(regs *[256]byte, b byte)
+	absent  bool
+	writes  [][]byte
+	closed  bool
+}
+
+// NewFake returns a Fake whose registers are initialized from seed.
+// Registers missing from seed read as 0.
+func NewFake(seed map[byte]byte) *Fake {
+	f := &Fake{
+		onRead:  map[byte]func(*[256]byte) byte{},
+		onWrite: map[byte]func(*[256]byte, byte){},
+	}
+	for r, b := range seed {
+		f.regs[r] = b
+	}
+	return f
+}
+
+// New returns a connection to a Fake at addr seeded with seed. The
+// connection is closed when the test ends.
+func New(tb testing.TB, addr uint8, seed map[byte]byte) (*i2c.I2C, *Fake) {
+	tb.Helper()
+	f := NewFake(seed)
+	v := i2c.NewI2CConn(f, addr)
+	tb.Cleanup(func() { v.Close() })
+	return v, f
+}
+
+// Write sets the register pointer to p[0] and stores the rest of p in the
+// registers, skipping read-only ones.
+func (f *Fake) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.check(); err != nil {
+		return 0, err
+	}
+	f.writes = append(f.writes, append([]byte(nil), p...))
+	if len(p) == 0 {
+		return 0, nil
+	}
+	f.ptr = p[0]
+	for _, b := range p[1:] {
+		if fn := f.onWrite[f.ptr]; fn != nil {
+			fn(&f.regs, b)
+		} else if !f.ro[f.ptr] {
+			f.regs[f.ptr] = b
+		}
+		f.ptr++
+	}
+	return len(p), nil
+}
+
+// Read fills p from the registers starting at the register pointer.
+func (f *Fake) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.check(); err != nil {
+		return 0, err
+	}
+	for i := range p {
+		if fn := f.onRead[f.ptr]; fn != nil {
+			p[i] = fn(&f.regs)
+		} else {
+			p[i] = f.regs[f.ptr]
+		}
+		f.ptr++
+	}
+	return len(p), nil
+}
+
+func (f *Fake) check() error {
+	if f.closed {
+		return syscall.EBADF
+	}
+	if f.absent {
+		return ErrNack
+	}
+	return nil
+}
+
+// Close marks the Fake closed, further transfers fail.
+func (f *Fake) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+// Reg returns the value of register r.
+func (f *Fake) Reg(r byte) byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.regs[r]
+}
+
+// Regs returns the n register values starting from r.
+func (f *Fake) Regs(r byte, n int) []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = f.regs[r]
+		r++
+	}
+	return b
+}
+
+// SetReg sets the registers starting from r to b, bypassing hooks and
+// read-only flags, as the device itself would.
+func (f *Fake) SetReg(r byte, b ...byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, x := range b {
+		f.regs[r] = x
+		r++
+	}
+}
+
+// ReadOnly makes writes to regs ignored.
+func (f *Fake) ReadOnly(regs ...byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range regs {
+		f.ro[r] = true
+	}
+}
+
+// OnRead makes reads of register r return fn(regs), e.g. a counter or a
+// flag clearing after a number of polls. Hooks run with the Fake locked
+// and access the register map through regs. A nil fn removes the hook.
+func (f *Fake) OnRead(r byte, fn func(regs *[256]byte) byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if fn == nil {
+		delete(f.onRead, r)
+		return
+	}
+	f.onRead[r] = fn
+}
+
+// OnWrite makes writes of b to register r call fn(regs, b) instead of
+// storing the value, e.g. to model a command register starting a
+// conversion. A nil fn removes the hook.
+func (f *Fake) OnWrite(r byte, fn func(regs *[256]byte, b byte)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if fn == nil {
+		delete(f.onWrite, r)
+		return
+	}
+	f.onWrite[r] = fn
+}
+
+// SetAbsent makes every transfer fail with ErrNack while absent is true,
+// as if the device was unplugged.
+func (f *Fake) SetAbsent(absent bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.absent = absent
+}
+
+// Writes returns the messages written so far, in order, register pointer
+// byte included.
+func (f *Fake) Writes() [][]byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]byte(nil), f.writes...)
+}
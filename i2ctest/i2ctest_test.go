@@ -0,0 +1,165 @@
+package i2ctest_test
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	i2c "github.com/fedeonline/i2c-go"
+	"github.com/fedeonline/i2c-go/devices/bme280"
+	"github.com/fedeonline/i2c-go/i2ctest"
+)
+
+// TestPackageExample runs the example of the package documentation.
+func TestPackageExample(t *testing.T) {
+	v, dev := i2ctest.New(t, 0x76, map[byte]byte{0xD0: 0x60})
+	cfg := bme280.DefaultConfig
+	cfg.Mode = bme280.Normal
+	if _, err := bme280.New(v, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if dev.Reg(0xF4) != 0x27 {
+		t.Errorf("ctrl_meas 0x%02X, want 0x27", dev.Reg(0xF4))
+	}
+}
+
+func TestFake(t *testing.T) {
+	v, f := i2ctest.New(t, 0x40, map[byte]byte{0x00: 0x11})
+	f.ReadOnly(0x00)
+	polls := 0
+	f.OnRead(0x01, func(regs *[256]byte) byte {
+		polls++
+		return byte(polls)
+	})
+	f.OnWrite(0x02, func(regs *[256]byte, b byte) { regs[0x03] = b + 1 })
+
+	if err := v.WriteRegU8(0x00, 0x22); err != nil {
+		t.Fatal(err)
+	}
+	if f.Reg(0x00) != 0x11 {
+		t.Errorf("read-only register written: 0x%02X", f.Reg(0x00))
+	}
+	for want := byte(1); want <= 2; want++ {
+		if b, err := v.ReadRegU8(0x01); err != nil || b != want {
+			t.Errorf("poll %d: got %d, %v", want, b, err)
+		}
+	}
+	if err := v.WriteRegU8(0x02, 0x41); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Regs(0x02, 2); !slices.Equal(got, []byte{0x00, 0x42}) {
+		t.Errorf("write hook: registers % X, want 00 42", got)
+	}
+	// the register reads write their pointer
+	if w := f.Writes(); len(w) != 4 || !slices.Equal(w[3], []byte{0x02, 0x41}) {
+		t.Errorf("writes % X", w)
+	}
+
+	f.SetAbsent(true)
+	if _, err := v.ReadRegU8(0x00); !errors.Is(err, i2ctest.ErrNack) || !i2c.IsNack(err) {
+		t.Errorf("absent device: got %v, want ErrNack", err)
+	}
+	f.SetAbsent(false)
+	if _, err := v.ReadRegU8(0x00); err != nil {
+		t.Errorf("device back: %v", err)
+	}
+}
+
+func TestMock(t *testing.T) {
+	m := i2ctest.NewMock(t)
+	m.ExpectWrite(0xF4, 0x2E)
+	m.ExpectWrite(0xF6)
+	m.ExpectRead(3).Return(0x5D, 0x23)
+	m.ExpectWrite(0xF4, 0x00).Fail(i2ctest.ErrNack)
+	v := i2c.NewI2CConn(m, 0x77)
+	defer v.Close()
+	if err := v.WriteRegU8(0xF4, 0x2E); err != nil {
+		t.Fatal(err)
+	}
+	b, _, err := v.ReadRegBytes(0xF6, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(b, []byte{0x5D, 0x23, 0x00}) {
+		t.Errorf("read % X, want 5D 23 00", b)
+	}
+	if err := v.WriteRegU8(0xF4, 0x00); !errors.Is(err, i2ctest.ErrNack) {
+		t.Errorf("got %v, want ErrNack", err)
+	}
+}
+
+// recorder is a testing.TB recording the failures of the test.
+type recorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failed = true
+}
+
+func TestMockUnexpected(t *testing.T) {
+	r := &recorder{TB: t}
+	m := i2ctest.NewMock(r)
+	m.ExpectWrite(0x01)
+	if _, err := m.Write([]byte{0x02}); !errors.Is(err, i2ctest.ErrUnexpected) {
+		t.Errorf("got %v, want ErrUnexpected", err)
+	}
+	if !r.failed {
+		t.Error("mismatch not reported")
+	}
+}
+
+func TestFaults(t *testing.T) {
+	run := func() []bool {
+		v, _ := i2ctest.New(t, 0x40, nil)
+		v.Use(i2ctest.Faults{Seed: 7, Nack: 0.5}.Middleware())
+		var nacks []bool
+		for range 32 {
+			_, err := v.ReadRegU8(0x00)
+			if err != nil && !i2c.IsNack(err) {
+				t.Fatal(err)
+			}
+			nacks = append(nacks, err != nil)
+		}
+		return nacks
+	}
+	a, b := run(), run()
+	if !slices.Equal(a, b) {
+		t.Error("faults not reproducible with the same seed")
+	}
+	if !slices.Contains(a, true) || !slices.Contains(a, false) {
+		t.Errorf("faults at 0.5 all alike: %v", a)
+	}
+}
+
+func TestReplayShortRead(t *testing.T) {
+	read := func(c i2c.Conn, mw ...i2c.Middleware) (int, []byte) {
+		v := i2c.NewI2CConn(c, 0x40)
+		defer v.Close()
+		v.Use(mw...)
+		buf := make([]byte, 4)
+		n, err := v.ReadRegBytesInto(0x10, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n, buf
+	}
+	m := i2ctest.NewMock(t)
+	m.ExpectWrite(0x10)
+	m.ExpectRead(4).Return(0x01, 0x02).Short(2)
+	var log bytes.Buffer
+	rec := i2c.NewRecorder(&log)
+	n, buf := read(m, rec.Middleware())
+	if n != 2 {
+		t.Fatalf("recorded read of %d bytes, want 2", n)
+	}
+	r, err := i2ctest.Replay(t, &log, 0x40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, gotBuf := read(r); got != n || !bytes.Equal(gotBuf, buf) {
+		t.Errorf("replayed %d bytes % X, want %d bytes % X", got, gotBuf, n, buf)
+	}
+}
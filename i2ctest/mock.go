@@ -0,0 +1,169 @@
+package i2ctest
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// ErrUnexpected is returned by the transfers of a Mock not matching the
+// next expectation.
+var ErrUnexpected = errors.New("i2ctest: unexpected transfer")
+
+// Expectation is a transfer expected by a Mock.
+type Expectation struct {
+	op   i2c.Op
+	n    int
+	data []byte
+	err  error
+	// short is the count of bytes transferred, -1 for all of them
+	short int
+	at    string
+}
+
+// Return sets the bytes returned by an expected read, zero padded or
+// truncated to the read length.
+func (e *Expectation) Return(b ...byte) *Expectation {
+	e.data = append(e.data[:0], b...)
+	return e
+}
+
+// Short makes the expected transfer stop after n bytes, e.g. a device
+// ending a read early, reporting n bytes transferred.
+func (e *Expectation) Short(n int) *Expectation {
+	e.short = n
+	return e
+}
+
+// Fail makes the expected transfer fail with err, e.g. ErrNack, without
+// transferring any byte unless set by Short.
+func (e *Expectation) Fail(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+func (e *Expectation) String() string {
+	if e.op == i2c.OpRead {
+		return fmt.Sprintf("read %d bytes", e.n)
+	}
+	return fmt.Sprintf("write % x", e.data)
+}
+
+// Mock is an i2c.Conn checking the traffic of a driver against an
+// ordered list of expected transfers, to catch protocol regressions:
+//
+//	m := i2ctest.NewMock(t)
+//	m.ExpectWrite(0xF4, 0x2E)
+//	m.ExpectWrite(0xF6)
+//	m.ExpectRead(3).Return(0x5D, 0x23, 0x00)
+//	v := i2c.NewI2CConn(m, 0x77)
+//
+// A transfer not matching the next expectation fails the test reporting
+// both, and returns ErrUnexpected. Expectations left unmet when the test
+// ends fail it too.
+type Mock struct {
+	tb   testing.TB
+	mu   sync.Mutex
+	exp  []*Expectation
+	next int
+}
+
+// NewMock returns a Mock without expectations, checked at the end of the
+// test.
+func NewMock(tb testing.TB) *Mock {
+	m := &Mock{tb: tb}
+	tb.Cleanup(m.check)
+	return m
+}
+
+func (m *Mock) expect(op i2c.Op, n int, data []byte) *Expectation {
+	e := &Expectation{op: op, n: n, data: data, short: -1}
+	if _, file, line, ok := runtime.Caller(2); ok {
+		e.at = fmt.Sprintf("%s:%d", file, line)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exp = append(m.exp, e)
+	return e
+}
+
+// ExpectWrite appends the expectation of a write of b, register pointer
+// byte included.
+func (m *Mock) ExpectWrite(b ...byte) *Expectation {
+	return m.expect(i2c.OpWrite, len(b), append([]byte(nil), b...))
+}
+
+// ExpectRead appends the expectation of a read of n bytes, returning
+// zeros unless set by Return.
+func (m *Mock) ExpectRead(n int) *Expectation {
+	return m.expect(i2c.OpRead, n, nil)
+}
+
+// match consumes the next expectation if the transfer matches it.
+func (m *Mock) match(op i2c.Op, p []byte) (*Expectation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	got := &Expectation{op: op, n: len(p), data: p}
+	if m.next >= len(m.exp) {
+		m.tb.Errorf("i2ctest: transfer %d: got %v, want no more transfers", m.next+1, got)
+		return nil, ErrUnexpected
+	}
+	e := m.exp[m.next]
+	if e.op != op || e.n != len(p) || op == i2c.OpWrite && string(e.data) != string(p) {
+		m.tb.Errorf("i2ctest: transfer %d: got %v, want %v (expected at %s)", m.next+1, got, e, e.at)
+		return nil, ErrUnexpected
+	}
+	m.next++
+	return e, nil
+}
+
+// count returns the count of bytes transferred by the expected transfer
+// of n bytes.
+func (e *Expectation) count(n int) int {
+	switch {
+	case e.short >= 0:
+		return min(e.short, n)
+	case e.err != nil:
+		return 0
+	}
+	return n
+}
+
+// Write checks p against the next expectation.
+func (m *Mock) Write(p []byte) (int, error) {
+	e, err := m.match(i2c.OpWrite, p)
+	if err != nil {
+		return 0, err
+	}
+	return e.count(len(p)), e.err
+}
+
+// Read checks the read against the next expectation and fills p with its
+// bytes.
+func (m *Mock) Read(p []byte) (int, error) {
+	e, err := m.match(i2c.OpRead, p)
+	if err != nil {
+		return 0, err
+	}
+	n := e.count(len(p))
+	clear(p[copy(p[:n], e.data):])
+	return n, e.err
+}
+
+// Close does nothing.
+func (m *Mock) Close() error {
+	return nil
+}
+
+// check fails the test when expectations are left unmet.
+func (m *Mock) check() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.exp[m.next:] {
+		m.tb.Errorf("i2ctest: missing transfer: %v (expected at %s)", e, e.at)
+	}
+}
@@ -0,0 +1,42 @@
+package i2ctest
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// Replay returns a Mock expecting the transactions with the device at
+// addr captured by an i2c.Recorder in r, in order, serving the recorded
+// read data, counts and errors, so that short transfers are reproduced.
+// Failed transactions which were not acknowledged fail with ErrNack.
+func Replay(tb testing.TB, r io.Reader, addr uint8) (*Mock, error) {
+	recs, err := i2c.ReadRecords(r)
+	if err != nil {
+		return nil, err
+	}
+	m := NewMock(tb)
+	for _, rec := range recs {
+		if rec.Addr != addr {
+			continue
+		}
+		var e *Expectation
+		if rec.Op == i2c.OpRead {
+			e = m.ExpectRead(rec.Len).Return(rec.Data...)
+		} else {
+			e = m.ExpectWrite(rec.Data...)
+		}
+		if rec.N < rec.Len {
+			e.Short(max(rec.N, 0))
+		}
+		switch {
+		case rec.Nack:
+			e.Fail(ErrNack)
+		case rec.Err != "":
+			e.Fail(errors.New(rec.Err))
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,27 @@
+package i2ctest
+
+import (
+	"errors"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// stubName is the adapter name of the i2c-stub kernel module.
+const stubName = "SMBus stub driver"
+
+// ErrNoStub is returned when the i2c-stub bus is not found.
+var ErrNoStub = errors.New("i2ctest: i2c-stub bus not found")
+
+// StubBus returns the number of the bus created by the i2c-stub module.
+func StubBus() (int, error) {
+	buses, err := i2c.ListBuses()
+	if err != nil {
+		return -1, err
+	}
+	for _, b := range buses {
+		if b.Name == stubName && b.Dev {
+			return b.Bus, nil
+		}
+	}
+	return -1, ErrNoStub
+}
@@ -0,0 +1,56 @@
+package i2ctest
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// LoadStub (re)loads the i2c-stub kernel module with chips at addrs, and
+// returns the number of its bus, so that tests can run against a real
+// i2c-dev bus on any Linux box. It needs root privileges, the i2c-dev
+// module and modprobe. The stub only supports SMBus transfers: open its
+// chips with i2c.OpenSMBus, the plain reads and writes of connections
+// opened with i2c.NewI2C fail on it.
+func LoadStub(addrs ...uint8) (int, error) {
+	if len(addrs) == 0 {
+		return -1, fmt.Errorf("i2ctest: no stub chip address")
+	}
+	list := make([]string, len(addrs))
+	for i, a := range addrs {
+		list[i] = fmt.Sprintf("0x%02x", a)
+	}
+	UnloadStub()
+	out, err := exec.Command("modprobe", "i2c-stub", "chip_addr="+strings.Join(list, ",")).CombinedOutput()
+	if err != nil {
+		return -1, fmt.Errorf("i2ctest: modprobe i2c-stub: %v: %s", err, out)
+	}
+	return StubBus()
+}
+
+// UnloadStub unloads the i2c-stub kernel module, discarding its chips.
+func UnloadStub() error {
+	out, err := exec.Command("modprobe", "-r", "i2c-stub").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("i2ctest: modprobe -r i2c-stub: %v: %s", err, out)
+	}
+	return nil
+}
+
+// SeedStub sets the registers of the stub chip at addr on bus with SMBus
+// byte data writes.
+func SeedStub(bus int, addr uint8, regs map[byte]byte) error {
+	v, err := i2c.OpenSMBus(addr, bus)
+	if err != nil {
+		return err
+	}
+	defer v.Close()
+	for r, b := range regs {
+		if err := v.WriteRegU8(r, b); err != nil {
+			return fmt.Errorf("i2ctest: seed register %#02x: %w", r, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package i2ctest_test
+
+import (
+	"slices"
+	"testing"
+
+	i2c "github.com/fedeonline/i2c-go"
+	"github.com/fedeonline/i2c-go/i2ctest"
+)
+
+func TestStub(t *testing.T) {
+	bus, err := i2ctest.LoadStub(0x50)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer i2ctest.UnloadStub()
+	if err := i2ctest.SeedStub(bus, 0x50, map[byte]byte{0x00: 0x5A}); err != nil {
+		t.Fatal(err)
+	}
+	v, err := i2c.OpenSMBus(0x50, bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Close()
+	if b, err := v.ReadRegU8(0x00); err != nil || b != 0x5A {
+		t.Fatalf("seeded register: got 0x%02X, %v, want 0x5A", b, err)
+	}
+	want := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	if _, err := v.WriteRegBytes(0x10, want); err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, len(want))
+	if _, err := v.ReadRegBytesInto(0x10, got); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("read % X, want % X", got, want)
+	}
+}
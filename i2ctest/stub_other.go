@@ -0,0 +1,20 @@
+//go:build !linux
+
+package i2ctest
+
+import i2c "github.com/fedeonline/i2c-go"
+
+// LoadStub is only supported on Linux.
+func LoadStub(addrs ...uint8) (int, error) {
+	return -1, i2c.ErrUnsupported
+}
+
+// UnloadStub is only supported on Linux.
+func UnloadStub() error {
+	return i2c.ErrUnsupported
+}
+
+// SeedStub is only supported on Linux.
+func SeedStub(bus int, addr uint8, regs map[byte]byte) error {
+	return i2c.ErrUnsupported
+}
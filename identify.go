@@ -0,0 +1,107 @@
+package i2c
+
+import (
+	"slices"
+	"sync"
+)
+
+// Chip describes how to recognize a chip model by its identification
+// (WHO_AM_I) register.
+type Chip struct {
+	Name  string
+	Addrs []uint8
+	// Reg is the identification register, read as a Len bytes (1 or 2)
+	// big endian value which matches when value&Mask == Value.
+	Reg   byte
+	Len   int
+	Mask  uint16
+	Value uint16
+}
+
+var (
+	chipsMu sync.RWMutex
+	chips   = []Chip{
+		{"BMP180", []uint8{0x77}, 0xD0, 1, 0xFF, 0x55},
+		{"BMP280", []uint8{0x76, 0x77}, 0xD0, 1, 0xFF, 0x58},
+		{"BME280", []uint8{0x76, 0x77}, 0xD0, 1, 0xFF, 0x60},
+		{"BME680", []uint8{0x76, 0x77}, 0xD0, 1, 0xFF, 0x61},
+		{"MPU6050", []uint8{0x68, 0x69}, 0x75, 1, 0x7E, 0x68},
+		{"MPU9250", []uint8{0x68, 0x69}, 0x75, 1, 0xFF, 0x71},
+		{"BMI160", []uint8{0x68, 0x69}, 0x00, 1, 0xFF, 0xD1},
+		{"ICM20948", []uint8{0x68, 0x69}, 0x00, 1, 0xFF, 0xEA},
+		{"LSM6DS3", []uint8{0x6A, 0x6B}, 0x0F, 1, 0xFF, 0x69},
+		{"LSM6DSO", []uint8{0x6A, 0x6B}, 0x0F, 1, 0xFF, 0x6C},
+		{"LIS3DH", []uint8{0x18, 0x19}, 0x0F, 1, 0xFF, 0x33},
+		{"LIS3MDL", []uint8{0x1C, 0x1E}, 0x0F, 1, 0xFF, 0x3D},
+		{"HMC5883L", []uint8{0x1E}, 0x0A, 1, 0xFF, 'H'},
+		{"QMC5883L", []uint8{0x0D}, 0x0D, 1, 0xFF, 0xFF},
+		{"ADXL345", []uint8{0x1D, 0x53}, 0x00, 1, 0xFF, 0xE5},
+		{"BNO055", []uint8{0x28, 0x29}, 0x00, 1, 0xFF, 0xA0},
+		{"VL53L0X", []uint8{0x29}, 0xC0, 1, 0xFF, 0xEE},
+		{"CCS811", []uint8{0x5A, 0x5B}, 0x20, 1, 0xFF, 0x81},
+		{"MAX30102", []uint8{0x57}, 0xFF, 1, 0xFF, 0x15},
+		{"MCP9808", []uint8{0x18, 0x19, 0x1A, 0x1B, 0x1C, 0x1D, 0x1E, 0x1F}, 0x07, 2, 0xFF00, 0x0400},
+		{"TMP117", []uint8{0x48, 0x49, 0x4A, 0x4B}, 0x0F, 2, 0x0FFF, 0x0117},
+		{"INA226", []uint8{0x40, 0x41, 0x44, 0x45}, 0xFF, 2, 0xFFF0, 0x2260},
+	}
+)
+
+// RegisterChip adds c to the chips recognized by Identify.
+func RegisterChip(c Chip) {
+	chipsMu.Lock()
+	defer chipsMu.Unlock()
+	chips = append(chips, c)
+}
+
+// Identify opens the device at addr on bus and returns the names of the
+// known chip models it matches, see (*I2C).Identify.
+func Identify(bus int, addr uint8) ([]string, error) {
+	v, err := NewI2C(addr, bus)
+	if err != nil {
+		return nil, err
+	}
+	defer v.Close()
+	return v.Identify()
+}
+
+// Identify reads the identification registers of the known chip models
+// using the address of the device, and returns the names of the ones
+// matching, e.g. to check that the expected sensors are populated on a
+// board. Several models may match, as some chips share identification
+// values. Identification registers which cannot be read are skipped, an
+// error is only returned when the device does not respond at all.
+func (v *I2C) Identify() ([]string, error) {
+	chipsMu.RLock()
+	list := slices.Clone(chips)
+	chipsMu.RUnlock()
+	addr := v.Addr()
+	var (
+		names []string
+		err   error
+		read  bool
+	)
+	for _, c := range list {
+		if !slices.Contains(c.Addrs, addr) {
+			continue
+		}
+		var id uint16
+		if c.Len == 2 {
+			id, err = v.ReadRegU16BE(c.Reg)
+		} else {
+			var b byte
+			b, err = v.ReadRegU8(c.Reg)
+			id = uint16(b)
+		}
+		if err != nil {
+			continue
+		}
+		read = true
+		if id&c.Mask == c.Value {
+			names = append(names, c.Name)
+		}
+	}
+	if !read && err != nil {
+		return nil, err
+	}
+	return names, nil
+}
@@ -0,0 +1,116 @@
+// Package cli holds the argument parsing and value formatting shared by
+// the commands of the module.
+package cli
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// Open opens the device at the address addr on bus, a bus number, i2c-N
+// name, device tree alias or node path. Numbers are decimal or prefixed
+// with 0x.
+func Open(bus, addr string) (*i2c.I2C, error) {
+	n, err := i2c.ResolveBus(bus)
+	if err != nil {
+		return nil, err
+	}
+	a, err := ParseUint(addr, 8)
+	if err != nil {
+		return nil, fmt.Errorf("address %q: %w", addr, err)
+	}
+	return i2c.NewI2C(uint8(a), n)
+}
+
+// ParseUint parses the bits wide unsigned number s, decimal or prefixed
+// with 0x, 0o or 0b.
+func ParseUint(s string, bits int) (uint64, error) {
+	x, err := strconv.ParseUint(s, 0, bits)
+	if err != nil {
+		return 0, err.(*strconv.NumError).Err
+	}
+	return x, nil
+}
+
+// ParseValue parses s as a width bytes wide value, either unsigned or,
+// with a leading minus, signed. The result holds the two's complement
+// of negative values in its width low bytes.
+func ParseValue(s string, width int) (uint64, error) {
+	if strings.HasPrefix(s, "-") {
+		x, err := strconv.ParseInt(s, 0, width*8)
+		if err != nil {
+			return 0, err.(*strconv.NumError).Err
+		}
+		return uint64(x) & mask(width), nil
+	}
+	return ParseUint(s, width*8)
+}
+
+// CheckWidth reports an error when width is not a supported register
+// width, 1 to 8 bytes.
+func CheckWidth(width int) error {
+	if width < 1 || width > 8 {
+		return fmt.Errorf("width %d: must be 1 to 8 bytes", width)
+	}
+	return nil
+}
+
+// Order returns the byte order selected by the -le flags.
+func Order(le bool) binary.ByteOrder {
+	if le {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+// Decode returns the value stored in b with the given byte order.
+func Decode(b []byte, order binary.ByteOrder) uint64 {
+	var x uint64
+	for i := range b {
+		c := b[i]
+		if order == binary.LittleEndian {
+			c = b[len(b)-1-i]
+		}
+		x = x<<8 | uint64(c)
+	}
+	return x
+}
+
+// Encode stores the len(b) low bytes of x in b with the given byte order.
+func Encode(b []byte, x uint64, order binary.ByteOrder) {
+	for i := len(b) - 1; i >= 0; i-- {
+		if order == binary.LittleEndian {
+			b[len(b)-1-i] = byte(x)
+		} else {
+			b[i] = byte(x)
+		}
+		x >>= 8
+	}
+}
+
+// Format formats the width bytes wide value x as "hex" (0x prefixed,
+// zero padded), "dec" or "signed" (two's complement).
+func Format(x uint64, width int, format string) (string, error) {
+	switch format {
+	case "hex":
+		return fmt.Sprintf("0x%0*x", width*2, x), nil
+	case "dec":
+		return strconv.FormatUint(x, 10), nil
+	case "signed":
+		shift := 64 - width*8
+		return strconv.FormatInt(int64(x<<shift)>>shift, 10), nil
+	}
+	return "", fmt.Errorf("format %q: must be hex, dec or signed", format)
+}
+
+// mask returns the mask of the width low bytes.
+func mask(width int) uint64 {
+	if width >= 8 {
+		return ^uint64(0)
+	}
+	return 1<<(width*8) - 1
+}
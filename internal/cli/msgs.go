@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// ParseMsgs parses i2ctransfer message descriptions {r|w}LENGTH[@ADDR],
+// each write followed by its data bytes. The first message must carry the
+// address, the next ones default to the address of the previous message.
+// A data byte ending with = repeats it up to the end of the message,
+// ending with + or - increments or decrements it for every following
+// byte.
+func ParseMsgs(args []string) ([]i2c.Msg, error) {
+	var msgs []i2c.Msg
+	var addr uint8
+	for len(args) > 0 {
+		desc := args[0]
+		args = args[1:]
+		m, err := parseDesc(desc, &addr)
+		if err != nil {
+			return nil, fmt.Errorf("message %q: %w", desc, err)
+		}
+		if !m.Read {
+			n, err := fill(m.Buf, args)
+			if err != nil {
+				return nil, fmt.Errorf("message %q: %w", desc, err)
+			}
+			args = args[n:]
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, nil
+}
+
+// parseDesc parses the message description {r|w}LENGTH[@ADDR], addr
+// holding the address of the previous message.
+func parseDesc(desc string, addr *uint8) (i2c.Msg, error) {
+	var m i2c.Msg
+	switch {
+	case strings.HasPrefix(desc, "r"):
+		m.Read = true
+	case strings.HasPrefix(desc, "w"):
+	default:
+		return m, errors.New("must start with r or w")
+	}
+	length, at, ok := strings.Cut(desc[1:], "@")
+	n, err := strconv.ParseUint(length, 10, 16)
+	if err != nil {
+		return m, errors.New("invalid length")
+	}
+	if ok {
+		a, err := ParseUint(at, 8)
+		if err != nil {
+			return m, fmt.Errorf("address: %w", err)
+		}
+		if err := i2c.ValidateAddr(uint8(a)); err != nil {
+			return m, err
+		}
+		*addr = uint8(a)
+	}
+	if *addr == 0 {
+		return m, errors.New("missing address")
+	}
+	m.Addr = *addr
+	m.Buf = make([]byte, n)
+	return m, nil
+}
+
+// fill fills buf with the data bytes of a write message at the start of
+// args, returning the count of arguments used.
+func fill(buf []byte, args []string) (int, error) {
+	used := 0
+	for i := 0; i < len(buf); {
+		if used == len(args) {
+			return 0, fmt.Errorf("%d data bytes missing", len(buf)-i)
+		}
+		s := args[used]
+		used++
+		suffix := byte(0)
+		if k := len(s) - 1; k > 0 && strings.IndexByte("=+-", s[k]) >= 0 {
+			s, suffix = s[:k], s[k]
+		}
+		x, err := ParseUint(s, 8)
+		if err != nil {
+			return 0, fmt.Errorf("data %q: %w", args[used-1], err)
+		}
+		b := byte(x)
+		if suffix == 0 {
+			buf[i] = b
+			i++
+			continue
+		}
+		for ; i < len(buf); i++ {
+			buf[i] = b
+			switch suffix {
+			case '+':
+				b++
+			case '-':
+				b--
+			}
+		}
+	}
+	return used, nil
+}
+
+// FormatBytes formats b as space separated 0x prefixed hex bytes, as
+// i2ctransfer prints them.
+func FormatBytes(b []byte) string {
+	s := make([]string, len(b))
+	for i, c := range b {
+		s[i] = fmt.Sprintf("0x%02x", c)
+	}
+	return strings.Join(s, " ")
+}
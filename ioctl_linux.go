@@ -0,0 +1,120 @@
+//go:build !baremetal
+
+package i2c
+
+import (
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// ioctlReq is an i2c-dev ioctl request, from linux/i2c-dev.h.
+type ioctlReq uintptr
+
+const (
+	i2cRetries    ioctlReq = 0x0701
+	i2cTimeout    ioctlReq = 0x0702
+	i2cSlave      ioctlReq = 0x0703
+	i2cTenBit     ioctlReq = 0x0704
+	i2cFuncs      ioctlReq = 0x0705
+	i2cSlaveForce ioctlReq = 0x0706
+	i2cRdwr       ioctlReq = 0x0707
+	i2cPEC        ioctlReq = 0x0708
+	i2cSMBus      ioctlReq = 0x0720
+)
+
+// i2c_msg flags, from linux/i2c.h.
+const (
+	i2cMRd      = 0x0001
+	i2cMTen     = 0x0010
+	i2cMNoStart = 0x4000
+)
+
+// i2cMsg mirrors struct i2c_msg of linux/i2c.h.
+type i2cMsg struct {
+	addr  uint16
+	flags uint16
+	len   uint16
+	buf   unsafe.Pointer
+}
+
+// i2cRdwrData mirrors struct i2c_rdwr_ioctl_data of linux/i2c-dev.h.
+type i2cRdwrData struct {
+	msgs  unsafe.Pointer
+	nmsgs uint32
+}
+
+// i2cSMBusData mirrors struct i2c_smbus_ioctl_data of linux/i2c-dev.h.
+type i2cSMBusData struct {
+	readWrite uint8
+	command   uint8
+	size      uint32
+	data      unsafe.Pointer
+}
+
+// The layouts above must match the kernel ones on every GOARCH: pointers
+// are 4 bytes and 4 aligned on 32 bit targets (arm, 386, mips), 8 bytes
+// and 8 aligned on 64 bit ones, which changes both padding and size.
+// Indexing a one element array with a non zero constant fails to compile.
+const ptrSize = unsafe.Sizeof(uintptr(0))
+
+var _ = [1]struct{}{}[unsafe.Sizeof(i2cMsg{})-(8+ptrSize)]
+var _ = [1]struct{}{}[unsafe.Offsetof(i2cMsg{}.buf)-8]
+var _ = [1]struct{}{}[unsafe.Sizeof(i2cRdwrData{})-2*ptrSize]
+var _ = [1]struct{}{}[unsafe.Offsetof(i2cRdwrData{}.nmsgs)-ptrSize]
+var _ = [1]struct{}{}[unsafe.Sizeof(i2cSMBusData{})-(8+ptrSize)]
+var _ = [1]struct{}{}[unsafe.Offsetof(i2cSMBusData{}.data)-8]
+
+func ioctl(fd uintptr, req ioctlReq, arg uintptr) error {
+	_, _, err := unix.Syscall(unix.SYS_IOCTL, fd, uintptr(req), arg)
+	if err != 0 {
+		return err
+	}
+	return nil
+}
+
+// ioctlPtr issues req passing a pointer to a kernel structure, keeping
+// the structure alive for the duration of the call.
+func ioctlPtr(fd uintptr, req ioctlReq, arg unsafe.Pointer) error {
+	_, _, err := unix.Syscall(unix.SYS_IOCTL, fd, uintptr(req), uintptr(arg))
+	runtime.KeepAlive(arg)
+	if err != 0 {
+		return err
+	}
+	return nil
+}
+
+// rdwr performs msgs as a single combined transaction, with repeated
+// start conditions between messages.
+func rdwr(fd uintptr, msgs []i2cMsg) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	data := i2cRdwrData{msgs: unsafe.Pointer(&msgs[0]), nmsgs: uint32(len(msgs))}
+	err := ioctlPtr(fd, i2cRdwr, unsafe.Pointer(&data))
+	runtime.KeepAlive(msgs)
+	return err
+}
+
+// newMsg returns the i2c_msg transferring buf with the device at addr.
+func newMsg(addr uint16, flags uint16, buf []byte) i2cMsg {
+	m := i2cMsg{addr: addr, flags: flags, len: uint16(len(buf))}
+	if len(buf) > 0 {
+		m.buf = unsafe.Pointer(&buf[0])
+	}
+	return m
+}
+
+// control runs fn with the descriptor of the connection, if it has one.
+func (v *I2C) control(fn func(fd uintptr) error) error {
+	rc, err := v.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var ferr error
+	if err := rc.Control(func(fd uintptr) { ferr = fn(fd) }); err != nil {
+		return err
+	}
+	return ferr
+}
@@ -0,0 +1,58 @@
+//go:build !baremetal
+
+package i2c
+
+import (
+	"runtime"
+	"testing"
+	"unsafe"
+)
+
+// kernelLayout is the layout of the i2c-dev structures on a GOARCH, as
+// laid out by the kernel headers:
+//
+//	struct i2c_msg { __u16 addr; __u16 flags; __u16 len; __u8 *buf; };
+//	struct i2c_rdwr_ioctl_data { struct i2c_msg *msgs; __u32 nmsgs; };
+//	struct i2c_smbus_ioctl_data { __u8 read_write; __u8 command; __u32 size; union i2c_smbus_data *data; };
+type kernelLayout struct {
+	msgSize, msgBuf                    uintptr
+	rdwrSize, rdwrNmsgs                uintptr
+	smbusSize, smbusSizeOff, smbusData uintptr
+}
+
+var (
+	layout32 = kernelLayout{12, 8, 8, 4, 12, 4, 8}
+	layout64 = kernelLayout{16, 8, 16, 8, 16, 4, 8}
+)
+
+// kernelLayouts lists the layouts of the Linux GOARCHes.
+var kernelLayouts = map[string]kernelLayout{
+	"386":      layout32,
+	"arm":      layout32,
+	"mips":     layout32,
+	"mipsle":   layout32,
+	"amd64":    layout64,
+	"arm64":    layout64,
+	"loong64":  layout64,
+	"mips64":   layout64,
+	"mips64le": layout64,
+	"ppc64":    layout64,
+	"ppc64le":  layout64,
+	"riscv64":  layout64,
+	"s390x":    layout64,
+}
+
+func TestIoctlLayout(t *testing.T) {
+	want, ok := kernelLayouts[runtime.GOARCH]
+	if !ok {
+		t.Skipf("no kernel layout for %s", runtime.GOARCH)
+	}
+	got := kernelLayout{
+		unsafe.Sizeof(i2cMsg{}), unsafe.Offsetof(i2cMsg{}.buf),
+		unsafe.Sizeof(i2cRdwrData{}), unsafe.Offsetof(i2cRdwrData{}.nmsgs),
+		unsafe.Sizeof(i2cSMBusData{}), unsafe.Offsetof(i2cSMBusData{}.size), unsafe.Offsetof(i2cSMBusData{}.data),
+	}
+	if got != want {
+		t.Fatalf("layout on %s: got %+v, want %+v", runtime.GOARCH, got, want)
+	}
+}
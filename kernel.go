@@ -0,0 +1,105 @@
+package i2c
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// sysfsWrite writes s to the sysfs attribute path.
+func sysfsWrite(path, s string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(s); err != nil {
+		f.Close()
+		return fmt.Errorf("i2c: write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// NewKernelDevice instantiates a kernel device of type name (e.g.
+// "lm75", "24c02") at addr on bus through the new_device sysfs
+// attribute, handing the chip to the kernel driver, e.g. after a
+// userspace provisioning step. It needs root privileges.
+func NewKernelDevice(bus int, name string, addr uint8) error {
+	if err := ValidateAddr(addr); err != nil {
+		return err
+	}
+	dir := filepath.Join(sysBusDevices, "i2c-"+strconv.Itoa(bus))
+	return sysfsWrite(filepath.Join(dir, "new_device"), fmt.Sprintf("%s 0x%02x\n", name, addr))
+}
+
+// DeleteKernelDevice removes the kernel device at addr on bus, previously
+// instantiated through new_device, by the delete_device sysfs attribute.
+// Devices declared by the device tree or ACPI cannot be removed this way.
+func DeleteKernelDevice(bus int, addr uint8) error {
+	dir := filepath.Join(sysBusDevices, "i2c-"+strconv.Itoa(bus))
+	return sysfsWrite(filepath.Join(dir, "delete_device"), fmt.Sprintf("0x%02x\n", addr))
+}
+
+// kernelName returns the sysfs name of the device at addr on bus.
+func kernelName(bus int, addr uint8) string {
+	return fmt.Sprintf("%d-%04x", bus, addr)
+}
+
+// KernelDriver returns the name of the kernel driver bound to the device
+// at addr on bus, or "" when there is none.
+func KernelDriver(bus int, addr uint8) (string, error) {
+	path, err := os.Readlink(filepath.Join(sysBusDevices, kernelName(bus, addr), "driver"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(path), nil
+}
+
+// UnbindDriver detaches the kernel driver bound to the device at addr on
+// bus, returning its name for BindDriver, or "" when there was none. The
+// kernel device stays instantiated.
+func UnbindDriver(bus int, addr uint8) (string, error) {
+	drv, err := KernelDriver(bus, addr)
+	if err != nil || drv == "" {
+		return "", err
+	}
+	path := filepath.Join("/sys/bus/i2c/drivers", drv, "unbind")
+	return drv, sysfsWrite(path, kernelName(bus, addr))
+}
+
+// BindDriver attaches the kernel driver named driver to the device at
+// addr on bus.
+func BindDriver(bus int, addr uint8, driver string) error {
+	path := filepath.Join("/sys/bus/i2c/drivers", driver, "bind")
+	return sysfsWrite(path, kernelName(bus, addr))
+}
+
+// NewI2CUnbind is NewI2C for devices which may be claimed by a kernel
+// driver: when the address is busy, the driver is unbound and the
+// connection opened again, and Close binds the driver back. It needs root
+// privileges to unbind.
+func NewI2CUnbind(addr uint8, bus int) (*I2C, error) {
+	v, err := NewI2C(addr, bus)
+	if !errors.Is(err, syscall.EBUSY) {
+		return v, err
+	}
+	drv, err := UnbindDriver(bus, addr)
+	if err != nil {
+		return nil, err
+	}
+	if drv == "" {
+		return nil, fmt.Errorf("i2c: address %#02x on bus %d busy without a driver: %w", addr, bus, syscall.EBUSY)
+	}
+	v, err = NewI2C(addr, bus)
+	if err != nil {
+		BindDriver(bus, addr, drv)
+		return nil, err
+	}
+	v.rebind = func() error { return BindDriver(bus, addr, drv) }
+	return v, nil
+}
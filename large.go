@@ -0,0 +1,125 @@
+package i2c
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// defaultChunk is the chunk size of large transfers when none is given,
+// matching the SMBus block size supported by every adapter.
+const defaultChunk = 32
+
+// LargeTransfer configures a multi-kilobyte transfer split in chunks, such
+// as a framebuffer upload or an EEPROM image dump.
+type LargeTransfer struct {
+	// Chunk is the count of data bytes per transaction, 32 when zero.
+	Chunk int
+	// Prefix, if not nil, returns the bytes sent before the chunk
+	// starting at off: the register or memory address for auto-increment
+	// devices, a control byte for displays. When nil each chunk continues
+	// the previous transfer.
+	Prefix func(off int) []byte
+	// Progress, if not nil, is called after each chunk.
+	Progress func(done, total int)
+	// Rate caps the throughput in bytes per second, 0 is unlimited.
+	Rate int
+}
+
+// ReadLarge read len(buf) bytes from the device in chunks. It stops with
+// ctx's error when ctx is done, returning the count of bytes read so far.
+// Like the other reads it fails with ErrWarmingUp during the warm-up
+// period of the device.
+func (v *I2C) ReadLarge(ctx context.Context, buf []byte, lt LargeTransfer) (int, error) {
+	if err := v.Ready(); err != nil {
+		return 0, err
+	}
+	return lt.run(ctx, len(buf), io.ErrUnexpectedEOF, func(off, end int) (n int, err error) {
+		err = v.doContext(ctx, func() error {
+			if lt.Prefix != nil {
+				if _, err := v.xfer(OpWrite, NoReg, lt.Prefix(off)); err != nil {
+					return err
+				}
+			}
+			n, err = v.xfer(OpRead, NoReg, buf[off:end])
+			return err
+		})
+		return n, err
+	})
+}
+
+// WriteLarge write buf to the device in chunks. It stops with ctx's error
+// when ctx is done, returning the count of bytes written so far.
+func (v *I2C) WriteLarge(ctx context.Context, buf []byte, lt LargeTransfer) (int, error) {
+	return lt.run(ctx, len(buf), io.ErrShortWrite, func(off, end int) (int, error) {
+		var pre []byte
+		if lt.Prefix != nil {
+			pre = lt.Prefix(off)
+		}
+		msg := append(pre[:len(pre):len(pre)], buf[off:end]...)
+		var n int
+		err := v.doContext(ctx, func() (err error) {
+			n, err = v.xfer(OpWrite, NoReg, msg)
+			return err
+		})
+		n -= len(pre)
+		if n < 0 {
+			n = 0
+		}
+		return n, err
+	})
+}
+
+// run calls fn for each chunk of the transfer, shortErr is returned when
+// fn transfers less than requested.
+func (lt *LargeTransfer) run(ctx context.Context, total int, shortErr error, fn func(off, end int) (int, error)) (int, error) {
+	chunk := lt.Chunk
+	if chunk <= 0 {
+		chunk = defaultChunk
+	}
+	start := time.Now()
+	done := 0
+	for done < total {
+		if err := ctx.Err(); err != nil {
+			return done, err
+		}
+		end := done + chunk
+		if end > total {
+			end = total
+		}
+		n, err := fn(done, end)
+		short := n < end-done
+		done += n
+		if lt.Progress != nil {
+			lt.Progress(done, total)
+		}
+		if err != nil {
+			return done, err
+		}
+		if short {
+			return done, shortErr
+		}
+		if lt.Rate > 0 {
+			due := start.Add(time.Duration(done) * time.Second / time.Duration(lt.Rate))
+			if err := sleepCtx(ctx, time.Until(due)); err != nil {
+				return done, err
+			}
+		}
+	}
+	return done, nil
+}
+
+// sleepCtx pauses for d or until ctx is done.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
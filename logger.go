@@ -0,0 +1,69 @@
+package i2c
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+)
+
+// Logger returns a middleware recording every transaction to l at debug
+// level, with address, register, direction, byte count and latency.
+// Failed transactions are recorded at warn level. When hexdump is set the
+// payload is added as a hex string. Records carry the context of the
+// operation, see ReadRegBytesContext, e.g. for handlers adding trace ids.
+func Logger(l *slog.Logger, hexdump bool) Middleware {
+	return func(next Handler) Handler {
+		return func(t *Transaction) {
+			next(t)
+			level := slog.LevelDebug
+			if t.Err != nil {
+				level = slog.LevelWarn
+			}
+			ctx := t.Context
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			if !l.Enabled(ctx, level) {
+				return
+			}
+			attrs := []slog.Attr{
+				slog.Int("bus", t.Bus),
+				slog.Int("addr", int(t.Addr)),
+				slog.String("op", t.Op.String()),
+				slog.Int("bytes", t.N),
+				slog.Duration("latency", t.Duration),
+			}
+			if t.Reg != NoReg {
+				attrs = append(attrs, slog.Int("reg", t.Reg))
+			}
+			if t.Op == OpCombined {
+				attrs = append(attrs, slog.Int("messages", len(t.Msgs)))
+			}
+			switch {
+			case !hexdump:
+			case t.Op == OpCombined && t.Err == nil:
+				// one hex string per message, prefixed by its direction
+				var b strings.Builder
+				for i, m := range t.Msgs {
+					if i > 0 {
+						b.WriteByte(' ')
+					}
+					if m.Read {
+						b.WriteString("r:")
+					} else {
+						b.WriteString("w:")
+					}
+					b.WriteString(hex.EncodeToString(m.Buf))
+				}
+				attrs = append(attrs, slog.String("data", b.String()))
+			case t.N > 0 && t.N <= len(t.Buf):
+				attrs = append(attrs, slog.String("data", hex.EncodeToString(t.Buf[:t.N])))
+			}
+			if t.Err != nil {
+				attrs = append(attrs, slog.Any("err", t.Err))
+			}
+			l.LogAttrs(ctx, level, "i2c transaction", attrs...)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package i2c
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// regField is a struct field mapped to device registers.
+type regField struct {
+	index  int
+	name   string
+	reg    int
+	n      int
+	little bool
+}
+
+var fieldCache sync.Map // reflect.Type -> []regField
+
+// regFields returns the register mapped fields of the struct type t.
+func regFields(t reflect.Type) ([]regField, error) {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]regField), nil
+	}
+	var fields []regField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("i2c")
+		if !ok || tag == "-" {
+			continue
+		}
+		if !sf.IsExported() {
+			return nil, fmt.Errorf("i2c: field %s: unexported", sf.Name)
+		}
+		f, err := parseRegTag(sf, tag)
+		if err != nil {
+			return nil, fmt.Errorf("i2c: field %s: %w", sf.Name, err)
+		}
+		f.index = i
+		fields = append(fields, f)
+	}
+	fieldCache.Store(t, fields)
+	return fields, nil
+}
+
+// parseRegTag parses a tag like "reg=0x10,len=2,order=le". len defaults
+// to the size of the field type, order to big endian.
+func parseRegTag(sf reflect.StructField, tag string) (regField, error) {
+	f := regField{name: sf.Name, reg: -1}
+	for _, kv := range strings.Split(tag, ",") {
+		k, val, _ := strings.Cut(strings.TrimSpace(kv), "=")
+		switch k {
+		case "reg":
+			r, err := strconv.ParseUint(val, 0, 8)
+			if err != nil {
+				return f, fmt.Errorf("bad register %q", val)
+			}
+			f.reg = int(r)
+		case "len":
+			n, err := strconv.ParseUint(val, 0, 8)
+			if err != nil || n == 0 {
+				return f, fmt.Errorf("bad length %q", val)
+			}
+			f.n = int(n)
+		case "order":
+			switch val {
+			case "be":
+			case "le":
+				f.little = true
+			default:
+				return f, fmt.Errorf("bad order %q", val)
+			}
+		default:
+			return f, fmt.Errorf("unknown key %q", k)
+		}
+	}
+	if f.reg < 0 {
+		return f, errors.New("missing register")
+	}
+	size := 0
+	switch k := sf.Type.Kind(); k {
+	case reflect.Bool, reflect.Int8, reflect.Uint8, reflect.Int16, reflect.Uint16,
+		reflect.Int32, reflect.Uint32, reflect.Int64, reflect.Uint64, reflect.Float32:
+		size = int(sf.Type.Size())
+		if f.n == 0 {
+			f.n = size
+		}
+		if f.n > size || (k == reflect.Float32 && f.n != 4) {
+			return f, fmt.Errorf("length %d does not fit %v", f.n, sf.Type)
+		}
+	case reflect.Array:
+		if sf.Type.Elem().Kind() != reflect.Uint8 {
+			return f, fmt.Errorf("unsupported type %v", sf.Type)
+		}
+		if f.n == 0 {
+			f.n = sf.Type.Len()
+		}
+		if f.n != sf.Type.Len() {
+			return f, fmt.Errorf("length %d does not match %v", f.n, sf.Type)
+		}
+	default:
+		return f, fmt.Errorf("unsupported type %v", sf.Type)
+	}
+	if f.reg+f.n > regSpace {
+		return f, ErrRegisterRange
+	}
+	return f, nil
+}
+
+// structValue returns the struct pointed to by p and its register fields.
+func structValue(p any) (reflect.Value, []regField, error) {
+	rv := reflect.ValueOf(p)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, nil, fmt.Errorf("i2c: %T is not a pointer to struct", p)
+	}
+	rv = rv.Elem()
+	fields, err := regFields(rv.Type())
+	return rv, fields, err
+}
+
+// ReadStruct fills the tagged fields of the struct pointed to by p from
+// the device registers, reading the whole register range in one block
+// transfer. Fields are tagged with the register, and optionally the
+// length and byte order:
+//
+//	type Measure struct {
+//		Status  uint8    `i2c:"reg=0x00"`
+//		Temp    int16    `i2c:"reg=0x02,order=le"`
+//		Press   uint32   `i2c:"reg=0x04,len=3"`
+//		Serial  [6]byte  `i2c:"reg=0x10"`
+//	}
+//
+// Signed fields shorter than their type are sign extended.
+func (v *I2C) ReadStruct(p any) error {
+	rv, fields, err := structValue(p)
+	if err != nil || len(fields) == 0 {
+		return err
+	}
+	lo, hi := regSpace, 0
+	for _, f := range fields {
+		lo, hi = min(lo, f.reg), max(hi, f.reg+f.n)
+	}
+	buf := make([]byte, hi-lo)
+	n, err := v.readBlock(context.Background(), byte(lo), buf)
+	if err != nil {
+		return err
+	}
+	if n < len(buf) {
+		return io.ErrUnexpectedEOF
+	}
+	for _, f := range fields {
+		decodeField(rv.Field(f.index), f, buf[f.reg-lo:f.reg-lo+f.n])
+	}
+	return nil
+}
+
+// WriteStruct writes the tagged fields of the struct pointed to by p to
+// the device registers. Fields covering contiguous registers are merged
+// in burst writes, gaps between fields are not written.
+func (v *I2C) WriteStruct(p any) error {
+	rv, fields, err := structValue(p)
+	if err != nil {
+		return err
+	}
+	c := v.Coalesce()
+	for _, f := range fields {
+		buf := make([]byte, f.n)
+		encodeField(rv.Field(f.index), f, buf)
+		if err := c.Write(byte(f.reg), buf); err != nil {
+			return err
+		}
+	}
+	return c.Flush()
+}
+
+func decodeField(fv reflect.Value, f regField, b []byte) {
+	if fv.Kind() == reflect.Array {
+		reflect.Copy(fv, reflect.ValueOf(b))
+		return
+	}
+	var w uint64
+	for _, x := range b {
+		w = w<<8 | uint64(x)
+	}
+	if f.little {
+		w = bswap(w, f.n)
+	}
+	switch fv.Kind() {
+	case reflect.Bool:
+		fv.SetBool(w != 0)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fv.SetInt(SignExtend(w, uint(8*f.n), 0))
+	case reflect.Float32:
+		fv.SetFloat(float64(math.Float32frombits(uint32(w))))
+	default:
+		fv.SetUint(w)
+	}
+}
+
+func encodeField(fv reflect.Value, f regField, b []byte) {
+	if fv.Kind() == reflect.Array {
+		reflect.Copy(reflect.ValueOf(b), fv)
+		return
+	}
+	var w uint64
+	switch fv.Kind() {
+	case reflect.Bool:
+		if fv.Bool() {
+			w = 1
+		}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		w = uint64(fv.Int())
+	case reflect.Float32:
+		w = uint64(math.Float32bits(float32(fv.Float())))
+	default:
+		w = fv.Uint()
+	}
+	if f.little {
+		w = bswap(w, f.n)
+	}
+	for i := f.n - 1; i >= 0; i-- {
+		b[i] = byte(w)
+		w >>= 8
+	}
+}
@@ -0,0 +1,253 @@
+// Package mcp2221 implements an i2c bus master on the Microchip MCP2221A
+// USB HID bridge, splitting transfers in the 60 byte fragments of its
+// HID reports transparently.
+//
+// The package speaks the HID commands of the MCP2221A datasheet over a
+// Device opened by a HID library (e.g. hidapi bindings).
+//
+//	bus, err := mcp2221.NewBus(dev, mcp2221.Config{Freq: 400000})
+//	if err != nil {
+//		return err
+//	}
+//	v := bus.Open(0x76)
+package mcp2221
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+var (
+	// ErrNack is returned when the device does not acknowledge its
+	// address. On Linux i2c.IsNack reports true for it.
+	ErrNack error = syscall.ENXIO
+	// ErrTimeout is returned when a transfer does not complete in time.
+	ErrTimeout = errors.New("mcp2221: transfer timeout")
+)
+
+// Device is a HID connection to an MCP2221A. Reports are 64 bytes long
+// and start with the command code.
+type Device interface {
+	// Write sends an output report.
+	Write(report []byte) (int, error)
+	// Read receives an input report.
+	Read(report []byte) (int, error)
+}
+
+// HID commands, from the MCP2221A datasheet.
+const (
+	cmdStatus    = 0x10
+	cmdWrite     = 0x90
+	cmdRead      = 0x91
+	cmdGetData   = 0x40
+	reportSize   = 64
+	fragment     = 60
+	maxTransfer  = 0xFFFF
+	cancelXfer   = 0x10
+	setSpeed     = 0x20
+	stateIdle    = 0x00
+	addrNack     = 0x40 // status byte 20
+	readError    = 127  // get data length on failure
+	statusFailed = 0x41
+)
+
+// defaultTimeout bounds transfers when no timeout is configured.
+const defaultTimeout = time.Second
+
+// Config configures a Bus.
+type Config struct {
+	// Freq is the clock frequency in Hz, 100kHz when zero.
+	Freq int
+	// Timeout bounds each transfer, 1s when zero.
+	Timeout time.Duration
+}
+
+// Bus is an i2c bus mastered by an MCP2221A.
+type Bus struct {
+	mu      sync.Mutex
+	dev     Device
+	timeout time.Duration
+	out     [reportSize]byte
+	in      [reportSize]byte
+}
+
+// NewBus configures dev as an i2c master, cancelling any transfer left
+// pending by a previous user.
+func NewBus(dev Device, cfg Config) (*Bus, error) {
+	if cfg.Freq <= 0 {
+		cfg.Freq = 100000
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	b := &Bus{dev: dev, timeout: cfg.Timeout}
+	if _, err := b.cmd(cmdStatus, 0, cancelXfer); err != nil {
+		return nil, fmt.Errorf("mcp2221: cancel: %w", err)
+	}
+	div := max(12000000/cfg.Freq-3, 0)
+	if _, err := b.cmd(cmdStatus, 0, 0, setSpeed, byte(div)); err != nil {
+		return nil, fmt.Errorf("mcp2221: set speed: %w", err)
+	}
+	return b, nil
+}
+
+// cmd sends a report made of args and returns the response.
+func (b *Bus) cmd(args ...byte) ([]byte, error) {
+	clear(b.out[:])
+	copy(b.out[:], args)
+	if _, err := b.dev.Write(b.out[:]); err != nil {
+		return nil, err
+	}
+	if _, err := b.dev.Read(b.in[:]); err != nil {
+		return nil, err
+	}
+	if b.in[0] != args[0] {
+		return nil, fmt.Errorf("mcp2221: unexpected response %#02x to command %#02x", b.in[0], args[0])
+	}
+	return b.in[:], nil
+}
+
+// idle waits for the bridge to complete the transfer, cancelling it when
+// the device does not acknowledge its address.
+func (b *Bus) idle(deadline time.Time) error {
+	for {
+		r, err := b.cmd(cmdStatus)
+		if err != nil {
+			return err
+		}
+		if r[20]&addrNack != 0 {
+			b.cmd(cmdStatus, 0, cancelXfer)
+			return ErrNack
+		}
+		if r[8] == stateIdle {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			b.cmd(cmdStatus, 0, cancelXfer)
+			return ErrTimeout
+		}
+	}
+}
+
+func (b *Bus) write(addr uint8, p []byte) (int, error) {
+	if len(p) > maxTransfer {
+		return 0, fmt.Errorf("mcp2221: write of %d bytes too long", len(p))
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	deadline := time.Now().Add(b.timeout)
+	n := 0
+	for first := true; first || n < len(p); first = false {
+		end := min(n+fragment, len(p))
+		// every fragment repeats the header with the total length
+		args := append([]byte{cmdWrite, byte(len(p)), byte(len(p) >> 8), addr << 1}, p[n:end]...)
+		for {
+			r, err := b.cmd(args...)
+			if err != nil {
+				return n, err
+			}
+			if r[1] == 0 {
+				break
+			}
+			// the bridge is busy sending the previous fragment
+			if time.Now().After(deadline) {
+				b.cmd(cmdStatus, 0, cancelXfer)
+				return n, ErrTimeout
+			}
+		}
+		n = end
+	}
+	if err := b.idle(deadline); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (b *Bus) read(addr uint8, p []byte) (int, error) {
+	if len(p) > maxTransfer {
+		return 0, fmt.Errorf("mcp2221: read of %d bytes too long", len(p))
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	deadline := time.Now().Add(b.timeout)
+	r, err := b.cmd(cmdRead, byte(len(p)), byte(len(p)>>8), addr<<1|1)
+	if err != nil {
+		return 0, err
+	}
+	if r[1] != 0 {
+		b.cmd(cmdStatus, 0, cancelXfer)
+		return 0, fmt.Errorf("mcp2221: read rejected")
+	}
+	n := 0
+	for n < len(p) {
+		r, err := b.cmd(cmdGetData)
+		if err != nil {
+			return n, err
+		}
+		if r[1] == statusFailed || r[3] == readError {
+			b.cmd(cmdStatus, 0, cancelXfer)
+			if n == 0 {
+				return 0, ErrNack
+			}
+			return n, fmt.Errorf("mcp2221: read failed")
+		}
+		n += copy(p[n:], r[4:4+min(int(r[3]), fragment)])
+		if n < len(p) && time.Now().After(deadline) {
+			b.cmd(cmdStatus, 0, cancelXfer)
+			return n, ErrTimeout
+		}
+	}
+	return n, nil
+}
+
+// Conn returns a transport to addr on the bus, for i2c.NewI2CConn.
+func (b *Bus) Conn(addr uint8) *Conn {
+	return &Conn{bus: b, addr: addr}
+}
+
+// Open returns a connection to the device at addr on the bus.
+func (b *Bus) Open(addr uint8) *i2c.I2C {
+	return i2c.NewI2CConn(b.Conn(addr), addr)
+}
+
+// Conn is an i2c.Conn to an address of a Bus. It implements
+// i2c.Addresser, so connections can be retargeted with SetAddr.
+type Conn struct {
+	bus  *Bus
+	mu   sync.Mutex
+	addr uint8
+}
+
+func (c *Conn) target() uint8 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.addr
+}
+
+// Write sends p to the device in one transfer.
+func (c *Conn) Write(p []byte) (int, error) {
+	return c.bus.write(c.target(), p)
+}
+
+// Read receives p from the device in one transfer.
+func (c *Conn) Read(p []byte) (int, error) {
+	return c.bus.read(c.target(), p)
+}
+
+// SetAddr retargets the connection to addr.
+func (c *Conn) SetAddr(addr uint8) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addr = addr
+	return nil
+}
+
+// Close does nothing, the Device is owned by the caller.
+func (c *Conn) Close() error {
+	return nil
+}
@@ -0,0 +1,67 @@
+package i2c
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrNameTaken is returned when registering a device under a name which
+// is already in use.
+var ErrNameTaken = errors.New("i2c: device name already registered")
+
+var (
+	namesMu sync.RWMutex
+	names   = make(map[string]*I2C)
+)
+
+// RegisterDevice makes v available under name (e.g. "outdoor-temp") to
+// other components of the program, such as debug endpoints or metrics.
+// The name is released by UnregisterDevice or when v is closed.
+func RegisterDevice(name string, v *I2C) error {
+	namesMu.Lock()
+	defer namesMu.Unlock()
+	if _, ok := names[name]; ok {
+		return ErrNameTaken
+	}
+	names[name] = v
+	return nil
+}
+
+// UnregisterDevice releases name.
+func UnregisterDevice(name string) {
+	namesMu.Lock()
+	defer namesMu.Unlock()
+	delete(names, name)
+}
+
+// LookupDevice returns the device registered under name.
+func LookupDevice(name string) (*I2C, bool) {
+	namesMu.RLock()
+	defer namesMu.RUnlock()
+	v, ok := names[name]
+	return v, ok
+}
+
+// DeviceNames returns the names of the registered devices, sorted.
+func DeviceNames() []string {
+	namesMu.RLock()
+	defer namesMu.RUnlock()
+	l := make([]string, 0, len(names))
+	for n := range names {
+		l = append(l, n)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// unregisterAll releases all the names of v.
+func unregisterAll(v *I2C) {
+	namesMu.Lock()
+	defer namesMu.Unlock()
+	for n, d := range names {
+		if d == v {
+			delete(names, n)
+		}
+	}
+}
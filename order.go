@@ -0,0 +1,129 @@
+package i2c
+
+import "encoding/binary"
+
+// little reports whether order stores the least significant byte first.
+// The standard orders are recognized without calling through the
+// interface, which keeps the register helpers allocation free.
+func little(order binary.ByteOrder) bool {
+	switch order {
+	case binary.BigEndian:
+		return false
+	case binary.LittleEndian:
+		return true
+	}
+	var b [2]byte
+	order.PutUint16(b[:], 1)
+	return b[0] == 1
+}
+
+// bswap reverses the order of the n low bytes of w.
+func bswap(w uint64, n int) uint64 {
+	var r uint64
+	for i := 0; i < n; i++ {
+		r = r<<8 | w&0xFF
+		w >>= 8
+	}
+	return r
+}
+
+// readRegOrder read the n bytes long value starting from register reg,
+// stored with the given byte order.
+func (v *I2C) readRegOrder(reg byte, n int, order binary.ByteOrder) (uint64, error) {
+	w, err := v.readRegN(reg, n)
+	if err != nil {
+		return 0, err
+	}
+	if little(order) {
+		w = bswap(w, n)
+	}
+	return w, nil
+}
+
+// writeRegOrder write the n bytes long value w starting from register
+// reg, stored with the given byte order.
+func (v *I2C) writeRegOrder(reg byte, w uint64, n int, order binary.ByteOrder) error {
+	if little(order) {
+		w = bswap(w, n)
+	}
+	return v.writeRegN(reg, w, n)
+}
+
+// ReadRegU16 read unsigned word (16 bits) stored with the given byte
+// order from i2c device starting from address specified in reg.
+func (v *I2C) ReadRegU16(reg byte, order binary.ByteOrder) (uint16, error) {
+	w, err := v.readRegOrder(reg, 2, order)
+	return uint16(w), err
+}
+
+// ReadRegS16 read signed word (16 bits) stored with the given byte order
+// from i2c device starting from address specified in reg.
+func (v *I2C) ReadRegS16(reg byte, order binary.ByteOrder) (int16, error) {
+	w, err := v.readRegOrder(reg, 2, order)
+	return int16(w), err
+}
+
+// WriteRegU16 write unsigned word (16 bits) value with the given byte
+// order to i2c device starting from address specified in reg.
+func (v *I2C) WriteRegU16(reg byte, value uint16, order binary.ByteOrder) error {
+	return v.writeRegOrder(reg, uint64(value), 2, order)
+}
+
+// WriteRegS16 write signed word (16 bits) value with the given byte order
+// to i2c device starting from address specified in reg.
+func (v *I2C) WriteRegS16(reg byte, value int16, order binary.ByteOrder) error {
+	return v.writeRegOrder(reg, uint64(uint16(value)), 2, order)
+}
+
+// ReadRegU24 read unsigned 24 bits value stored with the given byte order
+// from i2c device starting from address specified in reg.
+func (v *I2C) ReadRegU24(reg byte, order binary.ByteOrder) (uint32, error) {
+	w, err := v.readRegOrder(reg, 3, order)
+	return uint32(w), err
+}
+
+// WriteRegU24 write the low 24 bits of value with the given byte order to
+// i2c device starting from address specified in reg.
+func (v *I2C) WriteRegU24(reg byte, value uint32, order binary.ByteOrder) error {
+	return v.writeRegOrder(reg, uint64(value&0xFFFFFF), 3, order)
+}
+
+// ReadRegU32 read unsigned 32 bits value stored with the given byte order
+// from i2c device starting from address specified in reg.
+func (v *I2C) ReadRegU32(reg byte, order binary.ByteOrder) (uint32, error) {
+	w, err := v.readRegOrder(reg, 4, order)
+	return uint32(w), err
+}
+
+// WriteRegU32 write unsigned 32 bits value with the given byte order to
+// i2c device starting from address specified in reg.
+func (v *I2C) WriteRegU32(reg byte, value uint32, order binary.ByteOrder) error {
+	return v.writeRegOrder(reg, uint64(value), 4, order)
+}
+
+// ReadRegS24 read signed 24 bits value stored with the given byte order
+// from i2c device starting from address specified in reg, sign extended
+// to 32 bits.
+func (v *I2C) ReadRegS24(reg byte, order binary.ByteOrder) (int32, error) {
+	w, err := v.readRegOrder(reg, 3, order)
+	return int32(uint32(w)<<8) >> 8, err
+}
+
+// ReadRegS32 read signed 32 bits value stored with the given byte order
+// from i2c device starting from address specified in reg.
+func (v *I2C) ReadRegS32(reg byte, order binary.ByteOrder) (int32, error) {
+	w, err := v.readRegOrder(reg, 4, order)
+	return int32(w), err
+}
+
+// ReadRegU64 read unsigned 64 bits value stored with the given byte order
+// from i2c device starting from address specified in reg.
+func (v *I2C) ReadRegU64(reg byte, order binary.ByteOrder) (uint64, error) {
+	return v.readRegOrder(reg, 8, order)
+}
+
+// WriteRegU64 write unsigned 64 bits value with the given byte order to
+// i2c device starting from address specified in reg.
+func (v *I2C) WriteRegU64(reg byte, value uint64, order binary.ByteOrder) error {
+	return v.writeRegOrder(reg, value, 8, order)
+}
@@ -0,0 +1,136 @@
+package i2c
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// pattern are the bytes seeded at register 0x10 by the order tests.
+var pattern = []byte{0x81, 0x02, 0x83, 0x04, 0x85, 0x06, 0x87, 0x08}
+
+// encode returns the n bytes long value w stored with order.
+func encode(w uint64, n int, order binary.ByteOrder) []byte {
+	var b [8]byte
+	order.PutUint64(b[:], w)
+	if order == binary.BigEndian {
+		return b[8-n:]
+	}
+	return b[:n]
+}
+
+// decode returns the n bytes long value stored with order at the start
+// of b.
+func decode(b []byte, n int, order binary.ByteOrder) uint64 {
+	var w uint64
+	for i := range n {
+		if order == binary.BigEndian {
+			w = w<<8 | uint64(b[i])
+		} else {
+			w |= uint64(b[i]) << (8 * i)
+		}
+	}
+	return w
+}
+
+type orderCase struct {
+	name  string
+	n     int
+	order binary.ByteOrder
+	read  func(reg byte) (uint64, error)
+	write func(reg byte, w uint64) error
+}
+
+func r16[T uint16 | int16](f func(byte) (T, error)) func(byte) (uint64, error) {
+	return func(reg byte) (uint64, error) {
+		w, err := f(reg)
+		return uint64(uint16(w)), err
+	}
+}
+
+func r32(f func(byte) (uint32, error)) func(byte) (uint64, error) {
+	return func(reg byte) (uint64, error) {
+		w, err := f(reg)
+		return uint64(w), err
+	}
+}
+
+func w16[T uint16 | int16](f func(byte, T) error) func(byte, uint64) error {
+	return func(reg byte, w uint64) error { return f(reg, T(w)) }
+}
+
+func w32(f func(byte, uint32) error) func(byte, uint64) error {
+	return func(reg byte, w uint64) error { return f(reg, uint32(w)) }
+}
+
+// withOrder binds order to the helper f.
+func withOrder[T any](f func(byte, binary.ByteOrder) (T, error), order binary.ByteOrder) func(byte) (T, error) {
+	return func(reg byte) (T, error) { return f(reg, order) }
+}
+
+// withOrderW binds order to the write helper f.
+func withOrderW[T any](f func(byte, T, binary.ByteOrder) error, order binary.ByteOrder) func(byte, T) error {
+	return func(reg byte, w T) error { return f(reg, w, order) }
+}
+
+// orderCases lists the register helpers of v of every width and byte
+// order, both the ones taking a binary.ByteOrder and the BE/LE ones.
+func orderCases(v *I2C) []orderCase {
+	be, le := binary.BigEndian, binary.LittleEndian
+	var cases []orderCase
+	for _, o := range []binary.ByteOrder{be, le} {
+		cases = append(cases,
+			orderCase{"U16", 2, o, r16(withOrder(v.ReadRegU16, o)), w16(withOrderW(v.WriteRegU16, o))},
+			orderCase{"S16", 2, o, r16(withOrder(v.ReadRegS16, o)), w16(withOrderW(v.WriteRegS16, o))},
+			orderCase{"U24", 3, o, r32(withOrder(v.ReadRegU24, o)), w32(withOrderW(v.WriteRegU24, o))},
+			orderCase{"U32", 4, o, r32(withOrder(v.ReadRegU32, o)), w32(withOrderW(v.WriteRegU32, o))},
+			orderCase{"U64", 8, o, withOrder(v.ReadRegU64, o), withOrderW(v.WriteRegU64, o)},
+		)
+	}
+	return append(cases,
+		orderCase{"U16BE", 2, be, r16(v.ReadRegU16BE), w16(v.WriteRegU16BE)},
+		orderCase{"U16LE", 2, le, r16(v.ReadRegU16LE), w16(v.WriteRegU16LE)},
+		orderCase{"S16BE", 2, be, r16(v.ReadRegS16BE), w16(v.WriteRegS16BE)},
+		orderCase{"S16LE", 2, le, r16(v.ReadRegS16LE), w16(v.WriteRegS16LE)},
+		orderCase{"U24BE", 3, be, r32(v.ReadRegU24BE), w32(v.WriteRegU24BE)},
+		orderCase{"U24LE", 3, le, r32(v.ReadRegU24LE), w32(v.WriteRegU24LE)},
+		orderCase{"U32BE", 4, be, r32(v.ReadRegU32BE), w32(v.WriteRegU32BE)},
+		orderCase{"U32LE", 4, le, r32(v.ReadRegU32LE), w32(v.WriteRegU32LE)},
+		orderCase{"U64BE", 8, be, v.ReadRegU64BE, v.WriteRegU64BE},
+		orderCase{"U64LE", 8, le, v.ReadRegU64LE, v.WriteRegU64LE},
+	)
+}
+
+func TestRegOrder(t *testing.T) {
+	seed := map[byte]byte{}
+	for i, b := range pattern {
+		seed[0x10+byte(i)] = b
+	}
+	d := NewDryRun(seed)
+	v := NewI2CConn(d, 0x40)
+	defer v.Close()
+	for _, c := range orderCases(v) {
+		t.Run(fmt.Sprintf("%s/%v", c.name, c.order), func(t *testing.T) {
+			got, err := c.read(0x10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := decode(pattern, c.n, c.order); got != want {
+				t.Errorf("read 0x%0*X, want 0x%0*X", 2*c.n, got, 2*c.n, want)
+			}
+			w := decode(pattern, c.n, binary.BigEndian)
+			if err := c.write(0x20, w); err != nil {
+				t.Fatal(err)
+			}
+			writes := d.Writes()
+			want := append([]byte{0x20}, encode(w, c.n, c.order)...)
+			if last := writes[len(writes)-1]; !slices.Equal(last, want) {
+				t.Errorf("wrote % X, want % X", last, want)
+			}
+			if back, err := c.read(0x20); err != nil || back != w {
+				t.Errorf("read back 0x%X, %v, want 0x%X", back, err, w)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package i2c
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Ping checks that the device acknowledges its address, without touching
+// its registers, e.g. for health checks. It returns nil when the device
+// responds, an error for which IsNack reports true when it is absent. On
+// Linux it uses an SMBus quick write, or a one byte read when the adapter
+// cannot perform one or the address is in an EEPROM range, as Scan does,
+// and fails with ErrUnsupported when the adapter supports neither.
+// Connections not backed by a bus device send a zero length write.
+func (v *I2C) Ping() error {
+	return v.do(func() error {
+		err := v.probeFd()
+		// a zero length write of a descriptor is no transfer at all
+		if _, fd := v.rc.(syscall.Conn); !fd && errors.Is(err, ErrUnsupported) {
+			_, err = v.xfer(OpWrite, NoReg, nil)
+		}
+		return err
+	})
+}
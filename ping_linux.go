@@ -0,0 +1,15 @@
+//go:build !baremetal
+
+package i2c
+
+// probeFd probes the device through the descriptor of the connection.
+// It must be called with the lock held.
+func (v *I2C) probeFd() error {
+	return v.control(func(fd uintptr) error {
+		funcs, err := funcsFd(fd)
+		if err != nil {
+			return err
+		}
+		return probe(fd, v.addr, funcs)
+	})
+}
@@ -0,0 +1,8 @@
+//go:build !linux || baremetal
+
+package i2c
+
+// probeFd is only supported on Linux.
+func (v *I2C) probeFd() error {
+	return ErrUnsupported
+}
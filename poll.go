@@ -0,0 +1,66 @@
+package i2c
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrPollTimeout is returned when a polled register does not reach the
+// expected state in time.
+var ErrPollTimeout = errors.New("i2c: timeout polling register")
+
+// defaultPoll is the poll interval of WaitForRegBit when none is given.
+const defaultPoll = time.Millisecond
+
+// WaitForRegBit polls register reg every interval, 1ms when not positive,
+// until all the bits of mask are set, or all are clear when set is false,
+// e.g. waiting for a conversion-done flag or for a busy flag to clear. It
+// returns ErrPollTimeout when timeout elapses first, zero meaning no
+// timeout, and ctx's error when ctx is done. The register is read at
+// least once, bypassing the register cache, and a read error stops the
+// polling.
+func (v *I2C) WaitForRegBit(ctx context.Context, reg byte, mask byte, set bool, interval, timeout time.Duration) error {
+	if interval <= 0 {
+		interval = defaultPoll
+	}
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	want := byte(0)
+	if set {
+		want = mask
+	}
+	for {
+		var r [1]byte
+		err := v.doContext(ctx, func() error {
+			n, err := v.readRegLocked(reg, r[:])
+			if err == nil && n < 1 {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if r[0]&mask == want {
+			return nil
+		}
+		d := interval
+		if !deadline.IsZero() {
+			rem := time.Until(deadline)
+			if rem <= 0 {
+				return ErrPollTimeout
+			}
+			d = min(d, rem)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := sleepCtx(ctx, d); err != nil {
+			return err
+		}
+	}
+}
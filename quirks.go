@@ -0,0 +1,33 @@
+package i2c
+
+import "time"
+
+// Quirks describes protocol deviations of a device which the register
+// helpers take care of, so callers do not have to juggle raw bytes.
+type Quirks struct {
+	// ReadDummy is the count of dummy bytes the device sends ahead of the
+	// data of a register read. They are read and discarded.
+	ReadDummy int
+	// WriteDummy is the count of dummy bytes inserted between the
+	// register address and the data of a register write.
+	WriteDummy int
+	// Dummy is the value of the inserted dummy bytes.
+	Dummy byte
+}
+
+// SetTurnaround sets a delay between sending the register address and
+// reading the result in the register read helpers, for devices which
+// need time to fetch or convert the value. The bus stays locked during
+// the delay, so other operations cannot slip in between.
+func (v *I2C) SetTurnaround(d time.Duration) {
+	v.turn.Store(int64(d))
+}
+
+// SetQuirks sets the protocol deviations of the device.
+func (v *I2C) SetQuirks(q Quirks) {
+	if q == (Quirks{}) {
+		v.quirks.Store(nil)
+		return
+	}
+	v.quirks.Store(&q)
+}
@@ -0,0 +1,100 @@
+package i2c
+
+import (
+	"sync"
+	"time"
+)
+
+// limiter is a token bucket enforcing a transaction rate and a minimum
+// gap between consecutive transactions.
+type limiter struct {
+	// turn is held from wait to done, so that transactions queue up and
+	// the gap is measured from the end of the previous one
+	turn   sync.Mutex
+	mu     sync.Mutex // guards the fields below
+	rate   float64
+	burst  float64
+	tokens float64
+	refill time.Time
+	gap    time.Duration
+	end    time.Time
+}
+
+// wait blocks until a transaction is allowed and reserves it. The
+// transaction must be ended with done.
+func (l *limiter) wait() {
+	l.turn.Lock()
+	l.mu.Lock()
+	now := time.Now()
+	var d time.Duration
+	if l.gap > 0 && !l.end.IsZero() {
+		d = l.end.Add(l.gap).Sub(now)
+	}
+	if l.rate > 0 {
+		l.tokens += now.Sub(l.refill).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.refill = now
+		l.tokens--
+		if l.tokens < 0 {
+			if w := time.Duration(-l.tokens / l.rate * float64(time.Second)); w > d {
+				d = w
+			}
+		}
+	}
+	l.mu.Unlock()
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// done records the end of a transaction, the start of the minimum gap,
+// and lets the next one in.
+func (l *limiter) done() {
+	l.mu.Lock()
+	l.end = time.Now()
+	l.mu.Unlock()
+	l.turn.Unlock()
+}
+
+// SetRateLimit throttles the transactions with the device to perSecond,
+// allowing bursts of up to burst transactions, and enforces a minimum gap
+// between the end of a transaction and the start of the next one. Zero
+// values disable the respective limit. The limits are shared by every
+// connection to the same device in the program, so all callers are
+// throttled together.
+func (v *I2C) SetRateLimit(perSecond float64, burst int, gap time.Duration) {
+	d := v.dev.Load()
+	if d == nil {
+		return
+	}
+	l := &d.limit
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if burst < 1 {
+		burst = 1
+	}
+	l.rate = perSecond
+	l.burst = float64(burst)
+	l.tokens = l.burst
+	l.refill = time.Now()
+	l.gap = gap
+}
+
+// SetPacing enforces a minimum gap between the end of an operation with
+// the device and the start of the next one, e.g. 500µs for EEPROMs and
+// MCU based peripherals which NACK when addressed again too soon. It is
+// the gap of SetRateLimit, leaving the rate limit untouched, and is
+// likewise shared by every connection to the device: concurrent
+// operations queue up, each one starting at least gap after the end of
+// the previous one.
+func (v *I2C) SetPacing(gap time.Duration) {
+	d := v.dev.Load()
+	if d == nil {
+		return
+	}
+	d.limit.mu.Lock()
+	d.limit.gap = gap
+	d.limit.mu.Unlock()
+}
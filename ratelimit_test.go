@@ -0,0 +1,71 @@
+package i2c
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowConn is a DryRun whose writes last d, recording their span.
+type slowConn struct {
+	*DryRun
+	d     time.Duration
+	mu    sync.Mutex
+	spans [][2]time.Time
+}
+
+func (c *slowConn) Write(p []byte) (int, error) {
+	start := time.Now()
+	time.Sleep(c.d)
+	c.mu.Lock()
+	c.spans = append(c.spans, [2]time.Time{start, time.Now()})
+	c.mu.Unlock()
+	return c.DryRun.Write(p)
+}
+
+func TestPacingConcurrent(t *testing.T) {
+	const (
+		gap = 50 * time.Millisecond
+		n   = 4
+	)
+	c := &slowConn{DryRun: NewDryRun(nil), d: 20 * time.Millisecond}
+	v := NewI2CConn(c, 0x50)
+	v.SetPacing(gap)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := v.WriteBytes([]byte{0}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(c.spans) != n {
+		t.Fatalf("got %d transfers, want %d", len(c.spans), n)
+	}
+	sort.Slice(c.spans, func(i, j int) bool { return c.spans[i][0].Before(c.spans[j][0]) })
+	for i := 1; i < n; i++ {
+		// the limiter measures the end slightly after the transfer
+		if g := c.spans[i][0].Sub(c.spans[i-1][1]); g < gap {
+			t.Errorf("gap %d: %v, want at least %v", i, g, gap)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	v := NewI2CConn(NewDryRun(nil), 0x50)
+	v.SetRateLimit(100, 1, 0)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := v.WriteBytes([]byte{0}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the first transaction uses the initial token
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("5 transactions at 100/s took %v, want at least 40ms", d)
+	}
+}
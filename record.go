@@ -0,0 +1,169 @@
+package i2c
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Record is a transaction captured by a Recorder.
+type Record struct {
+	Time time.Time
+	Bus  int
+	Addr uint8
+	Op   Op
+	Reg  int
+	// Data is the message sent by a write, or the bytes received by a
+	// read of Len bytes.
+	Data     []byte
+	Len      int
+	N        int
+	Duration time.Duration
+	// Err is the error message of a failed transaction, Nack reports
+	// whether the device did not acknowledge it.
+	Err  string
+	Nack bool
+}
+
+// recordJSON is the encoding of a Record, with the payload in hex.
+type recordJSON struct {
+	Time     time.Time     `json:"time"`
+	Bus      int           `json:"bus"`
+	Addr     uint8         `json:"addr"`
+	Op       string        `json:"op"`
+	Reg      int           `json:"reg"`
+	Data     string        `json:"data"`
+	Len      int           `json:"len"`
+	N        int           `json:"n"`
+	Duration time.Duration `json:"dur"`
+	Err      string        `json:"err,omitempty"`
+	Nack     bool          `json:"nack,omitempty"`
+}
+
+func (r Record) MarshalJSON() ([]byte, error) {
+	return json.Marshal(recordJSON{r.Time, r.Bus, r.Addr, r.Op.String(), r.Reg,
+		hex.EncodeToString(r.Data), r.Len, r.N, r.Duration, r.Err, r.Nack})
+}
+
+func (r *Record) UnmarshalJSON(b []byte) error {
+	var j recordJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	data, err := hex.DecodeString(j.Data)
+	if err != nil {
+		return err
+	}
+	op := OpWrite
+	if j.Op == OpRead.String() {
+		op = OpRead
+	}
+	*r = Record{j.Time, j.Bus, j.Addr, op, j.Reg, data, j.Len, j.N, j.Duration, j.Err, j.Nack}
+	return nil
+}
+
+// Recorder captures the transactions of the connections using its
+// middleware to a stream of JSON lines, one Record per line, e.g. to
+// capture the behavior of real hardware once and replay it in tests with
+// package i2ctest.
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewRecorder returns a Recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// Middleware returns the middleware capturing the transactions. It can be
+// used by several connections at once.
+func (r *Recorder) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(t *Transaction) {
+			start := time.Now()
+			next(t)
+			var recs []Record
+			if t.Op == OpCombined {
+				recs = combinedRecords(t, start)
+			} else {
+				rec := Record{Time: start, Bus: t.Bus, Addr: t.Addr, Op: t.Op, Reg: t.Reg,
+					Len: len(t.Buf), N: t.N, Duration: t.Duration}
+				if t.Op == OpRead {
+					rec.Data = t.Buf[:min(max(t.N, 0), len(t.Buf))]
+				} else {
+					rec.Data = t.Buf
+				}
+				recs = []Record{rec}
+			}
+			if t.Err != nil {
+				// the failing message is unknown, the transaction
+				// stops at the first one
+				recs = recs[:1]
+				recs[0].Err = t.Err.Error()
+				recs[0].Nack = IsNack(t.Err)
+				recs[0].N = 0
+				if recs[0].Op == OpRead {
+					recs[0].Data = nil
+				}
+			}
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			for _, rec := range recs {
+				if r.err == nil {
+					r.err = r.enc.Encode(rec)
+				}
+			}
+		}
+	}
+}
+
+// combinedRecords returns a record per message of the OpCombined
+// transaction t, splitting its duration evenly among them, so that
+// replays see the transfers of the messages.
+func combinedRecords(t *Transaction, start time.Time) []Record {
+	recs := make([]Record, 0, len(t.Msgs))
+	d := t.Duration / time.Duration(max(len(t.Msgs), 1))
+	for _, m := range t.Msgs {
+		rec := Record{Time: start, Bus: t.Bus, Addr: t.Addr, Op: OpWrite, Reg: t.Reg,
+			Data: m.Buf, Len: len(m.Buf), N: len(m.Buf), Duration: d}
+		if m.Addr != 0 {
+			rec.Addr = m.Addr
+		}
+		if m.Read {
+			rec.Op = OpRead
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+// Err returns the first error writing the records, after which recording
+// stops.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// ReadRecords reads the records written by a Recorder.
+func ReadRecords(rd io.Reader) ([]Record, error) {
+	var recs []Record
+	sc := bufio.NewScanner(rd)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return recs, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, sc.Err()
+}
@@ -0,0 +1,71 @@
+package i2c
+
+import "io"
+
+// RegRead is a register range read by BatchRead: len(Buf) bytes starting
+// from register Reg.
+type RegRead struct {
+	Reg byte
+	Buf []byte
+}
+
+// BatchRead read every range of reads from the device as one operation,
+// e.g. the scattered status and data registers polled each control cycle.
+// On Linux the reads are combined in I2C_RDWR calls of up to 21 reads,
+// with repeated starts between them, each call going through the
+// middlewares as an OpCombined transaction, as do the reads of
+// connections opened with OpenBus and of transports with a Tx method.
+// Connections with quirks or a turnaround delay, and the other ones, fall
+// back to one register read after the other, still as a single
+// operation.
+func (v *I2C) BatchRead(reads []RegRead) error {
+	if err := v.Ready(); err != nil {
+		return err
+	}
+	err := v.do(func() error {
+		if v.combines() {
+			return v.batchMsgs(reads)
+		}
+		for _, r := range reads {
+			n, err := v.readRegLocked(r.Reg, r.Buf)
+			if err == nil && n < len(r.Buf) {
+				err = io.ErrUnexpectedEOF
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		for _, r := range reads {
+			v.cache.store(r.Reg, r.Buf)
+		}
+	}
+	return err
+}
+
+// rdwrMax is the maximum count of messages of an I2C_RDWR call,
+// I2C_RDWR_IOCTL_MAX_MSGS of linux/i2c-dev.h.
+const rdwrMax = 42
+
+// batchMsgs performs reads as register pointer write and read message
+// pairs of combined transactions of up to rdwrMax messages. It must be
+// called with the lock held.
+func (v *I2C) batchMsgs(reads []RegRead) error {
+	regs := make([]byte, len(reads))
+	msgs := make([]Msg, 0, min(2*len(reads), rdwrMax))
+	for len(reads) > 0 {
+		k := min(len(reads), rdwrMax/2)
+		msgs = msgs[:0]
+		for i, r := range reads[:k] {
+			regs[i] = r.Reg
+			msgs = append(msgs, Msg{Buf: regs[i : i+1]}, Msg{Read: true, Buf: r.Buf})
+		}
+		if err := v.xferMsgs(NoReg, msgs); err != nil {
+			return err
+		}
+		reads, regs = reads[k:], regs[k:]
+	}
+	return nil
+}
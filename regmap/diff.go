@@ -0,0 +1,123 @@
+package regmap
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Change is a register differing between two dumps.
+type Change struct {
+	Reg int
+	// Register is the register in the map, nil when there is no map or
+	// the register is not in it.
+	Register *Register
+	Old, New uint64
+	// InOld and InNew report whether the register was read in the
+	// respective dump.
+	InOld, InNew bool
+	// Fields are the fields of Register which differ, when the register
+	// was read in both dumps.
+	Fields []FieldChange
+}
+
+// FieldChange is a field differing between two dumps.
+type FieldChange struct {
+	Field    *Field
+	Old, New uint64
+}
+
+// Diff compares the dumps a and b, which must have the same width, and
+// returns the differing registers sorted by address. With a map, the
+// values of the registers of the map wider than the dumps are assembled
+// from consecutive values, and the differing fields are reported.
+// Registers read in one dump only are reported as changes as well.
+func Diff(a, b *Dump, m *Map) ([]Change, error) {
+	if a.Width != b.Width {
+		return nil, fmt.Errorf("regmap: dumps of different widths, %d and %d bytes", a.Width, b.Width)
+	}
+	va, vb := a.values(), b.values()
+	covered := make(map[int]bool)
+	var changes []Change
+	if m != nil {
+		for i := range m.Registers {
+			r := &m.Registers[i]
+			if a.Width != r.width() && a.Width != 1 {
+				continue
+			}
+			order := r.Order
+			if order == "" {
+				order = m.Order
+			}
+			oa, inA := r.value(va, a.Width, order)
+			ob, inB := r.value(vb, b.Width, order)
+			n := 1
+			if a.Width == 1 {
+				n = r.width()
+			}
+			for k := 0; k < n; k++ {
+				covered[int(r.Addr)+k] = true
+			}
+			if inA == inB && (!inA || oa == ob) {
+				continue
+			}
+			c := Change{Reg: int(r.Addr), Register: r, Old: oa, New: ob, InOld: inA, InNew: inB}
+			if inA && inB {
+				for j := range r.Fields {
+					f := &r.Fields[j]
+					fa, err := f.Extract(oa)
+					if err != nil {
+						return nil, fmt.Errorf("regmap: register %s field %s: %w", r.Name, f.Name, err)
+					}
+					fb, _ := f.Extract(ob)
+					if fa != fb {
+						c.Fields = append(c.Fields, FieldChange{Field: f, Old: fa, New: fb})
+					}
+				}
+			}
+			changes = append(changes, c)
+		}
+	}
+	regs := make(map[int]bool)
+	for _, d := range []*Dump{a, b} {
+		for _, e := range d.Registers {
+			regs[e.Reg] = true
+		}
+	}
+	for reg := range regs {
+		if covered[reg] {
+			continue
+		}
+		oa, inA := va[reg]
+		ob, inB := vb[reg]
+		if inA == inB && (!inA || oa == ob) {
+			continue
+		}
+		changes = append(changes, Change{Reg: reg, Old: oa, New: ob, InOld: inA, InNew: inB})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Reg < changes[j].Reg
+	})
+	return changes, nil
+}
+
+// value returns the value of r in the dump values vals of the given
+// width, assembling single byte values with order when r is wider.
+func (r *Register) value(vals map[int]uint64, width int, order string) (uint64, bool) {
+	addr := int(r.Addr)
+	if width == r.width() {
+		x, ok := vals[addr]
+		return x, ok
+	}
+	if width != 1 {
+		return 0, false
+	}
+	b := make([]byte, r.width())
+	for i := range b {
+		x, ok := vals[addr+i]
+		if !ok {
+			return 0, false
+		}
+		b[i] = byte(x)
+	}
+	return decode(b, order == "le"), true
+}
@@ -0,0 +1,102 @@
+package regmap
+
+import (
+	"encoding/json"
+	"io"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// Dump is a snapshot of the registers of a device, as written by
+// cmd/i2cdump in JSON.
+type Dump struct {
+	Bus  int   `json:"bus"`
+	Addr uint8 `json:"addr"`
+	// Width is the size of each value in bytes.
+	Width int `json:"width"`
+	// Order is the byte order of the values, "be" or "le", "be" when
+	// empty.
+	Order     string  `json:"order,omitempty"`
+	Registers []Entry `json:"registers"`
+}
+
+// Entry is the value of a register in a dump.
+type Entry struct {
+	Reg   int    `json:"reg"`
+	Value uint64 `json:"value"`
+	// Err is the error reading the register, empty on success.
+	Err string `json:"error,omitempty"`
+}
+
+// LoadDump reads a dump written by WriteJSON.
+func LoadDump(r io.Reader) (*Dump, error) {
+	var d Dump
+	if err := json.NewDecoder(r).Decode(&d); err != nil {
+		return nil, err
+	}
+	if d.Width == 0 {
+		d.Width = 1
+	}
+	return &d, nil
+}
+
+// WriteJSON writes the dump as indented JSON.
+func (d *Dump) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// Read captures the registers regs of v, reading width bytes values
+// stored with order, "be", "le" or empty for "be", one register at a
+// time. Registers which cannot be read are recorded with their error.
+func Read(v *i2c.I2C, regs []int, width int, order string) *Dump {
+	d := &Dump{Bus: v.Bus(), Addr: v.Addr(), Width: width, Order: order}
+	if order == "be" {
+		d.Order = ""
+	}
+	buf := make([]byte, width)
+	for _, r := range regs {
+		e := Entry{Reg: r}
+		if _, err := v.ReadRegBytesInto(byte(r), buf); err != nil {
+			e.Err = err.Error()
+		} else {
+			e.Value = decode(buf, d.Order == "le")
+		}
+		d.Registers = append(d.Registers, e)
+	}
+	return d
+}
+
+// Regs returns the registers of the dump, in order.
+func (d *Dump) Regs() []int {
+	regs := make([]int, len(d.Registers))
+	for i, e := range d.Registers {
+		regs[i] = e.Reg
+	}
+	return regs
+}
+
+// values returns the values of the registers read successfully.
+func (d *Dump) values() map[int]uint64 {
+	m := make(map[int]uint64, len(d.Registers))
+	for _, e := range d.Registers {
+		if e.Err == "" {
+			m[e.Reg] = e.Value
+		}
+	}
+	return m
+}
+
+// decode returns the value stored in b, little endian when le is true.
+func decode(b []byte, le bool) uint64 {
+	var x uint64
+	for i := range b {
+		c := b[i]
+		if le {
+			c = b[len(b)-1-i]
+		}
+		x = x<<8 | uint64(c)
+	}
+	return x
+}
@@ -0,0 +1,145 @@
+// Package regmap describes the registers of a chip, and captures and
+// compares register dumps of devices, decoding the differences down to
+// the bit fields of the registers.
+//
+// A register map is the JSON chip description of cmd/i2cgen:
+//
+//	{
+//	  "type": "BME280",
+//	  "order": "be",
+//	  "registers": [
+//	    {"name": "CtrlMeas", "addr": "0xF4", "fields": [
+//	      {"name": "Mode", "bits": "1:0", "enum": {"Sleep": 0, "Forced": 1, "Normal": 3}},
+//	      {"name": "OsrsP", "bits": "4:2"}
+//	    ]}
+//	  ]
+//	}
+package regmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Num is a JSON number, or a string holding a decimal or 0x prefixed hex
+// number.
+type Num uint64
+
+func (n *Num) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	u, err := strconv.ParseUint(s, 0, 64)
+	if err != nil {
+		return fmt.Errorf("bad number %s", b)
+	}
+	*n = Num(u)
+	return nil
+}
+
+// Map is the register map of a chip.
+type Map struct {
+	Package   string     `json:"package"`
+	Type      string     `json:"type"`
+	Order     string     `json:"order"`
+	Registers []Register `json:"registers"`
+}
+
+// Register is a register of a chip.
+type Register struct {
+	Name string `json:"name"`
+	Doc  string `json:"doc"`
+	Addr Num    `json:"addr"`
+	// Width is the size of the register in bytes, 1 when zero.
+	Width int `json:"width"`
+	// Order is the byte order of the register, "be" or "le", the one of
+	// the chip when empty.
+	Order  string  `json:"order"`
+	Signed bool    `json:"signed"`
+	Access string  `json:"access"`
+	Fields []Field `json:"fields"`
+}
+
+// Field is a bit field of a register.
+type Field struct {
+	Name string `json:"name"`
+	Doc  string `json:"doc"`
+	// Bits is the "hi:lo" bit range of the field, or its single bit.
+	Bits   string         `json:"bits"`
+	Access string         `json:"access"`
+	Type   string         `json:"type"`
+	Enum   map[string]Num `json:"enum"`
+}
+
+// Load reads a register map. Unknown keys are rejected, to catch typos.
+func Load(r io.Reader) (*Map, error) {
+	var m Map
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Lookup returns the register at addr.
+func (m *Map) Lookup(addr int) (*Register, bool) {
+	for i := range m.Registers {
+		if int(m.Registers[i].Addr) == addr {
+			return &m.Registers[i], true
+		}
+	}
+	return nil, false
+}
+
+// width returns the width of r in bytes.
+func (r *Register) width() int {
+	if r.Width == 0 {
+		return 1
+	}
+	return r.Width
+}
+
+// ParseBits parses a "hi:lo" bit range or a single bit number.
+func ParseBits(s string) (hi, lo int, err error) {
+	h, l, ok := strings.Cut(s, ":")
+	if !ok {
+		l = h
+	}
+	hi, err1 := strconv.Atoi(h)
+	lo, err2 := strconv.Atoi(l)
+	if err1 != nil || err2 != nil || lo < 0 || hi < lo || hi > 63 {
+		return 0, 0, fmt.Errorf("bad bits %q", s)
+	}
+	return hi, lo, nil
+}
+
+// Extract returns the value of the field in the register value x.
+func (f *Field) Extract(x uint64) (uint64, error) {
+	hi, lo, err := ParseBits(f.Bits)
+	if err != nil {
+		return 0, err
+	}
+	return x >> lo & (1<<(hi-lo+1) - 1), nil
+}
+
+// Format returns the field value x as its enumeration name, true or
+// false for single bit fields, or a decimal number.
+func (f *Field) Format(x uint64) string {
+	names := make([]string, 0, 1)
+	for k, v := range f.Enum {
+		if uint64(v) == x {
+			names = append(names, k)
+		}
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		return names[0]
+	}
+	if hi, lo, err := ParseBits(f.Bits); err == nil && hi == lo && f.Type == "" && len(f.Enum) == 0 {
+		return strconv.FormatBool(x != 0)
+	}
+	return strconv.FormatUint(x, 10)
+}
@@ -0,0 +1,66 @@
+package i2c
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrRegisterRange is returned when an offset falls outside of the
+// 8 bit register address space of a device.
+var ErrRegisterRange = errors.New("i2c: register address out of range")
+
+// regSpace is the size of the register address space of a device.
+const regSpace = 0x100
+
+// RegisterView exposes the register space of an i2c device as an
+// io.ReaderAt and io.WriterAt, where the offset is the register address.
+// It relies on the device auto-incrementing the register pointer on
+// multi-byte transfers.
+type RegisterView struct {
+	v *I2C
+}
+
+// Registers returns a register addressed view of the i2c device.
+func (v *I2C) Registers() *RegisterView {
+	return &RegisterView{v: v}
+}
+
+// ReadAt read len(p) bytes from the device starting from register off.
+// Reads running past the last register are truncated and return io.EOF.
+func (r *RegisterView) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 || off >= regSpace {
+		return 0, ErrRegisterRange
+	}
+	var eof bool
+	if off+int64(len(p)) > regSpace {
+		p = p[:regSpace-off]
+		eof = true
+	}
+	if len(p) == 0 {
+		return 0, io.EOF
+	}
+	n, err := r.v.readBlock(context.Background(), byte(off), p)
+	if err != nil {
+		return n, err
+	}
+	if n < len(p) {
+		return n, io.ErrUnexpectedEOF
+	}
+	if eof {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// WriteAt write p to the device starting from register off.
+func (r *RegisterView) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 || off+int64(len(p)) > regSpace {
+		return 0, ErrRegisterRange
+	}
+	n, err := r.v.writeBlock(context.Background(), byte(off), p)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	return n, err
+}
@@ -0,0 +1,162 @@
+// Package remote forwards i2c transfers to a bus on another host, served
+// by the i2cd daemon, so central services can talk to the devices of many
+// boards without running application logic on each of them.
+//
+//	c := &remote.Client{URL: "http://lab-sbc-3:8420", Token: token}
+//	v := c.Open(1, 0x76) // bus 1 of the remote host
+//	defer v.Close()
+//
+// Each transfer is a JSON request over HTTP, POSTed to /v1/transfer with
+// the token as bearer authorization, and serialized per bus by the
+// daemon. Register reads and Tx calls are a single request, their write
+// and read one operation on the remote bus.
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"syscall"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// TransferPath is the path of the transfer endpoint.
+const TransferPath = "/v1/transfer"
+
+// Request is a transfer request. Op is "write" to write Data, "read" to
+// read Len bytes, or "tx" to write Data then read Len bytes as one
+// operation of the device, as i2c.I2C.Tx does.
+type Request struct {
+	Bus  int    `json:"bus"`
+	Addr uint8  `json:"addr"`
+	Op   string `json:"op"`
+	Data []byte `json:"data,omitempty"`
+	Len  int    `json:"len,omitempty"`
+}
+
+// Response is the result of a transfer.
+type Response struct {
+	Data []byte `json:"data,omitempty"`
+	N    int    `json:"n"`
+	Err  string `json:"err,omitempty"`
+	// Nack reports that the device did not acknowledge the transfer.
+	Nack bool `json:"nack,omitempty"`
+}
+
+// ErrNack is returned when the remote device does not acknowledge a
+// transfer. On Linux i2c.IsNack reports true for it.
+var ErrNack error = syscall.ENXIO
+
+// Error is a failure reported by the remote host.
+type Error struct {
+	Status int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	if e.Status != 0 && e.Status != http.StatusOK {
+		return fmt.Sprintf("remote: %s: %s", http.StatusText(e.Status), e.Msg)
+	}
+	return "remote: " + e.Msg
+}
+
+// Client talks to an i2cd daemon.
+type Client struct {
+	// URL is the base URL of the daemon, e.g. http://host:8420.
+	URL string
+	// Token is the bearer token authenticating the client.
+	Token string
+	// HTTP is the client used for requests, http.DefaultClient when nil.
+	HTTP *http.Client
+}
+
+// Do performs a transfer request.
+func (c *Client) Do(req *Request) (*Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	hr, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(c.URL, "/")+TransferPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	hr.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		hr.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	res, err := hc.Do(hr)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return nil, &Error{Status: res.StatusCode, Msg: strings.TrimSpace(string(msg))}
+	}
+	var resp Response
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.Nack:
+		return &resp, ErrNack
+	case resp.Err != "":
+		return &resp, &Error{Msg: resp.Err}
+	}
+	return &resp, nil
+}
+
+// Bus returns bus n of the remote host, for i2c.OpenBus. Each Tx is a
+// single request.
+func (c *Client) Bus(n int) i2c.Bus {
+	return &bus{c: c, n: n}
+}
+
+// Conn returns a transport to the device at addr on bus of the remote
+// host, for i2c.NewI2CConn.
+func (c *Client) Conn(bus int, addr uint8) *i2c.BusConn {
+	return i2c.NewBusConn(c.Bus(bus), addr)
+}
+
+// Open returns a connection to the device at addr on bus of the remote
+// host.
+func (c *Client) Open(bus int, addr uint8) *i2c.I2C {
+	return i2c.OpenBus(c.Bus(bus), addr)
+}
+
+// bus is a bus of the remote host.
+type bus struct {
+	c *Client
+	n int
+}
+
+// Tx writes w to the device at addr then reads len(r) bytes into r, in a
+// "tx" request when both are set.
+func (b *bus) Tx(addr uint16, w, r []byte) error {
+	req := &Request{Bus: b.n, Addr: uint8(addr), Op: "tx", Data: w, Len: len(r)}
+	switch {
+	case len(r) == 0:
+		req.Op = "write"
+	case len(w) == 0:
+		req.Op = "read"
+	}
+	res, err := b.c.Do(req)
+	if err != nil {
+		return err
+	}
+	if len(r) > 0 && copy(r, res.Data[:min(max(res.N, 0), len(res.Data))]) < len(r) {
+		return io.ErrUnexpectedEOF
+	}
+	if len(r) == 0 && res.N < len(w) {
+		return io.ErrShortWrite
+	}
+	return nil
+}
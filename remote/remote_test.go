@@ -0,0 +1,42 @@
+package remote
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+func TestClientReadReg(t *testing.T) {
+	d := i2c.NewDryRun(map[byte]byte{0x10: 0xAB, 0x11: 0xCD})
+	s := &Server{
+		Credentials: []Credential{{Name: "test", Token: "tok", Grants: []Grant{{Bus: 1, ReadOnly: true}}}},
+		Open: func(bus int, addr uint8) (*i2c.I2C, error) {
+			return i2c.NewI2CConn(d, addr), nil
+		},
+	}
+	var requests atomic.Int32
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		s.ServeHTTP(w, r)
+	}))
+	defer hs.Close()
+	c := &Client{URL: hs.URL, Token: "tok"}
+	v := c.Open(1, 0x76)
+	defer v.Close()
+	u, err := v.ReadRegU16BE(0x10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u != 0xABCD {
+		t.Fatalf("got 0x%04X, want 0xABCD", u)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("register read took %d requests, want 1", n)
+	}
+	if err := v.WriteRegU8(0x10, 0); err == nil {
+		t.Fatal("register write through a read only grant succeeded")
+	}
+}
@@ -0,0 +1,187 @@
+package remote
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// maxRead bounds the length of a read request.
+const maxRead = 4096
+
+// Grant allows access to devices of a bus.
+type Grant struct {
+	Bus int `json:"bus"`
+	// Addrs lists the allowed addresses, all of them when empty.
+	Addrs []uint8 `json:"addrs,omitempty"`
+	// ReadOnly forbids writes, but for the pointer writes of register
+	// reads, sent along with their read as a "tx" transfer.
+	ReadOnly bool `json:"read_only,omitempty"`
+	// Pointer is the width in bytes of the register pointer of the
+	// devices, 1 when zero, which bounds the writes of the "tx"
+	// transfers of ReadOnly grants.
+	Pointer int `json:"pointer,omitempty"`
+}
+
+// readable reports whether the ReadOnly grant g allows req: a read, or a
+// "tx" writing no more than a register pointer before reading.
+func (g *Grant) readable(req *Request) bool {
+	switch req.Op {
+	case "read":
+		return true
+	case "tx":
+		return req.Len > 0 && len(req.Data) <= max(g.Pointer, 1)
+	}
+	return false
+}
+
+// Credential is a client of a Server, authenticated by its token.
+type Credential struct {
+	Name   string  `json:"name"`
+	Token  string  `json:"token"`
+	Grants []Grant `json:"grants"`
+}
+
+// allows reports whether the credential grants req.
+func (c *Credential) allows(req *Request) bool {
+	for _, g := range c.Grants {
+		if g.Bus == req.Bus && (len(g.Addrs) == 0 || slices.Contains(g.Addrs, req.Addr)) && (!g.ReadOnly || g.readable(req)) {
+			return true
+		}
+	}
+	return false
+}
+
+type devKey struct {
+	bus  int
+	addr uint8
+}
+
+// Server serves the transfer endpoint to authenticated clients. Devices
+// are opened on first use and kept open, transfers to the devices of a
+// bus are serialized by the bus lock of package i2c.
+type Server struct {
+	Credentials []Credential
+	// Open opens a device, i2c.NewI2C when nil.
+	Open func(bus int, addr uint8) (*i2c.I2C, error)
+	// Log receives a record per transfer, nothing is logged when nil.
+	Log *slog.Logger
+
+	mu    sync.Mutex
+	conns map[devKey]*i2c.I2C
+}
+
+func (s *Server) auth(r *http.Request) *Credential {
+	tok, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil
+	}
+	for i := range s.Credentials {
+		if subtle.ConstantTimeCompare([]byte(tok), []byte(s.Credentials[i].Token)) == 1 {
+			return &s.Credentials[i]
+		}
+	}
+	return nil
+}
+
+func (s *Server) device(bus int, addr uint8) (*i2c.I2C, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := devKey{bus, addr}
+	if v := s.conns[k]; v != nil {
+		return v, nil
+	}
+	open := s.Open
+	if open == nil {
+		open = func(bus int, addr uint8) (*i2c.I2C, error) { return i2c.NewI2C(addr, bus) }
+	}
+	v, err := open(bus, addr)
+	if err != nil {
+		return nil, err
+	}
+	if s.conns == nil {
+		s.conns = make(map[devKey]*i2c.I2C)
+	}
+	s.conns[k] = v
+	return v, nil
+}
+
+// ServeHTTP handles transfer requests.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != TransferPath {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cred := s.auth(r)
+	if cred == nil {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	var req Request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2*maxRead)).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Op != "read" && req.Op != "write" && req.Op != "tx" || req.Len < 0 || req.Len > maxRead {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if !cred.allows(&req) {
+		http.Error(w, "access denied", http.StatusForbidden)
+		return
+	}
+	var res Response
+	v, err := s.device(req.Bus, req.Addr)
+	if err == nil {
+		switch req.Op {
+		case "read":
+			res.Data = make([]byte, req.Len)
+			res.N, err = v.ReadBytes(res.Data)
+			res.Data = res.Data[:max(res.N, 0)]
+		case "write":
+			res.N, err = v.WriteBytes(req.Data)
+		case "tx":
+			res.Data = make([]byte, req.Len)
+			if err = v.Tx(req.Data, res.Data); err == nil {
+				res.N = req.Len
+			} else {
+				res.Data = nil
+			}
+		}
+	}
+	if err != nil {
+		res.Err = err.Error()
+		res.Nack = i2c.IsNack(err)
+	}
+	if s.Log != nil {
+		s.Log.Info("transfer", "client", cred.Name, "bus", req.Bus, "addr", req.Addr,
+			"op", req.Op, "n", res.N, "err", res.Err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&res)
+}
+
+// Close closes the devices opened by the server.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var first error
+	for k, v := range s.conns {
+		if err := v.Close(); err != nil && first == nil {
+			first = err
+		}
+		delete(s.conns, k)
+	}
+	return first
+}
@@ -0,0 +1,69 @@
+package remote
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+func newTestServer(t *testing.T, grants ...Grant) (*Client, *i2c.DryRun) {
+	t.Helper()
+	d := i2c.NewDryRun(map[byte]byte{0x10: 0xAB, 0x11: 0xCD})
+	s := &Server{
+		Credentials: []Credential{{Name: "test", Token: "tok", Grants: grants}},
+		Open: func(bus int, addr uint8) (*i2c.I2C, error) {
+			return i2c.NewI2CConn(d, addr), nil
+		},
+	}
+	hs := httptest.NewServer(s)
+	t.Cleanup(hs.Close)
+	return &Client{URL: hs.URL, Token: "tok"}, d
+}
+
+func TestServerTx(t *testing.T) {
+	c, _ := newTestServer(t, Grant{Bus: 1, ReadOnly: true})
+	res, err := c.Do(&Request{Bus: 1, Addr: 0x76, Op: "tx", Data: []byte{0x10}, Len: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.N != 2 || len(res.Data) != 2 || res.Data[0] != 0xAB || res.Data[1] != 0xCD {
+		t.Fatalf("got n=%d data=% X, want 2 and AB CD", res.N, res.Data)
+	}
+}
+
+func TestServerReadOnly(t *testing.T) {
+	c, d := newTestServer(t, Grant{Bus: 1, ReadOnly: true})
+	for _, req := range []Request{
+		{Bus: 1, Addr: 0x76, Op: "write", Data: []byte{0x10, 0x00}},
+		{Bus: 1, Addr: 0x76, Op: "tx", Data: []byte{0x10, 0x00}},
+		{Bus: 1, Addr: 0x76, Op: "tx", Data: []byte{0x10, 0xFF}, Len: 1},
+	} {
+		_, err := c.Do(&req)
+		var e *Error
+		if !errors.As(err, &e) || e.Status != http.StatusForbidden {
+			t.Errorf("%s writing % X: got %v, want %s", req.Op, req.Data, err, http.StatusText(http.StatusForbidden))
+		}
+	}
+	if n := len(d.Writes()); n != 0 {
+		t.Errorf("got %d writes to the device, want none", n)
+	}
+	if d.Reg(0x10) != 0xAB {
+		t.Errorf("register 0x10 written: 0x%02X", d.Reg(0x10))
+	}
+	if _, err := c.Do(&Request{Bus: 2, Addr: 0x76, Op: "read", Len: 1}); err == nil {
+		t.Error("read on a bus without grant succeeded")
+	}
+}
+
+func TestServerPointer(t *testing.T) {
+	c, _ := newTestServer(t, Grant{Bus: 1, ReadOnly: true, Pointer: 2})
+	if _, err := c.Do(&Request{Bus: 1, Addr: 0x50, Op: "tx", Data: []byte{0x00, 0x10}, Len: 1}); err != nil {
+		t.Fatalf("two byte pointer: %v", err)
+	}
+	if _, err := c.Do(&Request{Bus: 1, Addr: 0x50, Op: "tx", Data: []byte{0x00, 0x10, 0xFF}, Len: 1}); err == nil {
+		t.Fatal("three byte write through a read only grant succeeded")
+	}
+}
@@ -0,0 +1,49 @@
+package i2c
+
+import "time"
+
+// RetryPolicy controls how failed operations with a device are retried.
+// An operation is a whole logical exchange, e.g. a register read is
+// retried starting again from the register pointer write.
+type RetryPolicy struct {
+	// Attempts is the maximum count of attempts, the first one included.
+	Attempts int
+	// Backoff returns the delay before the n-th retry, starting from 1.
+	// A nil Backoff retries immediately.
+	Backoff func(n int) time.Duration
+	// Retryable reports whether a failed operation should be retried.
+	// When nil only NACKs are retried.
+	Retryable func(err error) bool
+}
+
+// ExponentialBackoff returns a backoff schedule starting from base and
+// doubling on every retry, up to max.
+func ExponentialBackoff(base, max time.Duration) func(n int) time.Duration {
+	return func(n int) time.Duration {
+		d := base
+		for i := 1; i < n && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		return d
+	}
+}
+
+// SetRetryPolicy applies p to all the operations of the connection. A zero
+// RetryPolicy disables retries.
+func (v *I2C) SetRetryPolicy(p RetryPolicy) {
+	if p.Attempts <= 1 {
+		v.retry.Store(nil)
+		return
+	}
+	v.retry.Store(&p)
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsNack(err)
+}
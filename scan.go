@@ -0,0 +1,32 @@
+package i2c
+
+// Scan range, the non reserved 7 bit addresses probed by i2cdetect.
+const (
+	scanFirst = 0x03
+	scanLast  = 0x77
+)
+
+// ScanResult is an address found by Scan.
+type ScanResult struct {
+	Addr uint8
+	// Busy reports that the address is claimed by a kernel driver, shown
+	// as UU by i2cdetect. Such addresses are not probed.
+	Busy bool
+}
+
+// Scan probes the addresses 0x03 to 0x77 of bus and returns the ones
+// which respond, mirroring i2cdetect: EEPROM ranges (0x30-0x37 and
+// 0x50-0x5f) are probed with a read, the other addresses with an SMBus
+// quick write when the adapter supports it. Probing may confuse some
+// devices, e.g. write-only chips latching the quick write bit. The scan
+// holds the bus lock of the program while probing each address and uses a
+// single descriptor.
+func Scan(bus int) ([]ScanResult, error) {
+	return scan(bus)
+}
+
+// probeRead reports whether addr should be probed with a read rather
+// than a quick write, which can corrupt EEPROMs.
+func probeRead(addr uint8) bool {
+	return addr >= 0x30 && addr <= 0x37 || addr >= 0x50 && addr <= 0x5f
+}
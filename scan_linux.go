@@ -0,0 +1,79 @@
+//go:build !baremetal
+
+package i2c
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// SMBus transfers, from linux/i2c.h.
+const (
+	smbusWrite = 0
+	smbusRead  = 1
+
+	smbusQuick        = 0
+	smbusByte         = 1
+	smbusByteData     = 2
+	smbusI2CBlockData = 8
+)
+
+// smbusBlockMax is I2C_SMBUS_BLOCK_MAX, union i2c_smbus_data holds a
+// block plus length and PEC bytes.
+const smbusBlockMax = 32
+
+func scan(bus int) ([]ScanResult, error) {
+	f, err := os.OpenFile(fmt.Sprintf("/dev/i2c-%d", bus), os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fd := f.Fd()
+	funcs, err := funcsFd(fd)
+	if err != nil {
+		return nil, err
+	}
+	mu := busLock(bus)
+	var found []ScanResult
+	for addr := uint8(scanFirst); addr <= scanLast; addr++ {
+		mu.Lock()
+		err := probe(fd, addr, funcs)
+		mu.Unlock()
+		switch {
+		case err == nil:
+			found = append(found, ScanResult{Addr: addr})
+		case errors.Is(err, unix.EBUSY):
+			found = append(found, ScanResult{Addr: addr, Busy: true})
+		case errors.Is(err, errNoProbe):
+			return found, err
+		}
+		// any other failure is an absent device, as for i2cdetect
+	}
+	return found, nil
+}
+
+// errNoProbe is returned when the adapter supports no probe transfer.
+var errNoProbe = fmt.Errorf("i2c: adapter cannot probe addresses: %w", ErrUnsupported)
+
+// probe addresses addr with a transfer leaving the device registers
+// untouched, returning nil when the device acknowledges it. It returns
+// EBUSY when addr is claimed by a kernel driver.
+func probe(fd uintptr, addr uint8, funcs Func) error {
+	if err := ioctl(fd, i2cSlave, uintptr(addr)); err != nil {
+		return err
+	}
+	var data [smbusBlockMax + 2]byte
+	req := i2cSMBusData{readWrite: smbusRead, size: smbusByte, data: unsafe.Pointer(&data[0])}
+	switch {
+	case funcs.Has(FuncSMBusQuick) && !probeRead(addr):
+		req = i2cSMBusData{readWrite: smbusWrite, size: smbusQuick}
+	case funcs.Has(FuncSMBusReadByte):
+	default:
+		return errNoProbe
+	}
+	return ioctlPtr(fd, i2cSMBus, unsafe.Pointer(&req))
+}
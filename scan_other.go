@@ -0,0 +1,8 @@
+//go:build !linux || baremetal
+
+package i2c
+
+// scan is only supported on Linux.
+func scan(bus int) ([]ScanResult, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,218 @@
+// Package script evaluates short register access scripts, so diagnostic
+// sequences can be shared as copy-pasteable snippets, e.g.
+//
+//	w8 0x20 0x01; sleep 10ms; r16be 0x00
+//
+// Statements are separated by semicolons or newlines, and # starts a
+// comment. Registers and values are decimal, or hex with a 0x prefix.
+//
+//	r8 REG, r16be REG, r16le REG, rs16be REG, rs16le REG
+//	rb REG N                 read N bytes starting from REG
+//	w8 REG VAL, w16be REG VAL, w16le REG VAL
+//	wb REG B...              write bytes starting from REG
+//	sleep DURATION           e.g. 500us, 10ms
+//
+// The whole script is parsed and checked before anything is executed.
+package script
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	i2c "github.com/fedeonline/i2c-go"
+)
+
+// Stmt is a parsed statement.
+type Stmt struct {
+	Line  int
+	Op    string
+	Reg   byte
+	Args  []uint64
+	Sleep time.Duration
+}
+
+func (s Stmt) String() string {
+	if s.Op == "sleep" {
+		return "sleep " + s.Sleep.String()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s 0x%02X", s.Op, s.Reg)
+	for _, a := range s.Args {
+		fmt.Fprintf(&b, " 0x%X", a)
+	}
+	return b.String()
+}
+
+// arity is the count of arguments after the register, -1 for one or more.
+var arity = map[string]int{
+	"r8": 0, "r16be": 0, "r16le": 0, "rs16be": 0, "rs16le": 0, "rb": 1,
+	"w8": 1, "w16be": 1, "w16le": 1, "wb": -1,
+}
+
+// argMax is the largest value allowed for the arguments of a statement.
+var argMax = map[string]uint64{
+	"rb": 0xFF, "w8": 0xFF, "w16be": 0xFFFF, "w16le": 0xFFFF, "wb": 0xFF,
+}
+
+// Parse parses src into statements.
+func Parse(src string) ([]Stmt, error) {
+	var stmts []Stmt
+	for i, line := range strings.Split(src, "\n") {
+		if c := strings.IndexByte(line, '#'); c >= 0 {
+			line = line[:c]
+		}
+		for _, part := range strings.Split(line, ";") {
+			f := strings.Fields(part)
+			if len(f) == 0 {
+				continue
+			}
+			s, err := parseStmt(f)
+			if err != nil {
+				return nil, fmt.Errorf("script: line %d: %q: %w", i+1, strings.TrimSpace(part), err)
+			}
+			s.Line = i + 1
+			stmts = append(stmts, s)
+		}
+	}
+	return stmts, nil
+}
+
+func parseStmt(f []string) (Stmt, error) {
+	s := Stmt{Op: strings.ToLower(f[0])}
+	if s.Op == "sleep" {
+		if len(f) != 2 {
+			return s, fmt.Errorf("sleep takes a duration")
+		}
+		d, err := time.ParseDuration(f[1])
+		if err != nil || d < 0 {
+			return s, fmt.Errorf("bad duration %q", f[1])
+		}
+		s.Sleep = d
+		return s, nil
+	}
+	n, ok := arity[s.Op]
+	if !ok {
+		return s, fmt.Errorf("unknown command %q", f[0])
+	}
+	if len(f) < 2 || (n >= 0 && len(f) != n+2) || (n < 0 && len(f) < 3) {
+		return s, fmt.Errorf("wrong count of arguments")
+	}
+	reg, err := strconv.ParseUint(f[1], 0, 8)
+	if err != nil {
+		return s, fmt.Errorf("bad register %q", f[1])
+	}
+	s.Reg = byte(reg)
+	for _, a := range f[2:] {
+		x, err := strconv.ParseUint(a, 0, 64)
+		if err != nil || x > argMax[s.Op] {
+			return s, fmt.Errorf("bad value %q", a)
+		}
+		s.Args = append(s.Args, x)
+	}
+	return s, nil
+}
+
+// Runner executes statements on a device.
+type Runner struct {
+	// Check, if not nil, is called before executing each statement and
+	// may veto it by returning an error, e.g. to enforce an access policy.
+	Check func(Stmt) error
+	// MaxSleep caps the total time a script may sleep, 5s when zero.
+	MaxSleep time.Duration
+}
+
+// Run checks all the statements, then executes them on v printing the
+// result of reads to out.
+func (r *Runner) Run(v *i2c.I2C, stmts []Stmt, out io.Writer) error {
+	max := r.MaxSleep
+	if max == 0 {
+		max = 5 * time.Second
+	}
+	var slept time.Duration
+	for _, s := range stmts {
+		slept += s.Sleep
+		if slept > max {
+			return fmt.Errorf("script: line %d: total sleep exceeds %v", s.Line, max)
+		}
+		if r.Check != nil {
+			if err := r.Check(s); err != nil {
+				return fmt.Errorf("script: line %d: %s: %w", s.Line, s, err)
+			}
+		}
+	}
+	for _, s := range stmts {
+		if err := exec(v, s, out); err != nil {
+			return fmt.Errorf("script: line %d: %s: %w", s.Line, s, err)
+		}
+	}
+	return nil
+}
+
+// Run parses and runs src on v with the default Runner.
+func Run(v *i2c.I2C, src string, out io.Writer) error {
+	stmts, err := Parse(src)
+	if err != nil {
+		return err
+	}
+	return (&Runner{}).Run(v, stmts, out)
+}
+
+func exec(v *i2c.I2C, s Stmt, out io.Writer) error {
+	var (
+		val int64
+		err error
+	)
+	switch s.Op {
+	case "sleep":
+		time.Sleep(s.Sleep)
+		return nil
+	case "w8":
+		return v.WriteRegU8(s.Reg, byte(s.Args[0]))
+	case "w16be":
+		return v.WriteRegU16BE(s.Reg, uint16(s.Args[0]))
+	case "w16le":
+		return v.WriteRegU16LE(s.Reg, uint16(s.Args[0]))
+	case "wb":
+		buf := make([]byte, len(s.Args))
+		for i, a := range s.Args {
+			buf[i] = byte(a)
+		}
+		_, err := v.Registers().WriteAt(buf, int64(s.Reg))
+		return err
+	case "rb":
+		buf, _, err := v.ReadRegBytes(s.Reg, int(s.Args[0]))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "%s = % X\n", s, buf)
+		return err
+	case "r8":
+		var b byte
+		b, err = v.ReadRegU8(s.Reg)
+		val = int64(b)
+	case "r16be", "r16le":
+		var w uint16
+		if s.Op == "r16be" {
+			w, err = v.ReadRegU16BE(s.Reg)
+		} else {
+			w, err = v.ReadRegU16LE(s.Reg)
+		}
+		val = int64(w)
+	case "rs16be", "rs16le":
+		var w int16
+		if s.Op == "rs16be" {
+			w, err = v.ReadRegS16BE(s.Reg)
+		} else {
+			w, err = v.ReadRegS16LE(s.Reg)
+		}
+		val = int64(w)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s = 0x%X (%d)\n", s, uint64(val)&0xFFFF, val)
+	return err
+}
@@ -0,0 +1,68 @@
+package i2c
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// Addresser is implemented by transports which can be retargeted to
+// another device address.
+type Addresser interface {
+	SetAddr(addr uint8) error
+}
+
+// Addr returns the address of the device the connection talks to.
+func (v *I2C) Addr() uint8 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.addr
+}
+
+// Bus returns the bus number of the connection, -1 for connections
+// created with NewI2CConn.
+func (v *I2C) Bus() int {
+	return v.bus
+}
+
+// SetAddr retargets the connection to the device at addr, without
+// reopening the bus. This is handy to talk in turn to many identical
+// devices, or to follow a device changing address. Per device settings
+// shared across connections, such as rate and concurrency limits, are
+// the ones of the new device; connections created with NewI2CConn keep
+// them per address instead. It fails with ErrUnsupported when the
+// transport can be neither retargeted nor given the address by ioctl.
+func (v *I2C) SetAddr(addr uint8) error {
+	if err := ValidateAddr(addr); err != nil {
+		return err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	switch rc := v.rc.(type) {
+	case Addresser:
+		if err := rc.SetAddr(addr); err != nil {
+			return err
+		}
+	case syscall.Conn:
+		if err := v.setSlave(addr); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: %T cannot be retargeted", ErrUnsupported, v.rc)
+	}
+	if v.bus >= 0 {
+		v.dev.Store(sharedDevice(v.bus, addr))
+	} else {
+		if v.devs == nil {
+			v.devs = map[uint8]*device{v.addr: v.dev.Load()}
+		}
+		d := v.devs[addr]
+		if d == nil {
+			d = newDevice()
+			v.devs[addr] = d
+		}
+		v.dev.Store(d)
+	}
+	v.addr = addr
+	v.cache.reset()
+	return nil
+}
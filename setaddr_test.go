@@ -0,0 +1,39 @@
+package i2c
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSetAddrUnsupported(t *testing.T) {
+	v := NewI2CConn(NewDryRun(nil), 0x40)
+	if err := v.SetAddr(0x41); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("got %v, want ErrUnsupported", err)
+	}
+	if a := v.Addr(); a != 0x40 {
+		t.Fatalf("address changed to 0x%02X", a)
+	}
+}
+
+func TestSetAddrDeviceState(t *testing.T) {
+	v := OpenBus(busFunc(func(addr uint16, w, r []byte) error { return nil }), 0x40)
+	first := v.dev.Load()
+	if err := v.SetAddr(0x41); err != nil {
+		t.Fatal(err)
+	}
+	if v.dev.Load() == first {
+		t.Fatal("device state kept after retargeting")
+	}
+	if err := v.SetAddr(0x40); err != nil {
+		t.Fatal(err)
+	}
+	if v.dev.Load() != first {
+		t.Fatal("device state not restored retargeting back")
+	}
+}
+
+type busFunc func(addr uint16, w, r []byte) error
+
+func (f busFunc) Tx(addr uint16, w, r []byte) error {
+	return f(addr, w, r)
+}
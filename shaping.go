@@ -0,0 +1,137 @@
+package i2c
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// shaping holds the traffic shaping settings of a connection or a bus,
+// and the transfer limit of the transport of a connection. Values are
+// accessed atomically so they can be changed while transfers are running.
+type shaping struct {
+	burst atomic.Int64
+	gap   atomic.Int64
+	limit atomic.Int64
+}
+
+var busShapes = make(map[int]*shaping) // guarded by devicesMu
+
+// busShaping returns the shaping settings of bus.
+func busShaping(bus int) *shaping {
+	devicesMu.Lock()
+	defer devicesMu.Unlock()
+	s, ok := busShapes[bus]
+	if !ok {
+		s = new(shaping)
+		busShapes[bus] = s
+	}
+	return s
+}
+
+func (s *shaping) set(burst int, gap time.Duration) {
+	s.burst.Store(int64(max(burst, 0)))
+	s.gap.Store(int64(gap))
+}
+
+// SetShaping caps register addressed block transfers (ReadRegBytes,
+// WriteRegBytes, the register view, ReadStruct and the Coalescer) to
+// burst bytes per transaction, inserting an idle gap between consecutive
+// transactions. This reduces EMI during sensitive measurement windows. A
+// burst of 0 disables shaping of the connection, which then follows the
+// settings of its bus, see SetBusShaping. It is safe to call while other
+// goroutines are using the connection.
+func (v *I2C) SetShaping(burst int, gap time.Duration) {
+	v.shape.set(burst, gap)
+}
+
+// Shaping returns the traffic shaping settings of the connection.
+func (v *I2C) Shaping() (burst int, gap time.Duration) {
+	return int(v.shape.burst.Load()), time.Duration(v.shape.gap.Load())
+}
+
+// SetBusShaping sets the traffic shaping settings of the connections to
+// the devices of bus which have none of their own, see SetShaping, so
+// that a measurement window can quiet a whole bus at runtime. A burst of
+// 0 disables it.
+func SetBusShaping(bus int, burst int, gap time.Duration) {
+	busShaping(bus).set(burst, gap)
+}
+
+// BusShaping returns the traffic shaping settings of bus.
+func BusShaping(bus int) (burst int, gap time.Duration) {
+	s := busShaping(bus)
+	return int(s.burst.Load()), time.Duration(s.gap.Load())
+}
+
+// SetTransferLimit caps register addressed block transfers to n bytes per
+// transaction, 0 for no cap, for transports bounding the length of their
+// transfers. It is set by their Open functions and combines with, rather
+// than replaces, the traffic shaping settings.
+func (v *I2C) SetTransferLimit(n int) {
+	v.shape.limit.Store(int64(max(n, 0)))
+}
+
+// chunks calls fn for each chunk of the n bytes long transfer allowed by
+// the transfer limit and the shaping settings, sleeping for the shaping
+// gap in between. fn returns the count of bytes transferred, a short
+// count stops the iteration.
+func (v *I2C) chunks(n int, fn func(off, end int) (int, error)) (int, error) {
+	burst, gap := v.Shaping()
+	if burst == 0 && v.bus >= 0 {
+		burst, gap = BusShaping(v.bus)
+	}
+	if burst == 0 {
+		burst, gap = n, 0
+	}
+	if l := int(v.shape.limit.Load()); l > 0 && l < burst {
+		burst = l
+	}
+	done := 0
+	for done < n {
+		end := min(done+burst, n)
+		if done > 0 && gap > 0 {
+			time.Sleep(gap)
+		}
+		c, err := fn(done, end)
+		short := c < end-done
+		done += c
+		if err != nil || short {
+			return done, err
+		}
+	}
+	return done, nil
+}
+
+// blockRange checks that the block of n bytes starting from register reg
+// ends within the 256 registers, so that the chunks do not wrap to
+// register 0.
+func blockRange(reg byte, n int) error {
+	if int(reg)+n > regSpace {
+		return fmt.Errorf("%w: %d bytes from 0x%02X", ErrRegisterRange, n, reg)
+	}
+	return nil
+}
+
+// readBlock read len(buf) bytes starting from register reg, honoring the
+// traffic shaping settings.
+func (v *I2C) readBlock(ctx context.Context, reg byte, buf []byte) (int, error) {
+	if err := blockRange(reg, len(buf)); err != nil {
+		return 0, err
+	}
+	return v.chunks(len(buf), func(off, end int) (int, error) {
+		return v.readReg(ctx, reg+byte(off), buf[off:end])
+	})
+}
+
+// writeBlock write buf starting from register reg, honoring the traffic
+// shaping settings.
+func (v *I2C) writeBlock(ctx context.Context, reg byte, buf []byte) (int, error) {
+	if err := blockRange(reg, len(buf)); err != nil {
+		return 0, err
+	}
+	return v.chunks(len(buf), func(off, end int) (int, error) {
+		return v.writeReg(ctx, reg+byte(off), buf[off:end])
+	})
+}
@@ -0,0 +1,77 @@
+package i2c
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// writeLens returns the lengths of the writes of d.
+func writeLens(d *DryRun) []int {
+	var l []int
+	for _, w := range d.Writes() {
+		l = append(l, len(w))
+	}
+	return l
+}
+
+func TestShapingTransferLimit(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		burst int
+		want  []int
+	}{
+		{"limit", 0, []int{5, 5, 3}},
+		{"burst below limit", 3, []int{4, 4, 4, 2}},
+		{"burst above limit", 8, []int{5, 5, 3}},
+	} {
+		d := NewDryRun(nil)
+		v := NewI2CConn(d, 0x40)
+		v.SetTransferLimit(4)
+		v.SetShaping(c.burst, 0)
+		if _, err := v.WriteRegBytes(0x10, make([]byte, 10)); err != nil {
+			t.Fatal(err)
+		}
+		if got := writeLens(d); !slices.Equal(got, c.want) {
+			t.Errorf("%s: got writes %v, want %v", c.name, got, c.want)
+		}
+		v.Close()
+	}
+}
+
+func TestBusShaping(t *testing.T) {
+	d := NewDryRun(nil)
+	v := &I2C{rc: d, bus: 98, addr: 0x40, mu: busLock(98)}
+	v.dev.Store(sharedDevice(98, 0x40))
+	SetBusShaping(98, 4, 0)
+	defer SetBusShaping(98, 0, 0)
+	if _, err := v.WriteRegBytes(0x10, make([]byte, 6)); err != nil {
+		t.Fatal(err)
+	}
+	v.SetShaping(2, 0)
+	if _, err := v.WriteRegBytes(0x10, make([]byte, 4)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := writeLens(d), []int{5, 3, 3, 3}; !slices.Equal(got, want) {
+		t.Fatalf("got writes %v, want %v", got, want)
+	}
+}
+
+func TestShapingRegisterRange(t *testing.T) {
+	d := NewDryRun(nil)
+	v := NewI2CConn(d, 0x40)
+	defer v.Close()
+	v.SetShaping(4, 0)
+	if _, err := v.WriteRegBytes(0xFC, make([]byte, 6)); !errors.Is(err, ErrRegisterRange) {
+		t.Errorf("write past register 0xFF: got %v, want ErrRegisterRange", err)
+	}
+	if _, err := v.ReadRegBytesInto(0xFC, make([]byte, 6)); !errors.Is(err, ErrRegisterRange) {
+		t.Errorf("read past register 0xFF: got %v, want ErrRegisterRange", err)
+	}
+	if n := len(d.Writes()); n != 0 {
+		t.Errorf("got %d writes, want none", n)
+	}
+	if _, err := v.WriteRegBytes(0xFC, make([]byte, 4)); err != nil {
+		t.Errorf("write up to register 0xFF: %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+package i2c
+
+// SignExtend decodes the bits wide two's complement value found at bit
+// shift of raw, e.g. a 12 bit temperature left aligned in a 16 bit
+// register is SignExtend(uint64(w), 12, 4), and a 20 bit ADC result in the
+// low bits of a 24 bit register is SignExtend(uint64(w), 20, 0). bits must
+// be between 1 and 64.
+func SignExtend(raw uint64, bits, shift uint) int64 {
+	return int64(raw>>shift<<(64-bits)) >> (64 - bits)
+}
+
+// ZeroExtend returns the bits wide unsigned value found at bit shift of
+// raw, the unsigned counterpart of SignExtend.
+func ZeroExtend(raw uint64, bits, shift uint) uint64 {
+	return raw >> shift << (64 - bits) >> (64 - bits)
+}
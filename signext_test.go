@@ -0,0 +1,73 @@
+package i2c
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+func TestReadRegSigned(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		be   []byte // register bytes, most significant first
+		read func(v *I2C, order binary.ByteOrder) (int32, error)
+		want int32
+	}{
+		{"S24 max", []byte{0x7F, 0xFF, 0xFF}, s24, 8388607},
+		{"S24 min", []byte{0x80, 0x00, 0x00}, s24, -8388608},
+		{"S24 -1", []byte{0xFF, 0xFF, 0xFF}, s24, -1},
+		{"S24 1", []byte{0x00, 0x00, 0x01}, s24, 1},
+		{"S32 max", []byte{0x7F, 0xFF, 0xFF, 0xFF}, s32, 2147483647},
+		{"S32 min", []byte{0x80, 0x00, 0x00, 0x00}, s32, -2147483648},
+		{"S32 -1", []byte{0xFF, 0xFF, 0xFF, 0xFF}, s32, -1},
+		{"S32 -256", []byte{0xFF, 0xFF, 0xFF, 0x00}, s32, -256},
+	} {
+		for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+			seed := map[byte]byte{}
+			for i, b := range c.be {
+				if order == binary.LittleEndian {
+					b = c.be[len(c.be)-1-i]
+				}
+				seed[0x10+byte(i)] = b
+			}
+			v := NewI2CConn(NewDryRun(seed), 0x40)
+			got, err := c.read(v, order)
+			v.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Errorf("%s %v: got %d, want %d", c.name, order, got, c.want)
+			}
+		}
+	}
+}
+
+// s24 and s32 read register 0x10 with both the helpers taking an order and
+// the BE/LE ones, failing when they disagree.
+func s24(v *I2C, order binary.ByteOrder) (int32, error) {
+	return signedBoth(v, order, v.ReadRegS24, v.ReadRegS24BE, v.ReadRegS24LE)
+}
+
+func s32(v *I2C, order binary.ByteOrder) (int32, error) {
+	return signedBoth(v, order, v.ReadRegS32, v.ReadRegS32BE, v.ReadRegS32LE)
+}
+
+func signedBoth(v *I2C, order binary.ByteOrder, f func(byte, binary.ByteOrder) (int32, error), be, le func(byte) (int32, error)) (int32, error) {
+	a, err := f(0x10, order)
+	if err != nil {
+		return 0, err
+	}
+	g := be
+	if order == binary.LittleEndian {
+		g = le
+	}
+	b, err := g(0x10)
+	if err != nil {
+		return 0, err
+	}
+	if a != b {
+		return 0, fmt.Errorf("ordered read %d, named read %d", a, b)
+	}
+	return a, nil
+}
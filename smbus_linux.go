@@ -0,0 +1,114 @@
+//go:build !baremetal
+
+package i2c
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+)
+
+// OpenSMBus opens a connection to the device at addr on bus performing
+// SMBus transfers, for adapters which cannot perform plain I2C ones, such
+// as SMBus host controllers and the i2c-stub test module. Writes of one
+// and two bytes are SMBus write byte and write byte data transfers,
+// longer ones I2C block writes of up to 32 bytes each, and reads are SMBus
+// read byte transfers, one per byte, from a device auto-incrementing its
+// register pointer. Combined transfers (Tx, Transfer, BatchRead) fall back
+// to consecutive transfers under the same lock.
+func OpenSMBus(addr uint8, bus int) (*I2C, error) {
+	if err := ValidateAddr(addr); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(fmt.Sprintf("/dev/i2c-%d", bus), os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	c := &smbusConn{f: f}
+	if err := c.SetAddr(addr); err != nil {
+		f.Close()
+		return nil, err
+	}
+	v := &I2C{rc: c, bus: bus, addr: addr, mu: busLock(bus)}
+	v.dev.Store(sharedDevice(bus, addr))
+	track(v)
+	return v, nil
+}
+
+// smbusConn is a Conn performing SMBus transfers. It does not implement
+// syscall.Conn, which would make the connection use I2C_RDWR.
+type smbusConn struct {
+	f *os.File
+}
+
+// smbus performs an SMBus transfer on the descriptor of c. data holds
+// the union i2c_smbus_data, nil for quick transfers.
+func (c *smbusConn) smbus(rw, cmd uint8, size uint32, data *[smbusBlockMax + 2]byte) error {
+	rc, err := c.f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	req := i2cSMBusData{readWrite: rw, command: cmd, size: size}
+	if data != nil {
+		req.data = unsafe.Pointer(&data[0])
+	}
+	var ferr error
+	if err := rc.Control(func(fd uintptr) { ferr = ioctlPtr(fd, i2cSMBus, unsafe.Pointer(&req)) }); err != nil {
+		return err
+	}
+	return ferr
+}
+
+func (c *smbusConn) Write(p []byte) (int, error) {
+	var data [smbusBlockMax + 2]byte
+	switch len(p) {
+	case 0:
+		return 0, c.smbus(smbusWrite, 0, smbusQuick, nil)
+	case 1:
+		return 1, c.smbus(smbusWrite, p[0], smbusByte, nil)
+	case 2:
+		data[0] = p[1]
+		if err := c.smbus(smbusWrite, p[0], smbusByteData, &data); err != nil {
+			return 0, err
+		}
+		return 2, nil
+	}
+	n := 1
+	for n < len(p) {
+		m := copy(data[1:smbusBlockMax+1], p[n:])
+		data[0] = byte(m)
+		if err := c.smbus(smbusWrite, p[0]+byte(n-1), smbusI2CBlockData, &data); err != nil {
+			return n, err
+		}
+		n += m
+	}
+	return n, nil
+}
+
+func (c *smbusConn) Read(p []byte) (int, error) {
+	var data [smbusBlockMax + 2]byte
+	for i := range p {
+		if err := c.smbus(smbusRead, 0, smbusByte, &data); err != nil {
+			return i, err
+		}
+		p[i] = data[0]
+	}
+	return len(p), nil
+}
+
+// SetAddr retargets the descriptor to addr.
+func (c *smbusConn) SetAddr(addr uint8) error {
+	rc, err := c.f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var ferr error
+	if err := rc.Control(func(fd uintptr) { ferr = ioctl(fd, i2cSlave, uintptr(addr)) }); err != nil {
+		return err
+	}
+	return ferr
+}
+
+func (c *smbusConn) Close() error {
+	return c.f.Close()
+}
@@ -0,0 +1,90 @@
+package i2c
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Stats holds cumulative transfer statistics of a connection.
+type Stats struct {
+	Reads        uint64
+	Writes       uint64
+	BytesRead    uint64
+	BytesWritten uint64
+	// Errors by class: not acknowledged, timed out and anything else.
+	Nacks    uint64
+	Timeouts uint64
+	Errors   uint64
+	// LastError is the time of the last failed transfer.
+	LastError time.Time
+	// AvgLatency is the average duration of a transfer.
+	AvgLatency time.Duration
+}
+
+type stats struct {
+	mu    sync.Mutex
+	s     Stats
+	total time.Duration
+}
+
+func (st *stats) record(t *Transaction) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	switch t.Op {
+	case OpRead:
+		st.s.Reads++
+		st.s.BytesRead += uint64(t.N)
+	case OpCombined:
+		// the messages all complete, or the transfer fails
+		for _, m := range t.Msgs {
+			n := uint64(len(m.Buf))
+			if t.Err != nil {
+				n = 0
+			}
+			if m.Read {
+				st.s.Reads++
+				st.s.BytesRead += n
+			} else {
+				st.s.Writes++
+				st.s.BytesWritten += n
+			}
+		}
+	default:
+		st.s.Writes++
+		st.s.BytesWritten += uint64(t.N)
+	}
+	st.total += t.Duration
+	if t.Err != nil {
+		var te interface{ Timeout() bool }
+		switch {
+		case IsNack(t.Err):
+			st.s.Nacks++
+		case errors.As(t.Err, &te) && te.Timeout():
+			st.s.Timeouts++
+		default:
+			st.s.Errors++
+		}
+		st.s.LastError = time.Now()
+	}
+}
+
+// Stats returns the cumulative transfer statistics of the connection. It
+// is safe to call while other goroutines are using the connection.
+func (v *I2C) Stats() Stats {
+	v.stats.mu.Lock()
+	defer v.stats.mu.Unlock()
+	s := v.stats.s
+	if n := s.Reads + s.Writes; n > 0 {
+		s.AvgLatency = v.stats.total / time.Duration(n)
+	}
+	return s
+}
+
+// ResetStats clears the transfer statistics of the connection.
+func (v *I2C) ResetStats() {
+	v.stats.mu.Lock()
+	defer v.stats.mu.Unlock()
+	v.stats.s = Stats{}
+	v.stats.total = 0
+}
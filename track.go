@@ -0,0 +1,120 @@
+package i2c
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"weak"
+)
+
+// openConn records an open connection. It only references the I2C value
+// weakly, so that leaked connections can be detected by the cleanup.
+type openConn struct {
+	rc      Conn
+	bus     int
+	addr    uint8
+	origin  string
+	v       weak.Pointer[I2C]
+	cleanup runtime.Cleanup
+}
+
+func (o *openConn) String() string {
+	return fmt.Sprintf("bus %d addr 0x%02X opened at %s", o.bus, o.addr, o.origin)
+}
+
+var (
+	openMu sync.Mutex
+	opened = make(map[*openConn]struct{})
+)
+
+// pkgPrefix prefixes the names of the functions of the package.
+var pkgPrefix = reflect.TypeFor[I2C]().PkgPath() + "."
+
+// track records v as open, with the location of the first caller outside
+// the package as origin. A cleanup rather than a finalizer reports the
+// leaks, as v is part of a cycle once middlewares are installed.
+func track(v *I2C) {
+	o := &openConn{rc: v.rc, bus: v.bus, addr: v.addr, origin: "unknown", v: weak.Make(v)}
+	var pc [16]uintptr
+	frames := runtime.CallersFrames(pc[:runtime.Callers(2, pc[:])])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, pkgPrefix) || strings.HasSuffix(f.File, "_test.go") {
+			o.origin = fmt.Sprintf("%s:%d", f.File, f.Line)
+			break
+		}
+		if !more {
+			break
+		}
+	}
+	openMu.Lock()
+	opened[o] = struct{}{}
+	openMu.Unlock()
+	v.open = o
+	o.cleanup = runtime.AddCleanup(v, func(o *openConn) {
+		if untrack(o) {
+			log.Printf("i2c: connection to %v was not closed", o)
+			o.rc.Close()
+		}
+	}, o)
+}
+
+func untrack(o *openConn) bool {
+	openMu.Lock()
+	defer openMu.Unlock()
+	_, ok := opened[o]
+	delete(opened, o)
+	return ok
+}
+
+// OpenConns describes the connections currently open, with the location
+// they were opened at.
+func OpenConns() []string {
+	openMu.Lock()
+	defer openMu.Unlock()
+	l := make([]string, 0, len(opened))
+	for o := range opened {
+		l = append(l, o.String())
+	}
+	sort.Strings(l)
+	return l
+}
+
+// CheckLeaks returns an error listing the connections still open, nil
+// when there are none. Tests call it at the end to detect leaks.
+func CheckLeaks() error {
+	l := OpenConns()
+	if len(l) == 0 {
+		return nil
+	}
+	return fmt.Errorf("i2c: %d connections not closed:\n\t%s", len(l), strings.Join(l, "\n\t"))
+}
+
+// CloseAll closes every open connection, e.g. during a graceful shutdown,
+// as Close does.
+func CloseAll() error {
+	openMu.Lock()
+	l := make([]*openConn, 0, len(opened))
+	for o := range opened {
+		l = append(l, o)
+	}
+	openMu.Unlock()
+	var errs []error
+	for _, o := range l {
+		var err error
+		if v := o.v.Value(); v != nil {
+			err = v.Close()
+		} else if untrack(o) {
+			err = o.rc.Close()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", o, err))
+		}
+	}
+	return errors.Join(errs...)
+}
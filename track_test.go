@@ -0,0 +1,86 @@
+package i2c
+
+import (
+	"bytes"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// closeConn is a DryRun signaling its Close.
+type closeConn struct {
+	*DryRun
+	closed chan struct{}
+}
+
+func (c *closeConn) Close() error {
+	close(c.closed)
+	return nil
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+func leak(c Conn) {
+	v := NewI2CConn(c, 0x40)
+	v.Use(func(next Handler) Handler { return next })
+	v.ReadRegU8(0x10)
+}
+
+func TestTrackLeak(t *testing.T) {
+	var out syncBuffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&out)
+	c := &closeConn{DryRun: NewDryRun(nil), closed: make(chan struct{})}
+	leak(c)
+	deadline := time.After(5 * time.Second)
+	for closed := false; !closed; {
+		runtime.GC()
+		select {
+		case <-c.closed:
+			closed = true
+		case <-deadline:
+			t.Fatal("leaked connection not closed")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if s := out.String(); !strings.Contains(s, "was not closed") || !strings.Contains(s, "track_test.go") {
+		t.Errorf("got log %q, want the leak reported at its origin in track_test.go", s)
+	}
+}
+
+func TestCloseAll(t *testing.T) {
+	v := OpenBus(busFunc(func(addr uint16, w, r []byte) error { return nil }), 0x40)
+	rebound := 0
+	v.rebind = func() error {
+		rebound++
+		return nil
+	}
+	if err := CloseAll(); err != nil {
+		t.Fatal(err)
+	}
+	if rebound != 1 || v.rebind != nil {
+		t.Fatalf("got %d rebinds, want the driver bound back once", rebound)
+	}
+	if err := v.Close(); err != nil || rebound != 1 {
+		t.Fatalf("closing again: %v, %d rebinds", err, rebound)
+	}
+}
@@ -0,0 +1,338 @@
+package i2c
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Op is the direction of a transaction.
+type Op int
+
+const (
+	// OpWrite sends bytes to the device.
+	OpWrite Op = iota
+	// OpRead receives bytes from the device.
+	OpRead
+	// OpCombined performs the messages of a combined transfer, e.g. a
+	// register pointer write and the following read, with repeated starts
+	// between them and a single stop at the end.
+	OpCombined
+)
+
+func (o Op) String() string {
+	switch o {
+	case OpRead:
+		return "read"
+	case OpCombined:
+		return "combined"
+	}
+	return "write"
+}
+
+// NoReg is the register of transactions which are not register addressed,
+// such as the ones issued by WriteBytes and ReadBytes.
+const NoReg = -1
+
+// Transaction describes a single transfer with the remote device.
+type Transaction struct {
+	Bus  int
+	Addr uint8
+	Op   Op
+	// Reg is the register the transfer belongs to, or NoReg. Register
+	// writes carry the register address as first byte of Buf.
+	Reg int
+	// Buf holds the bytes to send or the buffer receiving the reply.
+	Buf []byte
+	// Msgs holds the messages of an OpCombined transaction, whose Buf is
+	// nil. As for Transfer, messages with a zero Addr are sent to Addr.
+	Msgs []Msg
+	// N is the count of bytes transferred, by all the messages of an
+	// OpCombined transaction.
+	N int
+	// Duration is the time spent performing the transfer.
+	Duration time.Duration
+	Err      error
+	// Context is the context of the operation the transfer belongs to,
+	// given to the Context variants of the operations, e.g. TxContext,
+	// and context.Background() otherwise.
+	Context context.Context
+	// Attempt is the attempt of the operation the transfer belongs to,
+	// 1 for the first one and more when retried by the RetryPolicy.
+	Attempt int
+}
+
+// Handler performs a transaction, filling its N, Duration and Err fields.
+// The Transaction is only valid during the call and must not be retained.
+type Handler func(t *Transaction)
+
+// Middleware wraps a Handler to observe or modify every transaction of a
+// connection. Code before calling next sees the outgoing transaction,
+// code after it sees the result. A middleware may also skip next (fault
+// injection) or call it several times (retries).
+type Middleware func(next Handler) Handler
+
+// middlewares holds the middleware chain of a connection.
+type middlewares struct {
+	mu      sync.Mutex
+	list    []Middleware
+	handler atomic.Pointer[Handler]
+}
+
+// Use appends mw to the middleware chain of the connection. The first
+// middleware added is the outermost one.
+func (v *I2C) Use(mw ...Middleware) {
+	v.mw.mu.Lock()
+	defer v.mw.mu.Unlock()
+	v.mw.list = append(v.mw.list, mw...)
+	h := Handler(v.transfer)
+	for i := len(v.mw.list) - 1; i >= 0; i-- {
+		h = v.mw.list[i](h)
+	}
+	v.mw.handler.Store(&h)
+}
+
+// transfer is the innermost handler, talking to the device.
+func (v *I2C) transfer(t *Transaction) {
+	start := time.Now()
+	switch t.Op {
+	case OpRead:
+		t.N, t.Err = v.rc.Read(t.Buf)
+	case OpCombined:
+		t.N, t.Err = v.combined(t.Msgs)
+	default:
+		t.N, t.Err = v.rc.Write(t.Buf)
+	}
+	t.Duration = time.Since(start)
+	v.stats.record(t)
+}
+
+// xfer runs a transaction through the middleware chain. It must be
+// called with the connection lock held, as the Transaction is reused.
+func (v *I2C) xfer(op Op, reg int, buf []byte) (int, error) {
+	t := &v.tx
+	ctx := v.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	*t = Transaction{Bus: v.bus, Addr: v.addr, Op: op, Reg: reg, Buf: buf, Context: ctx, Attempt: max(v.try, 1)}
+	if h := v.mw.handler.Load(); h != nil {
+		(*h)(t)
+	} else {
+		v.transfer(t)
+	}
+	// do not retain the caller buffer and context
+	t.Buf, t.Context = nil, nil
+	return t.N, t.Err
+}
+
+// xferMsgs runs msgs as an OpCombined transaction through the middleware
+// chain. It must be called with the connection lock held.
+func (v *I2C) xferMsgs(reg int, msgs []Msg) error {
+	t := &v.tx
+	ctx := v.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	*t = Transaction{Bus: v.bus, Addr: v.addr, Op: OpCombined, Reg: reg, Msgs: msgs, Context: ctx, Attempt: max(v.try, 1)}
+	if h := v.mw.handler.Load(); h != nil {
+		(*h)(t)
+	} else {
+		v.transfer(t)
+	}
+	t.Msgs, t.Context = nil, nil
+	return t.Err
+}
+
+// do runs fn as one logical operation with the device, e.g. a register
+// read made of a pointer write and a read, applying the device policies.
+// fn runs with the connection lock held.
+func (v *I2C) do(fn func() error) error {
+	return v.doContext(context.Background(), fn)
+}
+
+// doContext is do, for an operation with context ctx. Retries stop when
+// ctx is done.
+func (v *I2C) doContext(ctx context.Context, fn func() error) error {
+	if d := v.dev.Load(); d != nil {
+		if err := d.flight.acquire(); err != nil {
+			return err
+		}
+		defer d.flight.release()
+	}
+	p := v.retry.Load()
+	for n := 1; ; n++ {
+		err := v.attempt(ctx, n, fn)
+		if err == nil || p == nil || n >= p.Attempts || !p.retryable(err) {
+			return err
+		}
+		if p.Backoff != nil {
+			time.Sleep(p.Backoff(n))
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+// attempt runs fn as the n-th attempt of an operation with context ctx,
+// within the rate limits of the device.
+func (v *I2C) attempt(ctx context.Context, n int, fn func() error) error {
+	if d := v.dev.Load(); d != nil {
+		d.limit.wait()
+		defer d.limit.done()
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.plock != nil {
+		if err := v.plock.lock(); err != nil {
+			return err
+		}
+		defer v.plock.unlock()
+	}
+	v.ctx, v.try = ctx, n
+	defer func() { v.ctx, v.try = nil, 0 }()
+	return fn()
+}
+
+// readReg read len(buf) bytes from the device starting from register reg.
+func (v *I2C) readReg(ctx context.Context, reg byte, buf []byte) (n int, err error) {
+	if err := v.Ready(); err != nil {
+		return 0, err
+	}
+	if v.cache.load(reg, buf) {
+		return len(buf), nil
+	}
+	err = v.doContext(ctx, func() (err error) {
+		n, err = v.readRegLocked(reg, buf)
+		return err
+	})
+	if err == nil {
+		v.cache.store(reg, buf[:n])
+	} else {
+		// the device may hold the old value, the new one or a part of it
+		v.cache.drop(reg, len(buf))
+	}
+	return n, err
+}
+
+// readRegLocked is readReg bypassing the cache, with the lock held.
+// Connections opened with OpenBus, and transports with a Tx method, send
+// the pointer write and the read as one OpCombined transaction, unless
+// they have quirks or a turnaround delay. Register reads of descriptors
+// stay a write and a read, which SMBus only adapters accept.
+func (v *I2C) readRegLocked(reg byte, buf []byte) (int, error) {
+	v.regBuf[0] = reg
+	if _, ok := v.rc.(txConn); ok && v.combines() {
+		v.msgs = [2]Msg{{Buf: v.regBuf[:]}, {Read: true, Buf: buf}}
+		err := v.xferMsgs(int(reg), v.msgs[:])
+		v.msgs = [2]Msg{}
+		if err != nil {
+			return 0, err
+		}
+		return len(buf), nil
+	}
+	if _, err := v.xfer(OpWrite, int(reg), v.regBuf[:]); err != nil {
+		return 0, err
+	}
+	if d := v.turn.Load(); d > 0 {
+		time.Sleep(time.Duration(d))
+	}
+	q := v.quirks.Load()
+	if q == nil || q.ReadDummy == 0 {
+		return v.xfer(OpRead, int(reg), buf)
+	}
+	var dst []byte
+	if q.ReadDummy+len(buf) <= len(v.scratch) {
+		dst = v.scratch[:q.ReadDummy+len(buf)]
+	} else {
+		dst = make([]byte, q.ReadDummy+len(buf))
+	}
+	n, err := v.xfer(OpRead, int(reg), dst)
+	return copy(buf, dst[min(n, q.ReadDummy):n]), err
+}
+
+// readRegN read the n bytes long (up to 8) big endian value starting from
+// register reg, without allocating.
+func (v *I2C) readRegN(reg byte, n int) (uint64, error) {
+	if err := v.Ready(); err != nil {
+		return 0, err
+	}
+	var b [8]byte
+	buf := b[:n]
+	if !v.cache.load(reg, buf) {
+		err := v.do(func() error {
+			c, err := v.readRegLocked(reg, v.scratch[:n])
+			if err == nil && c < n {
+				err = io.ErrUnexpectedEOF
+			}
+			copy(buf, v.scratch[:n])
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+		v.cache.store(reg, buf)
+	}
+	var w uint64
+	for _, c := range buf {
+		w = w<<8 | uint64(c)
+	}
+	return w, nil
+}
+
+// writeReg write buf to the device starting from register reg. The
+// returned count does not include the register address byte.
+func (v *I2C) writeReg(ctx context.Context, reg byte, buf []byte) (n int, err error) {
+	err = v.doContext(ctx, func() (err error) {
+		n, err = v.writeRegLocked(reg, buf)
+		return err
+	})
+	if err == nil && v.verify.Load() {
+		v.cache.drop(reg, len(buf))
+		err = v.verifyReg(ctx, reg, buf)
+	}
+	if err == nil {
+		v.cache.store(reg, buf[:n])
+	} else {
+		// the device may hold the old value, the new one or a part of it
+		v.cache.drop(reg, len(buf))
+	}
+	return n, err
+}
+
+// writeRegLocked is writeReg, with the lock held.
+func (v *I2C) writeRegLocked(reg byte, buf []byte) (int, error) {
+	head := 1
+	q := v.quirks.Load()
+	if q != nil {
+		head += q.WriteDummy
+	}
+	var msg []byte
+	if head+len(buf) <= len(v.scratch) {
+		msg = v.scratch[:head+len(buf)]
+	} else {
+		msg = make([]byte, head+len(buf))
+	}
+	msg[0] = reg
+	for i := 1; i < head; i++ {
+		msg[i] = q.Dummy
+	}
+	copy(msg[head:], buf)
+	n, err := v.xfer(OpWrite, int(reg), msg)
+	return max(n-head, 0), err
+}
+
+// writeRegN write the n bytes long (up to 8) big endian value w starting
+// from register reg, without allocating.
+func (v *I2C) writeRegN(reg byte, w uint64, n int) error {
+	var b [8]byte
+	for i := n - 1; i >= 0; i-- {
+		b[i] = byte(w)
+		w >>= 8
+	}
+	_, err := v.writeReg(context.Background(), reg, b[:n])
+	return err
+}
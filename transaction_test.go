@@ -0,0 +1,188 @@
+package i2c
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+// flakyConn is a DryRun failing its first fails writes.
+type flakyConn struct {
+	*DryRun
+	fails int
+}
+
+var errFlaky = errors.New("flaky")
+
+func (c *flakyConn) Write(p []byte) (int, error) {
+	if c.fails > 0 {
+		c.fails--
+		return 0, errFlaky
+	}
+	return c.DryRun.Write(p)
+}
+
+type ctxKey struct{}
+
+func TestTransactionContext(t *testing.T) {
+	v := NewI2CConn(&flakyConn{DryRun: NewDryRun(nil), fails: 1}, 0x50)
+	v.SetRetryPolicy(RetryPolicy{Attempts: 3, Retryable: func(err error) bool { return err == errFlaky }})
+	type seen struct {
+		val     any
+		attempt int
+	}
+	var got []seen
+	v.Use(func(next Handler) Handler {
+		return func(t *Transaction) {
+			got = append(got, seen{t.Context.Value(ctxKey{}), t.Attempt})
+			next(t)
+		}
+	})
+	ctx := context.WithValue(context.Background(), ctxKey{}, "op")
+	if err := v.TxContext(ctx, []byte{0x10}, make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+	want := []seen{{"op", 1}, {"op", 2}, {"op", 2}}
+	if len(got) != len(want) {
+		t.Fatalf("got %d transactions %v, want %v", len(got), got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("transaction %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	got = nil
+	if _, err := v.ReadRegBytesInto(0x10, make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range got {
+		if s.val != nil || s.attempt != 1 {
+			t.Errorf("transaction %d without context: got %v", i, s)
+		}
+	}
+}
+
+func TestTransactionContextCanceled(t *testing.T) {
+	v := NewI2CConn(&flakyConn{DryRun: NewDryRun(nil), fails: 5}, 0x50)
+	v.SetRetryPolicy(RetryPolicy{Attempts: 5, Retryable: func(err error) bool { return err == errFlaky }})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	n := 0
+	v.Use(func(next Handler) Handler {
+		return func(t *Transaction) {
+			n++
+			next(t)
+		}
+	})
+	if err := v.TxContext(ctx, []byte{0x10}, nil); err != errFlaky {
+		t.Fatalf("got %v, want %v", err, errFlaky)
+	}
+	if n != 1 {
+		t.Errorf("got %d attempts with a done context, want 1", n)
+	}
+}
+
+func TestTransactionCombined(t *testing.T) {
+	var txs int
+	v := OpenBus(busFunc(func(addr uint16, w, r []byte) error {
+		txs++
+		if addr != 0x40 || len(w) != 1 || len(r) != 2 {
+			t.Errorf("got tx to 0x%02X of % X and %d bytes", addr, w, len(r))
+		}
+		r[0], r[1] = 0xAB, 0xCD
+		return nil
+	}), 0x40)
+	var got []Transaction
+	v.Use(func(next Handler) Handler {
+		return func(t *Transaction) {
+			next(t)
+			got = append(got, *t)
+		}
+	})
+	r := make([]byte, 2)
+	err := v.do(func() error {
+		return v.xferMsgs(0x10, []Msg{{Buf: []byte{0x10}}, {Read: true, Buf: r}})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txs != 1 {
+		t.Fatalf("got %d bus transfers, want a single combined one", txs)
+	}
+	if len(got) != 1 || got[0].Op != OpCombined || got[0].Reg != 0x10 || got[0].N != 3 {
+		t.Fatalf("got transactions %+v, want one combined of 3 bytes", got)
+	}
+	if r[0] != 0xAB || r[1] != 0xCD {
+		t.Errorf("read % X, want AB CD", r)
+	}
+}
+
+func TestMiddlewareCombined(t *testing.T) {
+	var txs int
+	v := OpenBus(busFunc(func(addr uint16, w, r []byte) error {
+		txs++
+		for i := range r {
+			r[i] = 0xAB
+		}
+		return nil
+	}), 0x40)
+	var ops []Op
+	v.Use(func(next Handler) Handler {
+		return func(t *Transaction) {
+			ops = append(ops, t.Op)
+			next(t)
+		}
+	})
+	if err := v.Tx([]byte{0x10}, make([]byte, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.ReadRegU8(0x10); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Transfer(Msg{Buf: []byte{0x10}}, Msg{Read: true, Buf: make([]byte, 1)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.BatchRead([]RegRead{{0x10, make([]byte, 1)}, {0x20, make([]byte, 2)}}); err != nil {
+		t.Fatal(err)
+	}
+	if txs != 5 {
+		t.Errorf("got %d bus transfers, want 5", txs)
+	}
+	want := []Op{OpCombined, OpCombined, OpCombined, OpCombined}
+	if len(ops) != len(want) {
+		t.Fatalf("got transactions %v, want %v", ops, want)
+	}
+	for i := range want {
+		if ops[i] != want[i] {
+			t.Errorf("transaction %d: got %v, want %v", i, ops[i], want[i])
+		}
+	}
+}
+
+// ctxHandler is a slog.Handler recording the context values of ctxKey.
+type ctxHandler struct {
+	slog.Handler
+	vals *[]any
+}
+
+func (h ctxHandler) Handle(ctx context.Context, r slog.Record) error {
+	*h.vals = append(*h.vals, ctx.Value(ctxKey{}))
+	return nil
+}
+
+func TestLoggerContext(t *testing.T) {
+	var vals []any
+	h := ctxHandler{slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelDebug}), &vals}
+	v := NewI2CConn(NewDryRun(nil), 0x50)
+	v.Use(Logger(slog.New(h), false))
+	ctx := context.WithValue(context.Background(), ctxKey{}, "op")
+	if _, err := v.ReadRegBytesContext(ctx, 0x10, make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if len(vals) != 2 || vals[0] != "op" || vals[1] != "op" {
+		t.Errorf("got context values %v, want op for both transfers", vals)
+	}
+}
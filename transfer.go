@@ -0,0 +1,141 @@
+package i2c
+
+import (
+	"fmt"
+	"io"
+)
+
+// Msg is a message of a combined transfer.
+type Msg struct {
+	// Addr is the address of the device, zero for the address of the
+	// connection.
+	Addr uint8
+	// Read makes the message receive len(Buf) bytes into Buf, rather
+	// than sending Buf.
+	Read bool
+	Buf  []byte
+}
+
+// Transfer performs msgs as one combined transaction, each message after
+// a repeated start and a single stop at the end, as i2ctransfer does. On
+// Linux the messages are a single I2C_RDWR call, which accepts up to 42
+// messages, and may address other devices than the one of the
+// connection. Connections opened with OpenBus, and transports with a Tx
+// method, pair each write with the following read of the same device.
+// The messages go through the middlewares as a single OpCombined
+// transaction. Otherwise, or when the connection has quirks or a
+// turnaround delay, the messages are consecutive transfers under the same
+// lock, and messages to other devices fail with ErrUnsupported.
+func (v *I2C) Transfer(msgs ...Msg) error {
+	return v.do(func() error {
+		if v.combines() {
+			return v.xferMsgs(NoReg, msgs)
+		}
+		for _, m := range msgs {
+			if v.msgAddr(m) != v.addr {
+				return fmt.Errorf("%w: message to 0x%02X on a connection to 0x%02X", ErrUnsupported, m.Addr, v.addr)
+			}
+		}
+		for _, m := range msgs {
+			op, short := OpWrite, io.ErrShortWrite
+			if m.Read {
+				op, short = OpRead, io.ErrUnexpectedEOF
+			}
+			n, err := v.xfer(op, NoReg, m.Buf)
+			if err == nil && n < len(m.Buf) {
+				err = short
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// txConn is implemented by transports performing a write followed by a
+// read with a repeated start, e.g. BusConn.
+type txConn interface {
+	Tx(w, r []byte) error
+}
+
+// combines reports whether the transfers of the connection combine with
+// repeated starts: the transport performs them and no quirks or
+// turnaround delay require separate transfers.
+func (v *I2C) combines() bool {
+	if v.quirks.Load() != nil || v.turn.Load() != 0 {
+		return false
+	}
+	if _, ok := v.rc.(txConn); ok {
+		return true
+	}
+	return v.rdwrConn()
+}
+
+// combined performs msgs with repeated starts between them: in a single
+// I2C_RDWR call on Linux descriptors, or as pairs of a write and the
+// following read of the same device with transports implementing Tx. It
+// returns the count of bytes transferred. Only Linux descriptors and
+// OpenBus connections address other devices than the one of the
+// connection.
+func (v *I2C) combined(msgs []Msg) (int, error) {
+	total := 0
+	for _, m := range msgs {
+		total += len(m.Buf)
+	}
+	if ok, err := v.rdwrMsgs(msgs); ok {
+		if err != nil {
+			return 0, err
+		}
+		return total, nil
+	}
+	if _, ok := v.rc.(*BusConn); !ok {
+		for _, m := range msgs {
+			if a := v.msgAddr(m); a != v.addr {
+				return 0, fmt.Errorf("%w: message to 0x%02X on a connection to 0x%02X", ErrUnsupported, a, v.addr)
+			}
+		}
+	}
+	n := 0
+	for i := 0; i < len(msgs); {
+		m := msgs[i]
+		addr := v.msgAddr(m)
+		var w, r []byte
+		j := i + 1
+		if m.Read {
+			r = m.Buf
+		} else {
+			w = m.Buf
+			if j < len(msgs) && msgs[j].Read && v.msgAddr(msgs[j]) == addr {
+				r = msgs[j].Buf
+				j++
+			}
+		}
+		if err := v.pair(addr, w, r); err != nil {
+			return n, err
+		}
+		n += len(w) + len(r)
+		i = j
+	}
+	return n, nil
+}
+
+// pair writes w to the device at addr then reads r with a repeated
+// start through the transport of the connection.
+func (v *I2C) pair(addr uint8, w, r []byte) error {
+	switch c := v.rc.(type) {
+	case *BusConn:
+		return c.bus.Tx(uint16(addr), w, r)
+	case txConn:
+		return c.Tx(w, r)
+	}
+	return fmt.Errorf("%w: combined transfers", ErrUnsupported)
+}
+
+// msgAddr returns the address of the device m is sent to.
+func (v *I2C) msgAddr(m Msg) uint8 {
+	if m.Addr == 0 {
+		return v.addr
+	}
+	return m.Addr
+}
@@ -0,0 +1,35 @@
+//go:build !baremetal
+
+package i2c
+
+import "syscall"
+
+// rdwrMsgs performs msgs in a single I2C_RDWR call. It reports false when
+// the connection has no descriptor. It must be called with the lock held.
+func (v *I2C) rdwrMsgs(msgs []Msg) (bool, error) {
+	if !v.rdwrConn() {
+		return false, nil
+	}
+	if len(msgs) == 0 {
+		return true, nil
+	}
+	var buf [2]i2cMsg
+	raw := buf[:0]
+	for _, m := range msgs {
+		flags := uint16(0)
+		if m.Read {
+			flags = i2cMRd
+		}
+		raw = append(raw, newMsg(uint16(v.msgAddr(m)), flags, m.Buf))
+	}
+	return true, v.control(func(fd uintptr) error {
+		return rdwr(fd, raw)
+	})
+}
+
+// rdwrConn reports whether the connection has a descriptor accepting
+// I2C_RDWR calls.
+func (v *I2C) rdwrConn() bool {
+	_, ok := v.rc.(syscall.Conn)
+	return ok
+}
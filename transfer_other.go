@@ -0,0 +1,13 @@
+//go:build !linux || baremetal
+
+package i2c
+
+// rdwrMsgs reports false, combined transfers are only supported on Linux.
+func (v *I2C) rdwrMsgs(msgs []Msg) (bool, error) {
+	return false, nil
+}
+
+// rdwrConn reports false, combined transfers are only supported on Linux.
+func (v *I2C) rdwrConn() bool {
+	return false
+}
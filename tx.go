@@ -0,0 +1,52 @@
+package i2c
+
+import (
+	"context"
+	"io"
+)
+
+// Tx write w to the device then read len(r) bytes into r as one
+// operation, e.g. a command followed by its reply. Either may be empty.
+// On Linux both are combined in a single I2C_RDWR call with a repeated
+// start, as are the transfers of connections opened with OpenBus and of
+// transports with a Tx method, and go through the middlewares as an
+// OpCombined transaction. Connections with quirks or a turnaround delay
+// perform them as consecutive transfers under the same lock.
+func (v *I2C) Tx(w, r []byte) error {
+	return v.TxContext(context.Background(), w, r)
+}
+
+// TxContext is Tx for an operation with context ctx, see
+// ReadRegBytesContext.
+func (v *I2C) TxContext(ctx context.Context, w, r []byte) error {
+	if len(r) > 0 {
+		if err := v.Ready(); err != nil {
+			return err
+		}
+	}
+	return v.doContext(ctx, func() error {
+		if len(w) > 0 && len(r) > 0 && v.combines() {
+			v.msgs = [2]Msg{{Buf: w}, {Read: true, Buf: r}}
+			err := v.xferMsgs(NoReg, v.msgs[:])
+			v.msgs = [2]Msg{}
+			return err
+		}
+		if len(w) > 0 {
+			n, err := v.xfer(OpWrite, NoReg, w)
+			if err == nil && n < len(w) {
+				err = io.ErrShortWrite
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if len(r) > 0 {
+			n, err := v.xfer(OpRead, NoReg, r)
+			if err == nil && n < len(r) {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		return nil
+	})
+}
@@ -0,0 +1,74 @@
+package i2c
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+)
+
+// updateRegN replaces the bits selected by mask of the n bytes long big
+// endian value starting from register reg with the ones of value, as a
+// single operation under the bus lock.
+func (v *I2C) updateRegN(reg byte, n int, mask, value uint64) error {
+	var b [8]byte
+	buf := b[:n]
+	err := v.do(func() error {
+		c, err := v.readRegLocked(reg, v.scratch[:n])
+		if err != nil {
+			return err
+		}
+		if c < n {
+			return io.ErrUnexpectedEOF
+		}
+		var w uint64
+		for _, x := range v.scratch[:n] {
+			w = w<<8 | uint64(x)
+		}
+		w = w&^mask | value&mask
+		for i := n - 1; i >= 0; i-- {
+			buf[i] = byte(w)
+			w >>= 8
+		}
+		_, err = v.writeRegLocked(reg, buf)
+		return err
+	})
+	if err == nil && v.verify.Load() {
+		v.cache.drop(reg, n)
+		err = v.verifyReg(context.Background(), reg, buf)
+	}
+	if err == nil {
+		v.cache.store(reg, buf)
+	} else {
+		v.cache.drop(reg, n)
+	}
+	return err
+}
+
+// UpdateRegU8 read register reg, replace the bits selected by mask with
+// the ones of value and write it back, as a single operation which other
+// goroutines cannot interleave with.
+func (v *I2C) UpdateRegU8(reg byte, mask, value byte) error {
+	return v.updateRegN(reg, 1, uint64(mask), uint64(value))
+}
+
+// UpdateRegU16 read the word (16 bits) stored with the given byte order
+// starting from register reg, replace the bits selected by mask with the
+// ones of value and write it back, as a single operation which other
+// goroutines cannot interleave with.
+func (v *I2C) UpdateRegU16(reg byte, mask, value uint16, order binary.ByteOrder) error {
+	m, w := uint64(mask), uint64(value)
+	if little(order) {
+		m, w = bswap(m, 2), bswap(w, 2)
+	}
+	return v.updateRegN(reg, 2, m, w)
+}
+
+// UpdateRegU16BE is UpdateRegU16 for big endian words.
+func (v *I2C) UpdateRegU16BE(reg byte, mask, value uint16) error {
+	return v.UpdateRegU16(reg, mask, value, binary.BigEndian)
+}
+
+// UpdateRegU16LE is UpdateRegU16 for little endian words.
+func (v *I2C) UpdateRegU16LE(reg byte, mask, value uint16) error {
+	return v.UpdateRegU16(reg, mask, value, binary.LittleEndian)
+}
@@ -0,0 +1,7 @@
+module github.com/fedeonline/i2c-go/v2
+
+go 1.25.0
+
+require github.com/fedeonline/i2c-go v1.0.0
+
+require golang.org/x/sys v0.47.0 // indirect
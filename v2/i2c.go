@@ -0,0 +1,137 @@
+// Package i2c is version 2 of the API of github.com/fedeonline/i2c-go.
+//
+// It separates the bus from the devices on it, and replaces the fixed
+// width register helpers by a few generic operations:
+//
+//	bus := i2c.OpenBus(1)
+//	d, err := bus.Open(0x76)
+//	...
+//	id, err := i2c.ReadReg[uint8](d, 0xD0, binary.BigEndian)
+//	err = d.Read(0x88, calib[:])
+//
+// Version 1 keeps working unchanged and implements this one: a Device
+// wraps a version 1 connection, returned by Legacy and wrapped by Wrap,
+// so programs may migrate one call at a time and keep using the version 1
+// drivers and helpers meanwhile. Transaction and Middleware are the
+// version 1 types, so middlewares work with both.
+package i2c
+
+import (
+	"encoding/binary"
+	"io"
+
+	v1 "github.com/fedeonline/i2c-go"
+)
+
+// Types shared with version 1.
+type (
+	Transaction = v1.Transaction
+	Handler     = v1.Handler
+	Middleware  = v1.Middleware
+	Op          = v1.Op
+	Integer     = v1.Integer
+	// Transport is a bus reached without a Linux i2c-dev node, e.g. a
+	// mux channel, a USB bridge or a remote bus.
+	Transport = v1.Bus
+)
+
+// ErrUnsupported is returned when the requested operation is not
+// available on the running platform. It matches errors.ErrUnsupported.
+var ErrUnsupported = v1.ErrUnsupported
+
+// Bus is an i2c bus.
+type Bus struct {
+	n  int
+	tr Transport
+}
+
+// OpenBus returns the Linux bus n, /dev/i2c-n. The node is opened by
+// Open, once per device.
+func OpenBus(n int) *Bus {
+	return &Bus{n: n}
+}
+
+// NewBus returns the bus reached through t.
+func NewBus(t Transport) *Bus {
+	return &Bus{n: -1, tr: t}
+}
+
+// Open returns the device at addr on the bus.
+func (b *Bus) Open(addr uint8) (*Device, error) {
+	if b.tr != nil {
+		return Wrap(v1.OpenBus(b.tr, addr)), nil
+	}
+	c, err := v1.NewI2C(addr, b.n)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap(c), nil
+}
+
+// Device is a device on a bus. It is safe for concurrent use, with the
+// guarantees of version 1 connections.
+type Device struct {
+	c *v1.I2C
+}
+
+// Wrap returns the Device of the version 1 connection c.
+func Wrap(c *v1.I2C) *Device {
+	return &Device{c: c}
+}
+
+// Legacy returns the version 1 connection of d.
+func (d *Device) Legacy() *v1.I2C {
+	return d.c
+}
+
+// Addr returns the address of the device.
+func (d *Device) Addr() uint8 {
+	return d.c.Addr()
+}
+
+// Read reads len(p) bytes starting from register reg.
+func (d *Device) Read(reg byte, p []byte) error {
+	n, err := d.c.ReadRegBytesInto(reg, p)
+	if err == nil && n < len(p) {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Write writes p starting at register reg.
+func (d *Device) Write(reg byte, p []byte) error {
+	n, err := d.c.WriteRegBytes(reg, p)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	return err
+}
+
+// Tx writes w to the device then reads len(r) bytes into r as one
+// operation, with a repeated start where the transport supports it.
+func (d *Device) Tx(w, r []byte) error {
+	return d.c.Tx(w, r)
+}
+
+// Use appends middlewares to the chain run around every transaction of
+// the device.
+func (d *Device) Use(mw ...Middleware) {
+	d.c.Use(mw...)
+}
+
+// Close closes the device.
+func (d *Device) Close() error {
+	return d.c.Close()
+}
+
+// ReadReg reads a T stored with the given byte order starting from
+// register reg. The width of T sets the count of bytes.
+func ReadReg[T Integer](d *Device, reg byte, order binary.ByteOrder) (T, error) {
+	return v1.ReadReg[T](d.c, reg, order)
+}
+
+// WriteReg writes value with the given byte order starting at register
+// reg.
+func WriteReg[T Integer](d *Device, reg byte, value T, order binary.ByteOrder) error {
+	return v1.WriteReg(d.c, reg, value, order)
+}
@@ -0,0 +1,59 @@
+package i2c
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	v1 "github.com/fedeonline/i2c-go"
+)
+
+func TestDevice(t *testing.T) {
+	d := Wrap(v1.NewI2CConn(v1.NewDryRun(map[byte]byte{0xD0: 0x60}), 0x76))
+	if d.Addr() != 0x76 {
+		t.Fatalf("Addr() = 0x%02X, want 0x76", d.Addr())
+	}
+	id, err := ReadReg[uint8](d, 0xD0, binary.BigEndian)
+	if err != nil || id != 0x60 {
+		t.Fatalf("ReadReg = 0x%02X, %v, want 0x60", id, err)
+	}
+	if err := WriteReg[uint16](d, 0x10, 0x1234, binary.LittleEndian); err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, 2)
+	if err := d.Read(0x10, got); err != nil || !bytes.Equal(got, []byte{0x34, 0x12}) {
+		t.Fatalf("Read = % X, %v, want 34 12", got, err)
+	}
+	if err := d.Write(0x20, []byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	// the legacy helpers see the same device
+	w, err := d.Legacy().ReadRegU16BE(0x21)
+	if err != nil || w != 0x0203 {
+		t.Fatalf("ReadRegU16BE = 0x%04X, %v, want 0x0203", w, err)
+	}
+}
+
+func TestBusTransport(t *testing.T) {
+	var got uint16
+	bus := NewBus(busFunc(func(addr uint16, w, r []byte) error {
+		got = addr
+		return nil
+	}))
+	d, err := bus.Open(0x48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Tx([]byte{0}, make([]byte, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if got != 0x48 {
+		t.Fatalf("transport addressed 0x%02X, want 0x48", got)
+	}
+}
+
+type busFunc func(addr uint16, w, r []byte) error
+
+func (f busFunc) Tx(addr uint16, w, r []byte) error {
+	return f(addr, w, r)
+}
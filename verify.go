@@ -0,0 +1,42 @@
+package i2c
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// VerifyError is returned by register writes in verify mode when the
+// value read back differs from the written one.
+type VerifyError struct {
+	Reg   byte
+	Wrote []byte
+	Read  []byte
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("i2c: register 0x%02X verify failed: wrote % X, read back % X",
+		e.Reg, e.Wrote, e.Read)
+}
+
+// SetVerifyWrites enables or disables verify mode. In verify mode every
+// register write (WriteReg* helpers and the register view) reads the
+// registers back and returns a *VerifyError when the device did not
+// accept the value. Do not enable it for devices with write-only,
+// self-clearing or volatile registers.
+func (v *I2C) SetVerifyWrites(on bool) {
+	v.verify.Store(on)
+}
+
+// verifyReg reads back len(want) bytes starting from register reg and
+// compares them with want.
+func (v *I2C) verifyReg(ctx context.Context, reg byte, want []byte) error {
+	got := make([]byte, len(want))
+	if _, err := v.readReg(ctx, reg, got); err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return &VerifyError{Reg: reg, Wrote: append([]byte(nil), want...), Read: got}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package i2c
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrWarmingUp is returned while a device has not completed its declared
+// warm-up period and its readings should not be trusted yet.
+var ErrWarmingUp = errors.New("i2c: device warming up")
+
+// SetWarmUp declares that the device needs d of settling time (e.g. after
+// power-on, reset or mode change) before its readings are meaningful.
+// The period starts when SetWarmUp is called, and is shared by all the
+// connections to the device. Until its end, reads (ReadBytes, register
+// reads, BatchRead and Tx with a reply) fail with ErrWarmingUp, while
+// writes, read-modify-write updates and WaitForRegBit polls still run,
+// so that drivers can configure the device meanwhile.
+func (v *I2C) SetWarmUp(d time.Duration) {
+	v.dev.Load().readyAt.Store(time.Now().Add(d).UnixNano())
+}
+
+// ReadyAt returns the time when the device completes its warm-up period.
+// It returns the zero time when no warm-up was declared.
+func (v *I2C) ReadyAt() time.Time {
+	t := v.dev.Load().readyAt.Load()
+	if t == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, t)
+}
+
+// Ready returns nil when the device completed its warm-up period, or an
+// error wrapping ErrWarmingUp reporting the remaining time.
+func (v *I2C) Ready() error {
+	if v.dev.Load().readyAt.Load() == 0 {
+		return nil
+	}
+	if rem := time.Until(v.ReadyAt()); rem > 0 {
+		return fmt.Errorf("%w: %v remaining", ErrWarmingUp, rem.Round(time.Millisecond))
+	}
+	return nil
+}
+
+// WaitReady blocks until the device completes its warm-up period or ctx
+// is done.
+func (v *I2C) WaitReady(ctx context.Context) error {
+	return sleepCtx(ctx, time.Until(v.ReadyAt()))
+}
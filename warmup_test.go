@@ -0,0 +1,44 @@
+package i2c
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWarmUp(t *testing.T) {
+	v := NewI2CConn(NewDryRun(nil), 0x40)
+	defer v.Close()
+	v.SetWarmUp(time.Hour)
+	if _, err := v.ReadRegU8(0x10); !errors.Is(err, ErrWarmingUp) {
+		t.Fatalf("read during warm-up: got %v, want ErrWarmingUp", err)
+	}
+	if _, err := v.ReadLarge(context.Background(), make([]byte, 64), LargeTransfer{}); !errors.Is(err, ErrWarmingUp) {
+		t.Fatalf("large read during warm-up: got %v, want ErrWarmingUp", err)
+	}
+	if err := v.WriteRegU8(0x10, 1); err != nil {
+		t.Fatalf("write during warm-up: %v", err)
+	}
+	v.SetWarmUp(0)
+	if _, err := v.ReadRegU8(0x10); err != nil {
+		t.Fatalf("read after warm-up: %v", err)
+	}
+}
+
+func TestWarmUpShared(t *testing.T) {
+	open := func() *I2C {
+		v := &I2C{rc: NewDryRun(nil), bus: 99, addr: 0x40, mu: busLock(99)}
+		v.dev.Store(sharedDevice(99, 0x40))
+		return v
+	}
+	a, b := open(), open()
+	a.SetWarmUp(time.Hour)
+	if err := b.Ready(); !errors.Is(err, ErrWarmingUp) {
+		t.Fatalf("other connection: got %v, want ErrWarmingUp", err)
+	}
+	b.SetWarmUp(0)
+	if err := a.Ready(); err != nil {
+		t.Fatal(err)
+	}
+}
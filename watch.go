@@ -0,0 +1,53 @@
+package i2c
+
+import (
+	"bytes"
+	"context"
+	"time"
+)
+
+// defaultWatch is the poll interval of Watch when none is given.
+const defaultWatch = 100 * time.Millisecond
+
+// RegChange is a change of a register range observed by Watch.
+type RegChange struct {
+	Time time.Time
+	Reg  byte
+	// Old is the previous value of the range, nil for the first reading.
+	Old []byte
+	New []byte
+}
+
+// Watch polls the register ranges of regs every interval, 100ms when
+// zero, and calls fn for each range whose value changed, e.g. to observe
+// interrupt and status flags while exercising the hardware. The first
+// reading of every range is reported as a change from nil. The ranges
+// are read with BatchRead, bypassing the register cache. Watch returns
+// ctx's error when ctx is done, or the first read error. The slices of
+// the RegChange are only valid during the call to fn.
+func (v *I2C) Watch(ctx context.Context, regs []RegRead, interval time.Duration, fn func(RegChange)) error {
+	if interval <= 0 {
+		interval = defaultWatch
+	}
+	old := make([][]byte, len(regs))
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		if err := v.BatchRead(regs); err != nil {
+			return err
+		}
+		now := time.Now()
+		for i, r := range regs {
+			if old[i] != nil && bytes.Equal(old[i], r.Buf) {
+				continue
+			}
+			fn(RegChange{Time: now, Reg: r.Reg, Old: old[i], New: r.Buf})
+			old[i] = append(old[i][:0], r.Buf...)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+	}
+}